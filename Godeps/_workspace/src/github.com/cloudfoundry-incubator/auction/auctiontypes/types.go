@@ -75,6 +75,32 @@ type AuctionRepDelegate interface {
 	Stop(stopInstance models.StopLRPInstance) error
 }
 
+//AuctionRep
+//what a rep's auction server asks of it; the rep's remaining resources and
+//its instances of the process are reported with each of its start bids
+type AuctionRep interface {
+	Guid() string
+	BidForStartAuction(startAuctionInfo StartAuctionInfo) (float64, error)
+	BidForStopAuction(stopAuctionInfo StopAuctionInfo) (float64, []string, error)
+	RebidThenTentativelyReserve(startAuctionInfo StartAuctionInfo) (float64, error)
+	ReleaseReservation(startAuctionInfo StartAuctionInfo) error
+	Run(startAuction models.LRPStartAuction) error
+	Stop(stopInstance models.StopLRPInstance) error
+
+	RemainingResources() (Resources, error)
+	NumInstancesForProcessGuid(processGuid string) (int, error)
+}
+
+//simulation-only interface
+type SimulationAuctionRep interface {
+	AuctionRep
+
+	TotalResources() Resources
+	Reset()
+	SetSimulatedInstances(instances []SimulatedInstance)
+	SimulatedInstances() []SimulatedInstance
+}
+
 //simulation-only interface
 type SimulationRepPoolClient interface {
 	RepPoolClient
@@ -110,9 +136,13 @@ func NewStopAuctionInfoFromLRPStopAuction(auction models.LRPStopAuction) StopAuc
 }
 
 type StartAuctionBid struct {
-	Rep   string
-	Bid   float64
-	Error string
+	Rep       string
+	Bid       float64
+	Resources Resources //remaining on the rep; zero if the rep doesn't report them
+	//instances of the process on the rep, including reservations; from
+	//AuctionRepDelegate.NumInstancesForProcessGuid
+	NumInstances int
+	Error        string
 }
 
 type StartAuctionBids []StartAuctionBid
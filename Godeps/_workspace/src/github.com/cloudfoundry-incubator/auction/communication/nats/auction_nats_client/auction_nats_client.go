@@ -16,11 +16,19 @@ import (
 
 var RequestFailedError = errors.New("request failed")
 
+//RequestObserver is told how each request to a rep for a bid or a release went:
+//err is nil if the rep answered, nats_muxer.TimeoutError if it didn't in time,
+//and RequestFailedError if it answered with an error
+type RequestObserver func(repGuid string, err error)
+
 type AuctionNATSClient struct {
-	client     *nats_muxer.NATSMuxerClient
-	timeout    time.Duration
-	runTimeout time.Duration
-	logger     lager.Logger
+	client *nats_muxer.NATSMuxerClient
+	logger lager.Logger
+
+	timeoutLock *sync.RWMutex
+	timeout     time.Duration
+	runTimeout  time.Duration
+	observer    RequestObserver
 }
 
 func New(natsClient yagnats.NATSClient, timeout time.Duration, runTimeout time.Duration, logger lager.Logger) (*AuctionNATSClient, error) {
@@ -31,13 +39,49 @@ func New(natsClient yagnats.NATSClient, timeout time.Duration, runTimeout time.D
 	}
 
 	return &AuctionNATSClient{
-		client:     client,
-		timeout:    timeout,
-		runTimeout: runTimeout,
-		logger:     logger.Session("auction-nats-client"),
+		client:      client,
+		logger:      logger.Session("auction-nats-client"),
+		timeoutLock: &sync.RWMutex{},
+		timeout:     timeout,
+		runTimeout:  runTimeout,
 	}, nil
 }
 
+//SetTimeouts changes the timeouts of requests made from now on
+func (rep *AuctionNATSClient) SetTimeouts(timeout time.Duration, runTimeout time.Duration) {
+	rep.timeoutLock.Lock()
+	rep.timeout, rep.runTimeout = timeout, runTimeout
+	rep.timeoutLock.Unlock()
+}
+
+//SetRequestObserver has the observer told how requests made from now on went
+func (rep *AuctionNATSClient) SetRequestObserver(observer RequestObserver) {
+	rep.timeoutLock.Lock()
+	rep.observer = observer
+	rep.timeoutLock.Unlock()
+}
+
+func (rep *AuctionNATSClient) requestObserver() RequestObserver {
+	rep.timeoutLock.RLock()
+	defer rep.timeoutLock.RUnlock()
+
+	return rep.observer
+}
+
+func (rep *AuctionNATSClient) requestTimeout() time.Duration {
+	rep.timeoutLock.RLock()
+	defer rep.timeoutLock.RUnlock()
+
+	return rep.timeout
+}
+
+func (rep *AuctionNATSClient) runRequestTimeout() time.Duration {
+	rep.timeoutLock.RLock()
+	defer rep.timeoutLock.RUnlock()
+
+	return rep.runTimeout
+}
+
 func (rep *AuctionNATSClient) BidForStartAuction(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
 	bidLog := rep.logger.Session("start-bid", lager.Data{
 		"start-auction-info": startAuctionInfo,
@@ -46,13 +90,11 @@ func (rep *AuctionNATSClient) BidForStartAuction(repGuids []string, startAuction
 
 	bidLog.Info("fetching")
 
-	subjects := []string{}
-	for _, repGuid := range repGuids {
-		subjects = append(subjects, nats.NewSubjects(repGuid).BidForStartAuction)
-	}
 	payload, _ := json.Marshal(startAuctionInfo)
 
-	responses, _ := rep.aggregateWithTimeout(bidLog, subjects, payload, rep.timeout)
+	responses, _ := rep.aggregateWithTimeout(bidLog, repGuids, func(subjects nats.Subjects) string {
+		return subjects.BidForStartAuction
+	}, payload, rep.requestTimeout())
 
 	results := auctiontypes.StartAuctionBids{}
 	for _, response := range responses {
@@ -82,13 +124,11 @@ func (rep *AuctionNATSClient) BidForStopAuction(repGuids []string, stopAuctionIn
 
 	bidLog.Info("fetching")
 
-	subjects := []string{}
-	for _, repGuid := range repGuids {
-		subjects = append(subjects, nats.NewSubjects(repGuid).BidForStopAuction)
-	}
 	payload, _ := json.Marshal(stopAuctionInfo)
 
-	responses, _ := rep.aggregateWithTimeout(bidLog, subjects, payload, rep.timeout)
+	responses, _ := rep.aggregateWithTimeout(bidLog, repGuids, func(subjects nats.Subjects) string {
+		return subjects.BidForStopAuction
+	}, payload, rep.requestTimeout())
 
 	results := auctiontypes.StopAuctionBids{}
 	for _, response := range responses {
@@ -118,16 +158,11 @@ func (rep *AuctionNATSClient) RebidThenTentativelyReserve(repGuids []string, sta
 
 	bidLog.Info("fetching")
 
-	subjects := []string{}
-	subjectToRepGuid := map[string]string{}
-	for _, repGuid := range repGuids {
-		subject := nats.NewSubjects(repGuid).RebidThenTentativelyReserve
-		subjects = append(subjects, subject)
-		subjectToRepGuid[subject] = repGuid
-	}
 	payload, _ := json.Marshal(startAuctionInfo)
 
-	responses, failedSubjects := rep.aggregateWithTimeout(bidLog, subjects, payload, rep.timeout)
+	responses, failedRepGuids := rep.aggregateWithTimeout(bidLog, repGuids, func(subjects nats.Subjects) string {
+		return subjects.RebidThenTentativelyReserve
+	}, payload, rep.requestTimeout())
 
 	results := auctiontypes.StartAuctionBids{}
 	for _, response := range responses {
@@ -142,13 +177,8 @@ func (rep *AuctionNATSClient) RebidThenTentativelyReserve(repGuids []string, sta
 		results = append(results, bid)
 	}
 
-	if len(failedSubjects) > 0 {
-		releaseGuids := []string{}
-		for _, failedSubject := range failedSubjects {
-			releaseGuids = append(releaseGuids, subjectToRepGuid[failedSubject])
-		}
-
-		rep.ReleaseReservation(releaseGuids, startAuctionInfo)
+	if len(failedRepGuids) > 0 {
+		rep.ReleaseReservation(failedRepGuids, startAuctionInfo)
 	}
 
 	bidLog.Info("fetched", lager.Data{
@@ -166,14 +196,11 @@ func (rep *AuctionNATSClient) ReleaseReservation(repGuids []string, startAuction
 
 	releaseLog.Info("starting")
 
-	subjects := []string{}
-	for _, repGuid := range repGuids {
-		subjects = append(subjects, nats.NewSubjects(repGuid).ReleaseReservation)
-	}
-
 	payload, _ := json.Marshal(startAuctionInfo)
 
-	rep.aggregateWithTimeout(releaseLog, subjects, payload, rep.timeout)
+	rep.aggregateWithTimeout(releaseLog, repGuids, func(subjects nats.Subjects) string {
+		return subjects.ReleaseReservation
+	}, payload, rep.requestTimeout())
 
 	releaseLog.Info("done")
 }
@@ -188,7 +215,7 @@ func (rep *AuctionNATSClient) Run(repGuid string, startAuction models.LRPStartAu
 
 	subjects := nats.NewSubjects(repGuid)
 	payload, _ := json.Marshal(startAuction)
-	_, err := rep.publishWithTimeout(subjects.Run, payload, rep.runTimeout)
+	_, err := rep.publishWithTimeout(subjects.Run, payload, rep.runRequestTimeout())
 
 	if err != nil {
 		runLog.Error("failed-to-publish", err)
@@ -209,7 +236,7 @@ func (rep *AuctionNATSClient) Stop(repGuid string, stopInstance models.StopLRPIn
 	subjects := nats.NewSubjects(repGuid)
 	payload, _ := json.Marshal(stopInstance)
 
-	_, err := rep.publishWithTimeout(subjects.Stop, payload, rep.timeout)
+	_, err := rep.publishWithTimeout(subjects.Stop, payload, rep.requestTimeout())
 
 	if err != nil {
 		stopLog.Error("failed-to-publish", err)
@@ -232,24 +259,32 @@ func (rep *AuctionNATSClient) publishWithTimeout(subject string, payload []byte,
 	return response, nil
 }
 
-func (rep *AuctionNATSClient) aggregateWithTimeout(logger lager.Logger, subjects []string, payload []byte, timeout time.Duration) ([][]byte, []string) {
+//aggregateWithTimeout makes the request of every rep at once, and returns
+//the responses along with the reps that failed to respond
+func (rep *AuctionNATSClient) aggregateWithTimeout(logger lager.Logger, repGuids []string, subject func(nats.Subjects) string, payload []byte, timeout time.Duration) ([][]byte, []string) {
 	allReceived := new(sync.WaitGroup)
-	allReceived.Add(len(subjects))
+	allReceived.Add(len(repGuids))
+
+	observer := rep.requestObserver()
 
 	lock := &sync.Mutex{}
 	results := [][]byte{}
 	failed := []string{}
 
-	for _, subject := range subjects {
-		go func(subject string) {
+	for _, repGuid := range repGuids {
+		go func(repGuid string) {
 			defer allReceived.Done()
 
-			result, err := rep.publishWithTimeout(subject, payload, timeout)
+			result, err := rep.publishWithTimeout(subject(nats.NewSubjects(repGuid)), payload, timeout)
+			if observer != nil {
+				observer(repGuid, err)
+			}
+
 			if err != nil {
-				logger.Error("aggregate-request-publish-failed", err)
+				logger.Error("aggregate-request-publish-failed", err, lager.Data{"rep-guid": repGuid})
 
 				lock.Lock()
-				failed = append(failed, subject)
+				failed = append(failed, repGuid)
 				lock.Unlock()
 
 				return
@@ -258,7 +293,7 @@ func (rep *AuctionNATSClient) aggregateWithTimeout(logger lager.Logger, subjects
 			lock.Lock()
 			results = append(results, result)
 			lock.Unlock()
-		}(subject)
+		}(repGuid)
 	}
 
 	allReceived.Wait()
@@ -271,7 +306,7 @@ func (rep *AuctionNATSClient) aggregateWithTimeout(logger lager.Logger, subjects
 func (rep *AuctionNATSClient) TotalResources(repGuid string) auctiontypes.Resources {
 	var totalResources auctiontypes.Resources
 	subjects := nats.NewSubjects(repGuid)
-	response, err := rep.publishWithTimeout(subjects.TotalResources, nil, rep.timeout)
+	response, err := rep.publishWithTimeout(subjects.TotalResources, nil, rep.requestTimeout())
 	if err != nil {
 		//test only, so panic is OK
 		panic(err)
@@ -289,7 +324,7 @@ func (rep *AuctionNATSClient) TotalResources(repGuid string) auctiontypes.Resour
 func (rep *AuctionNATSClient) SimulatedInstances(repGuid string) []auctiontypes.SimulatedInstance {
 	var instances []auctiontypes.SimulatedInstance
 	subjects := nats.NewSubjects(repGuid)
-	response, err := rep.publishWithTimeout(subjects.SimulatedInstances, nil, rep.timeout)
+	response, err := rep.publishWithTimeout(subjects.SimulatedInstances, nil, rep.requestTimeout())
 	if err != nil {
 		//test only, so panic is OK
 		panic(err)
@@ -306,7 +341,7 @@ func (rep *AuctionNATSClient) SimulatedInstances(repGuid string) []auctiontypes.
 
 func (rep *AuctionNATSClient) Reset(repGuid string) {
 	subjects := nats.NewSubjects(repGuid)
-	_, err := rep.publishWithTimeout(subjects.Reset, nil, rep.timeout)
+	_, err := rep.publishWithTimeout(subjects.Reset, nil, rep.requestTimeout())
 	if err != nil {
 		//test only, so panic is OK
 		panic(err)
@@ -316,7 +351,7 @@ func (rep *AuctionNATSClient) Reset(repGuid string) {
 func (rep *AuctionNATSClient) SetSimulatedInstances(repGuid string, instances []auctiontypes.SimulatedInstance) {
 	subjects := nats.NewSubjects(repGuid)
 	payload, _ := json.Marshal(instances)
-	_, err := rep.publishWithTimeout(subjects.SetSimulatedInstances, payload, rep.timeout)
+	_, err := rep.publishWithTimeout(subjects.SetSimulatedInstances, payload, rep.requestTimeout())
 	if err != nil {
 		//test only, so panic is OK
 		panic(err)
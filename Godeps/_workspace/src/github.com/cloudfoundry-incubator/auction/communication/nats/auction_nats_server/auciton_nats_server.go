@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"os"
 
-	"github.com/cloudfoundry-incubator/auction/auctionrep"
 	"github.com/cloudfoundry-incubator/auction/auctiontypes"
 	"github.com/cloudfoundry-incubator/auction/communication/nats"
 	"github.com/cloudfoundry-incubator/auction/communication/nats/nats_muxer"
@@ -18,12 +17,12 @@ var successResponse = []byte("ok")
 
 type AuctionNATSServer struct {
 	repGuid string
-	rep     *auctionrep.AuctionRep
+	rep     auctiontypes.SimulationAuctionRep
 	client  yagnats.NATSClient
 	logger  lager.Logger
 }
 
-func New(client yagnats.NATSClient, rep *auctionrep.AuctionRep, logger lager.Logger) *AuctionNATSServer {
+func New(client yagnats.NATSClient, rep auctiontypes.SimulationAuctionRep, logger lager.Logger) *AuctionNATSServer {
 	return &AuctionNATSServer{
 		repGuid: rep.Guid(),
 		rep:     rep,
@@ -74,18 +73,7 @@ func (s *AuctionNATSServer) start(subjects nats.Subjects) {
 			return errorResponse
 		}
 
-		response := auctiontypes.StartAuctionBid{
-			Rep: s.repGuid,
-		}
-
-		bid, err := s.rep.BidForStartAuction(inst)
-		if err != nil {
-			response.Error = err.Error()
-		} else {
-			response.Bid = bid
-		}
-
-		out, _ := json.Marshal(response)
+		out, _ := json.Marshal(s.startAuctionBid(inst, s.rep.BidForStartAuction))
 		return out
 	})
 
@@ -131,18 +119,7 @@ func (s *AuctionNATSServer) start(subjects nats.Subjects) {
 			return errorResponse
 		}
 
-		response := auctiontypes.StartAuctionBid{
-			Rep: s.repGuid,
-		}
-
-		bid, err := s.rep.RebidThenTentativelyReserve(inst)
-		if err != nil {
-			response.Error = err.Error()
-		} else {
-			response.Bid = bid
-		}
-
-		out, _ := json.Marshal(response)
+		out, _ := json.Marshal(s.startAuctionBid(inst, s.rep.RebidThenTentativelyReserve))
 		return out
 	})
 
@@ -225,6 +202,38 @@ func (s *AuctionNATSServer) start(subjects nats.Subjects) {
 	})
 }
 
+// the rep's remaining resources and instances are taken before it bids, so
+// that a rebid reports them as they were before its reservation
+func (s *AuctionNATSServer) startAuctionBid(inst auctiontypes.StartAuctionInfo, bid func(auctiontypes.StartAuctionInfo) (float64, error)) auctiontypes.StartAuctionBid {
+	response := auctiontypes.StartAuctionBid{
+		Rep: s.repGuid,
+	}
+
+	resources, err := s.rep.RemainingResources()
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	numInstances, err := s.rep.NumInstancesForProcessGuid(inst.ProcessGuid)
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	score, err := bid(inst)
+	if err != nil {
+		response.Error = err.Error()
+		return response
+	}
+
+	response.Bid = score
+	response.Resources = resources
+	response.NumInstances = numInstances
+
+	return response
+}
+
 func (s *AuctionNATSServer) stop(subjects nats.Subjects) {
 	for _, topic := range subjects.Slice() {
 		s.client.UnsubscribeAll(topic)
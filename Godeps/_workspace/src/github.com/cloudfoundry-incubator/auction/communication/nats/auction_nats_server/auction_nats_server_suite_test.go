@@ -0,0 +1,13 @@
+package auction_nats_server_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAuctionNATSServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Auction NATS Server Suite")
+}
@@ -0,0 +1,167 @@
+package auction_nats_server_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/auction/communication/nats/auction_nats_client"
+	. "github.com/cloudfoundry-incubator/auction/communication/nats/auction_nats_server"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/yagnats/fakeyagnats"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeRep struct {
+	bid                float64
+	bidErr             error
+	remainingResources auctiontypes.Resources
+	resourcesErr       error
+	numInstances       map[string]int
+
+	reserved []auctiontypes.StartAuctionInfo
+}
+
+func (r *fakeRep) Guid() string {
+	return "rep-guid"
+}
+
+func (r *fakeRep) BidForStartAuction(auctiontypes.StartAuctionInfo) (float64, error) {
+	return r.bid, r.bidErr
+}
+
+func (r *fakeRep) BidForStopAuction(auctiontypes.StopAuctionInfo) (float64, []string, error) {
+	return 0, nil, nil
+}
+
+func (r *fakeRep) RebidThenTentativelyReserve(info auctiontypes.StartAuctionInfo) (float64, error) {
+	if r.bidErr != nil {
+		return 0, r.bidErr
+	}
+
+	r.reserved = append(r.reserved, info)
+	r.numInstances[info.ProcessGuid]++
+	r.remainingResources.MemoryMB -= info.MemoryMB
+	r.remainingResources.DiskMB -= info.DiskMB
+	r.remainingResources.Containers--
+
+	return r.bid, nil
+}
+
+func (r *fakeRep) ReleaseReservation(auctiontypes.StartAuctionInfo) error { return nil }
+func (r *fakeRep) Run(models.LRPStartAuction) error                       { return nil }
+func (r *fakeRep) Stop(models.StopLRPInstance) error                      { return nil }
+
+func (r *fakeRep) RemainingResources() (auctiontypes.Resources, error) {
+	return r.remainingResources, r.resourcesErr
+}
+
+func (r *fakeRep) NumInstancesForProcessGuid(processGuid string) (int, error) {
+	return r.numInstances[processGuid], nil
+}
+
+func (r *fakeRep) TotalResources() auctiontypes.Resources                 { return auctiontypes.Resources{} }
+func (r *fakeRep) Reset()                                                 {}
+func (r *fakeRep) SetSimulatedInstances([]auctiontypes.SimulatedInstance) {}
+func (r *fakeRep) SimulatedInstances() []auctiontypes.SimulatedInstance   { return nil }
+
+var _ = Describe("AuctionNATSServer", func() {
+	var (
+		rep     *fakeRep
+		client  *auction_nats_client.AuctionNATSClient
+		signals chan os.Signal
+		info    auctiontypes.StartAuctionInfo
+	)
+
+	BeforeEach(func() {
+		rep = &fakeRep{
+			bid:                0.25,
+			remainingResources: auctiontypes.Resources{MemoryMB: 1024, DiskMB: 2048, Containers: 10},
+			numInstances:       map[string]int{"process-guid": 2, "other-process-guid": 3},
+		}
+
+		info = auctiontypes.StartAuctionInfo{
+			ProcessGuid:  "process-guid",
+			InstanceGuid: "instance-guid",
+			MemoryMB:     256,
+			DiskMB:       512,
+		}
+
+		natsClient := fakeyagnats.New()
+		logger := lagertest.NewTestLogger("test")
+
+		var err error
+		client, err = auction_nats_client.New(natsClient, time.Second, time.Second, logger)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		server := New(natsClient, rep, logger)
+
+		signals = make(chan os.Signal)
+		ready := make(chan struct{})
+		go server.Run(signals, ready)
+		Eventually(ready).Should(BeClosed())
+	})
+
+	AfterEach(func() {
+		close(signals)
+	})
+
+	Describe("bidding for a start auction", func() {
+		It("reports the rep's remaining resources and instances of the process with its bid", func() {
+			bids := client.BidForStartAuction([]string{"rep-guid"}, info)
+			Ω(bids).Should(Equal(auctiontypes.StartAuctionBids{
+				{
+					Rep:          "rep-guid",
+					Bid:          0.25,
+					Resources:    auctiontypes.Resources{MemoryMB: 1024, DiskMB: 2048, Containers: 10},
+					NumInstances: 2,
+				},
+			}))
+		})
+
+		Context("when the rep can't tell its remaining resources", func() {
+			BeforeEach(func() {
+				rep.resourcesErr = errors.New("oops")
+			})
+
+			It("reports the error", func() {
+				bids := client.BidForStartAuction([]string{"rep-guid"}, info)
+				Ω(bids).Should(HaveLen(1))
+				Ω(bids[0].Error).Should(Equal("oops"))
+			})
+		})
+
+		Context("when the rep doesn't bid", func() {
+			BeforeEach(func() {
+				rep.bidErr = errors.New("insufficient resources")
+			})
+
+			It("reports the error and no resources", func() {
+				bids := client.BidForStartAuction([]string{"rep-guid"}, info)
+				Ω(bids).Should(Equal(auctiontypes.StartAuctionBids{
+					{Rep: "rep-guid", Error: "insufficient resources"},
+				}))
+			})
+		})
+	})
+
+	Describe("rebidding then tentatively reserving", func() {
+		It("reports the rep's remaining resources and instances as they were before the reservation", func() {
+			bids := client.RebidThenTentativelyReserve([]string{"rep-guid"}, info)
+			Ω(bids).Should(Equal(auctiontypes.StartAuctionBids{
+				{
+					Rep:          "rep-guid",
+					Bid:          0.25,
+					Resources:    auctiontypes.Resources{MemoryMB: 1024, DiskMB: 2048, Containers: 10},
+					NumInstances: 2,
+				},
+			}))
+
+			Ω(rep.reserved).Should(Equal([]auctiontypes.StartAuctionInfo{info}))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package bbs
 import (
 	"time"
 
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/control_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/lock_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/lrp_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/services_bbs"
@@ -55,6 +56,9 @@ type ConvergerBBS interface {
 type TPSBBS interface {
 	//lrp
 	GetActualLRPsByProcessGuid(string) ([]models.ActualLRP, error)
+
+	//start auction
+	GetFailedLRPStartAuctionsByProcessGuid(string) ([]models.FailedLRPStartAuction, error)
 }
 
 type AppManagerBBS interface {
@@ -84,19 +88,40 @@ type NsyncBBS interface {
 type AuctioneerBBS interface {
 	//services
 	GetAllExecutors() ([]models.ExecutorPresence, error)
+	WatchForExecutorChanges() (<-chan models.ExecutorPresenceChange, chan<- bool, <-chan error)
+
+	//lrp
+	GetDesiredLRPByProcessGuid(processGuid string) (models.DesiredLRP, error)
+	GetAllActualLRPs() ([]models.ActualLRP, error)
+	GetActualLRPsByProcessGuid(string) ([]models.ActualLRP, error)
+	RequestStopLRPInstance(stopInstance models.StopLRPInstance) error
 
 	//start auction
+	RequestLRPStartAuction(models.LRPStartAuction) error
 	WatchForLRPStartAuction() (<-chan models.LRPStartAuction, chan<- bool, <-chan error)
+	GetAllLRPStartAuctions() ([]models.LRPStartAuction, error)
 	ClaimLRPStartAuction(models.LRPStartAuction) error
+	RequeueLRPStartAuction(models.LRPStartAuction) error
 	ResolveLRPStartAuction(models.LRPStartAuction) error
+	RecordFailedLRPStartAuction(failure models.FailedLRPStartAuction, ttl time.Duration) error
+	RemoveFailedLRPStartAuction(models.LRPStartAuction) error
 
 	//stop auction
+	RequestLRPStopAuction(models.LRPStopAuction) error
 	WatchForLRPStopAuction() (<-chan models.LRPStopAuction, chan<- bool, <-chan error)
+	GetAllLRPStopAuctions() ([]models.LRPStopAuction, error)
 	ClaimLRPStopAuction(models.LRPStopAuction) error
 	ResolveLRPStopAuction(models.LRPStopAuction) error
 
 	//lock
 	MaintainAuctioneerLock(interval time.Duration, auctioneerID string) (<-chan bool, chan<- chan bool, error)
+
+	//control
+	GetAuctioneerPause() (models.AuctioneerPause, error)
+	SetAuctioneerPause(models.AuctioneerPause) error
+	GetCordonedExecutors() ([]models.CordonedExecutor, error)
+	CordonExecutor(models.CordonedExecutor) error
+	UncordonExecutor(executorID string) error
 }
 
 type StagerBBS interface {
@@ -114,6 +139,9 @@ type MetricsBBS interface {
 	//task
 	GetAllTasks() ([]models.Task, error)
 
+	//start auction
+	GetAllFailedLRPStartAuctions() ([]models.FailedLRPStartAuction, error)
+
 	//services
 	GetServiceRegistrations() (models.ServiceRegistrations, error)
 }
@@ -180,6 +208,7 @@ func NewTPSBBS(store storeadapter.StoreAdapter, timeProvider timeprovider.TimePr
 func NewBBS(store storeadapter.StoreAdapter, timeProvider timeprovider.TimeProvider, logger lager.Logger) *BBS {
 	return &BBS{
 		LockBBS:         lock_bbs.New(store),
+		ControlBBS:      control_bbs.New(store, timeProvider),
 		LRPBBS:          lrp_bbs.New(store, timeProvider, logger.Session("lrp-bbs")),
 		StartAuctionBBS: start_auction_bbs.New(store, timeProvider, logger.Session("lrp-start-auction-bbs")),
 		StopAuctionBBS:  stop_auction_bbs.New(store, timeProvider, logger.Session("lrp-stop-auction-bbs")),
@@ -190,6 +219,7 @@ func NewBBS(store storeadapter.StoreAdapter, timeProvider timeprovider.TimeProvi
 
 type BBS struct {
 	*lock_bbs.LockBBS
+	*control_bbs.ControlBBS
 	*lrp_bbs.LRPBBS
 	*start_auction_bbs.StartAuctionBBS
 	*stop_auction_bbs.StopAuctionBBS
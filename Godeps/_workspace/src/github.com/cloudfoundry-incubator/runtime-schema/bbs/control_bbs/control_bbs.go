@@ -0,0 +1,20 @@
+package control_bbs
+
+import (
+	"github.com/cloudfoundry/gunk/timeprovider"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+// ControlBBS holds what operators have told the auctioneer to do, so that
+// whichever auctioneer holds the lock does it
+type ControlBBS struct {
+	store        storeadapter.StoreAdapter
+	timeProvider timeprovider.TimeProvider
+}
+
+func New(store storeadapter.StoreAdapter, timeProvider timeprovider.TimeProvider) *ControlBBS {
+	return &ControlBBS{
+		store:        store,
+		timeProvider: timeProvider,
+	}
+}
@@ -0,0 +1,61 @@
+package control_bbs
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+// CordonExecutor replaces any earlier cordon of the same executor
+func (bbs *ControlBBS) CordonExecutor(cordon models.CordonedExecutor) error {
+	if cordon.CordonedAt == 0 {
+		cordon.CordonedAt = bbs.timeProvider.Time().UnixNano()
+	}
+
+	return shared.RetryIndefinitelyOnStoreTimeout(func() error {
+		return bbs.store.SetMulti([]storeadapter.StoreNode{
+			{
+				Key:   shared.CordonedExecutorSchemaPath(cordon.ExecutorID),
+				Value: cordon.ToJSON(),
+			},
+		})
+	})
+}
+
+// UncordonExecutor does nothing if the executor isn't cordoned
+func (bbs *ControlBBS) UncordonExecutor(executorID string) error {
+	err := shared.RetryIndefinitelyOnStoreTimeout(func() error {
+		return bbs.store.Delete(shared.CordonedExecutorSchemaPath(executorID))
+	})
+	if err == storeadapter.ErrorKeyNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (bbs *ControlBBS) GetCordonedExecutors() ([]models.CordonedExecutor, error) {
+	cordons := []models.CordonedExecutor{}
+
+	node, err := bbs.store.ListRecursively(shared.CordonedExecutorSchemaRoot)
+	if err == storeadapter.ErrorKeyNotFound {
+		return cordons, nil
+	}
+
+	if err != nil {
+		return cordons, err
+	}
+
+	for _, node := range node.ChildNodes {
+		cordon, err := models.NewCordonedExecutorFromJSON(node.Value)
+		if err != nil {
+			return cordons, fmt.Errorf("cannot parse cordoned executor JSON for key %s: %s", node.Key, err.Error())
+		}
+
+		cordons = append(cordons, cordon)
+	}
+
+	return cordons, nil
+}
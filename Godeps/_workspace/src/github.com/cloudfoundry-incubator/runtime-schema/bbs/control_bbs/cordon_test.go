@@ -0,0 +1,151 @@
+package control_bbs_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+var _ = Describe("Cordoned Executors", func() {
+	Describe("CordonExecutor", func() {
+		It("creates /v1/auctioneer/cordoned/<executor-id>, stamped with when it was cordoned", func() {
+			cordon := models.CordonedExecutor{ExecutorID: "executor-a"}
+
+			err := bbs.CordonExecutor(cordon)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/auctioneer/cordoned/executor-a")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			cordon.CordonedAt = timeProvider.Time().UnixNano()
+			Ω(node.Value).Should(Equal(cordon.ToJSON()))
+		})
+
+		It("keeps the time it was cordoned if it is given one", func() {
+			cordon := models.CordonedExecutor{ExecutorID: "executor-a", CordonedAt: 42}
+
+			err := bbs.CordonExecutor(cordon)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/auctioneer/cordoned/executor-a")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(node.Value).Should(Equal(cordon.ToJSON()))
+		})
+
+		It("replaces an earlier cordon of the same executor", func() {
+			err := bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-a"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			timeProvider.Increment(time.Minute)
+
+			err = bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-a"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			cordons, err := bbs.GetCordonedExecutors()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(cordons).Should(Equal([]models.CordonedExecutor{
+				{ExecutorID: "executor-a", CordonedAt: timeProvider.Time().UnixNano()},
+			}))
+		})
+
+		Context("when the store is out of commission", func() {
+			itRetriesUntilStoreComesBack(func() error {
+				return bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-a"})
+			})
+		})
+	})
+
+	Describe("UncordonExecutor", func() {
+		Context("when the executor is cordoned", func() {
+			BeforeEach(func() {
+				err := bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-a"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-b"})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("removes its cordon, and no other", func() {
+				err := bbs.UncordonExecutor("executor-a")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = etcdClient.Get("/v1/auctioneer/cordoned/executor-a")
+				Ω(err).Should(Equal(storeadapter.ErrorKeyNotFound))
+
+				_, err = etcdClient.Get("/v1/auctioneer/cordoned/executor-b")
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			Context("when the store is out of commission", func() {
+				itRetriesUntilStoreComesBack(func() error {
+					return bbs.UncordonExecutor("executor-a")
+				})
+			})
+		})
+
+		Context("when the executor is not cordoned", func() {
+			It("does not error", func() {
+				err := bbs.UncordonExecutor("executor-a")
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("GetCordonedExecutors", func() {
+		Context("when no executor is cordoned", func() {
+			It("returns no cordons", func() {
+				cordons, err := bbs.GetCordonedExecutors()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(cordons).Should(BeEmpty())
+			})
+		})
+
+		Context("when executors are cordoned", func() {
+			BeforeEach(func() {
+				err := bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-a"})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				err = bbs.CordonExecutor(models.CordonedExecutor{ExecutorID: "executor-b", CordonedAt: 42})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns every cordon", func() {
+				cordons, err := bbs.GetCordonedExecutors()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(cordons).Should(ConsistOf(
+					models.CordonedExecutor{ExecutorID: "executor-a", CordonedAt: timeProvider.Time().UnixNano()},
+					models.CordonedExecutor{ExecutorID: "executor-b", CordonedAt: 42},
+				))
+			})
+
+			It("no longer returns an executor once it is uncordoned", func() {
+				err := bbs.UncordonExecutor("executor-a")
+				Ω(err).ShouldNot(HaveOccurred())
+
+				cordons, err := bbs.GetCordonedExecutors()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(cordons).Should(Equal([]models.CordonedExecutor{
+					{ExecutorID: "executor-b", CordonedAt: 42},
+				}))
+			})
+
+			Context("when a cordon can't be parsed", func() {
+				BeforeEach(func() {
+					err := etcdClient.SetMulti([]storeadapter.StoreNode{
+						{Key: "/v1/auctioneer/cordoned/executor-c", Value: []byte("ß")},
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+
+				It("errors", func() {
+					_, err := bbs.GetCordonedExecutors()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+	})
+})
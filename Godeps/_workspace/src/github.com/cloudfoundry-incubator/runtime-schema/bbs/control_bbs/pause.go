@@ -0,0 +1,37 @@
+package control_bbs
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+// GetAuctioneerPause returns the zero pause, with nothing paused, if none has
+// been set
+func (bbs *ControlBBS) GetAuctioneerPause() (models.AuctioneerPause, error) {
+	node, err := bbs.store.Get(shared.AuctioneerPauseSchemaPath)
+	if err == storeadapter.ErrorKeyNotFound {
+		return models.AuctioneerPause{}, nil
+	}
+
+	if err != nil {
+		return models.AuctioneerPause{}, err
+	}
+
+	return models.NewAuctioneerPauseFromJSON(node.Value)
+}
+
+func (bbs *ControlBBS) SetAuctioneerPause(pause models.AuctioneerPause) error {
+	if pause.UpdatedAt == 0 {
+		pause.UpdatedAt = bbs.timeProvider.Time().UnixNano()
+	}
+
+	return shared.RetryIndefinitelyOnStoreTimeout(func() error {
+		return bbs.store.SetMulti([]storeadapter.StoreNode{
+			{
+				Key:   shared.AuctioneerPauseSchemaPath,
+				Value: pause.ToJSON(),
+			},
+		})
+	})
+}
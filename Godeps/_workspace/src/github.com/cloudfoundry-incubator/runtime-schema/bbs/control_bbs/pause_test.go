@@ -0,0 +1,104 @@
+package control_bbs_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+var _ = Describe("Auctioneer Pause", func() {
+	Describe("SetAuctioneerPause", func() {
+		It("creates /v1/auctioneer/pause, stamped with when it was set", func() {
+			pause := models.AuctioneerPause{StartAuctions: true}
+
+			err := bbs.SetAuctioneerPause(pause)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/auctioneer/pause")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			pause.UpdatedAt = timeProvider.Time().UnixNano()
+			Ω(node.Value).Should(Equal(pause.ToJSON()))
+		})
+
+		It("keeps the time it was set if it is given one", func() {
+			pause := models.AuctioneerPause{StopAuctions: true, UpdatedAt: 42}
+
+			err := bbs.SetAuctioneerPause(pause)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/auctioneer/pause")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(node.Value).Should(Equal(pause.ToJSON()))
+		})
+
+		Context("when the store is out of commission", func() {
+			itRetriesUntilStoreComesBack(func() error {
+				return bbs.SetAuctioneerPause(models.AuctioneerPause{StartAuctions: true})
+			})
+		})
+	})
+
+	Describe("GetAuctioneerPause", func() {
+		Context("when no pause has been set", func() {
+			It("returns the zero pause, with nothing paused", func() {
+				pause, err := bbs.GetAuctioneerPause()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(pause).Should(Equal(models.AuctioneerPause{}))
+			})
+		})
+
+		Context("when the pause can't be parsed", func() {
+			BeforeEach(func() {
+				err := etcdClient.SetMulti([]storeadapter.StoreNode{
+					{Key: "/v1/auctioneer/pause", Value: []byte("ß")},
+				})
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("errors", func() {
+				_, err := bbs.GetAuctioneerPause()
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("pausing and resuming", func() {
+		It("reads back what was set each time", func() {
+			paused := models.AuctioneerPause{StartAuctions: true, StopAuctions: true}
+			err := bbs.SetAuctioneerPause(paused)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			pause, err := bbs.GetAuctioneerPause()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(pause.StartAuctions).Should(BeTrue())
+			Ω(pause.StopAuctions).Should(BeTrue())
+			Ω(pause.UpdatedAt).Should(Equal(timeProvider.Time().UnixNano()))
+
+			timeProvider.Increment(time.Minute)
+
+			resumedStarts := models.AuctioneerPause{StopAuctions: true}
+			err = bbs.SetAuctioneerPause(resumedStarts)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			pause, err = bbs.GetAuctioneerPause()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(pause.StartAuctions).Should(BeFalse())
+			Ω(pause.StopAuctions).Should(BeTrue())
+			Ω(pause.UpdatedAt).Should(Equal(timeProvider.Time().UnixNano()))
+
+			timeProvider.Increment(time.Minute)
+
+			err = bbs.SetAuctioneerPause(models.AuctioneerPause{})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			pause, err = bbs.GetAuctioneerPause()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(pause).Should(Equal(models.AuctioneerPause{UpdatedAt: timeProvider.Time().UnixNano()}))
+		})
+	})
+})
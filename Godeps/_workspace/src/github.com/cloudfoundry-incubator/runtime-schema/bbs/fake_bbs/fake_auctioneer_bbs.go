@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
 )
 
 type FakeAuctioneerBBS struct {
@@ -14,10 +15,23 @@ type FakeAuctioneerBBS struct {
 	LRPStartAuctionStopChan  chan bool
 	LRPStartAuctionErrorChan chan error
 
+	LRPStartAuctions    []models.LRPStartAuction
+	LRPStartAuctionsErr error
+
+	RequestedLRPStartAuctions      []models.LRPStartAuction
+	RequestLRPStartAuctionError    error
+	WhenRequestingLRPStartAuctions func(models.LRPStartAuction) error //called with the fake locked
+
 	LRPStopAuctionChan      chan models.LRPStopAuction
 	LRPStopAuctionStopChan  chan bool
 	LRPStopAuctionErrorChan chan error
 
+	LRPStopAuctions    []models.LRPStopAuction
+	LRPStopAuctionsErr error
+
+	RequestedLRPStopAuctions   []models.LRPStopAuction
+	RequestLRPStopAuctionError error
+
 	LockChannel        chan bool
 	ReleaseLockChannel chan chan bool
 	LockError          error
@@ -25,16 +39,52 @@ type FakeAuctioneerBBS struct {
 	ClaimedLRPStartAuctions   []models.LRPStartAuction
 	ClaimLRPStartAuctionError error
 
+	RequeuedLRPStartAuctions    []models.LRPStartAuction
+	RequeueLRPStartAuctionError error
+
 	ResolvedLRPStartAuction     models.LRPStartAuction
 	ResolveLRPStartAuctionError error
 
+	FailedLRPStartAuctions           []models.FailedLRPStartAuction
+	FailedLRPStartAuctionTTL         time.Duration
+	RecordFailedLRPStartAuctionError error
+	RemovedFailedLRPStartAuctions    []models.LRPStartAuction
+	RemoveFailedLRPStartAuctionError error
+
+	WhenRecordingFailedLRPStartAuction func(models.FailedLRPStartAuction) //called without the fake locked
+
 	ClaimedLRPStopAuctions   []models.LRPStopAuction
 	ClaimLRPStopAuctionError error
 
 	ResolvedLRPStopAuction     models.LRPStopAuction
 	ResolveLRPStopAuctionError error
 
-	Executors []models.ExecutorPresence
+	Executors    []models.ExecutorPresence
+	ExecutorsErr error
+
+	ExecutorChangeChan      chan models.ExecutorPresenceChange
+	ExecutorChangeStopChan  chan bool
+	ExecutorChangeErrorChan chan error
+
+	DesiredLRPs   []models.DesiredLRP
+	DesiredLRPErr error
+
+	ActualLRPs                         []models.ActualLRP
+	ActualLRPsErr                      error
+	WhenGettingActualLRPsByProcessGuid func(processGuid string) //called without the fake locked
+
+	RequestedStopLRPInstances   []models.StopLRPInstance
+	RequestStopLRPInstanceError error
+
+	AuctioneerPause         models.AuctioneerPause
+	GetAuctioneerPauseError error
+	SetAuctioneerPauseError error
+
+	WhenGettingAuctioneerPause func(models.AuctioneerPause) //called without the fake locked
+
+	CordonedExecutors         map[string]models.CordonedExecutor
+	GetCordonedExecutorsError error
+	CordonExecutorError       error
 }
 
 func NewFakeAuctioneerBBS() *FakeAuctioneerBBS {
@@ -46,6 +96,9 @@ func NewFakeAuctioneerBBS() *FakeAuctioneerBBS {
 		LRPStopAuctionChan:       make(chan models.LRPStopAuction),
 		LRPStopAuctionStopChan:   make(chan bool),
 		LRPStopAuctionErrorChan:  make(chan error),
+		ExecutorChangeChan:       make(chan models.ExecutorPresenceChange),
+		ExecutorChangeStopChan:   make(chan bool),
+		ExecutorChangeErrorChan:  make(chan error),
 		LockChannel:              make(chan bool),
 		ReleaseLockChannel:       make(chan chan bool),
 	}
@@ -58,7 +111,103 @@ func (bbs *FakeAuctioneerBBS) MaintainAuctioneerLock(interval time.Duration, auc
 func (bbs *FakeAuctioneerBBS) GetAllExecutors() ([]models.ExecutorPresence, error) {
 	bbs.Lock()
 	defer bbs.Unlock()
-	return bbs.Executors, nil
+	return bbs.Executors, bbs.ExecutorsErr
+}
+
+func (bbs *FakeAuctioneerBBS) WatchForExecutorChanges() (<-chan models.ExecutorPresenceChange, chan<- bool, <-chan error) {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.ExecutorChangeChan, bbs.ExecutorChangeStopChan, bbs.ExecutorChangeErrorChan
+}
+
+func (bbs *FakeAuctioneerBBS) GetDesiredLRPByProcessGuid(processGuid string) (models.DesiredLRP, error) {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	if bbs.DesiredLRPErr != nil {
+		return models.DesiredLRP{}, bbs.DesiredLRPErr
+	}
+
+	for _, lrp := range bbs.DesiredLRPs {
+		if lrp.ProcessGuid == processGuid {
+			return lrp, nil
+		}
+	}
+
+	return models.DesiredLRP{}, storeadapter.ErrorKeyNotFound
+}
+
+func (bbs *FakeAuctioneerBBS) GetAllActualLRPs() ([]models.ActualLRP, error) {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.ActualLRPs, bbs.ActualLRPsErr
+}
+
+func (bbs *FakeAuctioneerBBS) GetActualLRPsByProcessGuid(processGuid string) ([]models.ActualLRP, error) {
+	bbs.Lock()
+	when := bbs.WhenGettingActualLRPsByProcessGuid
+	bbs.Unlock()
+
+	if when != nil {
+		when(processGuid)
+	}
+
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	lrps := []models.ActualLRP{}
+	for _, lrp := range bbs.ActualLRPs {
+		if lrp.ProcessGuid == processGuid {
+			lrps = append(lrps, lrp)
+		}
+	}
+
+	return lrps, bbs.ActualLRPsErr
+}
+
+func (bbs *FakeAuctioneerBBS) RequestStopLRPInstance(stopInstance models.StopLRPInstance) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	if bbs.RequestStopLRPInstanceError != nil {
+		return bbs.RequestStopLRPInstanceError
+	}
+
+	bbs.RequestedStopLRPInstances = append(bbs.RequestedStopLRPInstances, stopInstance)
+	return nil
+}
+
+func (bbs *FakeAuctioneerBBS) GetRequestedStopLRPInstances() []models.StopLRPInstance {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.RequestedStopLRPInstances
+}
+
+func (bbs *FakeAuctioneerBBS) RequestLRPStartAuction(auction models.LRPStartAuction) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	if bbs.RequestLRPStartAuctionError != nil {
+		return bbs.RequestLRPStartAuctionError
+	}
+
+	bbs.RequestedLRPStartAuctions = append(bbs.RequestedLRPStartAuctions, auction)
+
+	if bbs.WhenRequestingLRPStartAuctions != nil {
+		return bbs.WhenRequestingLRPStartAuctions(auction)
+	}
+
+	return nil
+}
+
+func (bbs *FakeAuctioneerBBS) GetRequestedLRPStartAuctions() []models.LRPStartAuction {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.RequestedLRPStartAuctions
 }
 
 func (bbs *FakeAuctioneerBBS) WatchForLRPStartAuction() (<-chan models.LRPStartAuction, chan<- bool, <-chan error) {
@@ -68,6 +217,13 @@ func (bbs *FakeAuctioneerBBS) WatchForLRPStartAuction() (<-chan models.LRPStartA
 	return bbs.LRPStartAuctionChan, bbs.LRPStartAuctionStopChan, bbs.LRPStartAuctionErrorChan
 }
 
+func (bbs *FakeAuctioneerBBS) GetAllLRPStartAuctions() ([]models.LRPStartAuction, error) {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.LRPStartAuctions, bbs.LRPStartAuctionsErr
+}
+
 func (bbs *FakeAuctioneerBBS) ClaimLRPStartAuction(auction models.LRPStartAuction) error {
 	bbs.Lock()
 	defer bbs.Unlock()
@@ -76,6 +232,14 @@ func (bbs *FakeAuctioneerBBS) ClaimLRPStartAuction(auction models.LRPStartAuctio
 	return bbs.ClaimLRPStartAuctionError
 }
 
+func (bbs *FakeAuctioneerBBS) RequeueLRPStartAuction(auction models.LRPStartAuction) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	bbs.RequeuedLRPStartAuctions = append(bbs.RequeuedLRPStartAuctions, auction)
+	return bbs.RequeueLRPStartAuctionError
+}
+
 func (bbs *FakeAuctioneerBBS) ResolveLRPStartAuction(auction models.LRPStartAuction) error {
 	bbs.Lock()
 	defer bbs.Unlock()
@@ -84,18 +248,80 @@ func (bbs *FakeAuctioneerBBS) ResolveLRPStartAuction(auction models.LRPStartAuct
 	return bbs.ResolveLRPStartAuctionError
 }
 
+func (bbs *FakeAuctioneerBBS) RecordFailedLRPStartAuction(failure models.FailedLRPStartAuction, ttl time.Duration) error {
+	bbs.Lock()
+	when := bbs.WhenRecordingFailedLRPStartAuction
+	bbs.Unlock()
+
+	if when != nil {
+		when(failure)
+	}
+
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	bbs.FailedLRPStartAuctions = append(bbs.FailedLRPStartAuctions, failure)
+	bbs.FailedLRPStartAuctionTTL = ttl
+	return bbs.RecordFailedLRPStartAuctionError
+}
+
+func (bbs *FakeAuctioneerBBS) RemoveFailedLRPStartAuction(auction models.LRPStartAuction) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	bbs.RemovedFailedLRPStartAuctions = append(bbs.RemovedFailedLRPStartAuctions, auction)
+	return bbs.RemoveFailedLRPStartAuctionError
+}
+
+func (bbs *FakeAuctioneerBBS) GetFailedLRPStartAuctions() []models.FailedLRPStartAuction {
+	bbs.Lock()
+	defer bbs.Unlock()
+	return bbs.FailedLRPStartAuctions
+}
+
+func (bbs *FakeAuctioneerBBS) GetRemovedFailedLRPStartAuctions() []models.LRPStartAuction {
+	bbs.Lock()
+	defer bbs.Unlock()
+	return bbs.RemovedFailedLRPStartAuctions
+}
+
 func (bbs *FakeAuctioneerBBS) GetClaimedLRPStartAuctions() []models.LRPStartAuction {
 	bbs.Lock()
 	defer bbs.Unlock()
 	return bbs.ClaimedLRPStartAuctions
 }
 
+func (bbs *FakeAuctioneerBBS) GetRequeuedLRPStartAuctions() []models.LRPStartAuction {
+	bbs.Lock()
+	defer bbs.Unlock()
+	return bbs.RequeuedLRPStartAuctions
+}
+
 func (bbs *FakeAuctioneerBBS) GetResolvedLRPStartAuction() models.LRPStartAuction {
 	bbs.Lock()
 	defer bbs.Unlock()
 	return bbs.ResolvedLRPStartAuction
 }
 
+func (bbs *FakeAuctioneerBBS) RequestLRPStopAuction(auction models.LRPStopAuction) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	if bbs.RequestLRPStopAuctionError != nil {
+		return bbs.RequestLRPStopAuctionError
+	}
+
+	bbs.RequestedLRPStopAuctions = append(bbs.RequestedLRPStopAuctions, auction)
+	return nil
+}
+
+func (bbs *FakeAuctioneerBBS) GetRequestedLRPStopAuctions() []models.LRPStopAuction {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.RequestedLRPStopAuctions
+}
+
 func (bbs *FakeAuctioneerBBS) WatchForLRPStopAuction() (<-chan models.LRPStopAuction, chan<- bool, <-chan error) {
 	bbs.Lock()
 	defer bbs.Unlock()
@@ -103,6 +329,13 @@ func (bbs *FakeAuctioneerBBS) WatchForLRPStopAuction() (<-chan models.LRPStopAuc
 	return bbs.LRPStopAuctionChan, bbs.LRPStopAuctionStopChan, bbs.LRPStopAuctionErrorChan
 }
 
+func (bbs *FakeAuctioneerBBS) GetAllLRPStopAuctions() ([]models.LRPStopAuction, error) {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.LRPStopAuctions, bbs.LRPStopAuctionsErr
+}
+
 func (bbs *FakeAuctioneerBBS) ClaimLRPStopAuction(auction models.LRPStopAuction) error {
 	bbs.Lock()
 	defer bbs.Unlock()
@@ -130,3 +363,83 @@ func (bbs *FakeAuctioneerBBS) GetResolvedLRPStopAuction() models.LRPStopAuction
 	defer bbs.Unlock()
 	return bbs.ResolvedLRPStopAuction
 }
+
+func (bbs *FakeAuctioneerBBS) GetAuctioneerPause() (models.AuctioneerPause, error) {
+	bbs.Lock()
+	pause, err := bbs.AuctioneerPause, bbs.GetAuctioneerPauseError
+	when := bbs.WhenGettingAuctioneerPause
+	bbs.Unlock()
+
+	if when != nil {
+		when(pause)
+	}
+
+	return pause, err
+}
+
+func (bbs *FakeAuctioneerBBS) SetAuctioneerPause(pause models.AuctioneerPause) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	if bbs.SetAuctioneerPauseError != nil {
+		return bbs.SetAuctioneerPauseError
+	}
+
+	bbs.AuctioneerPause = pause
+	return nil
+}
+
+func (bbs *FakeAuctioneerBBS) GetStoredAuctioneerPause() models.AuctioneerPause {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	return bbs.AuctioneerPause
+}
+
+func (bbs *FakeAuctioneerBBS) GetCordonedExecutors() ([]models.CordonedExecutor, error) {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	cordons := []models.CordonedExecutor{}
+	for _, cordon := range bbs.CordonedExecutors {
+		cordons = append(cordons, cordon)
+	}
+
+	return cordons, bbs.GetCordonedExecutorsError
+}
+
+func (bbs *FakeAuctioneerBBS) CordonExecutor(cordon models.CordonedExecutor) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	if bbs.CordonExecutorError != nil {
+		return bbs.CordonExecutorError
+	}
+
+	if bbs.CordonedExecutors == nil {
+		bbs.CordonedExecutors = map[string]models.CordonedExecutor{}
+	}
+
+	bbs.CordonedExecutors[cordon.ExecutorID] = cordon
+	return nil
+}
+
+func (bbs *FakeAuctioneerBBS) UncordonExecutor(executorID string) error {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	delete(bbs.CordonedExecutors, executorID)
+	return nil
+}
+
+func (bbs *FakeAuctioneerBBS) GetStoredCordonedExecutors() map[string]models.CordonedExecutor {
+	bbs.Lock()
+	defer bbs.Unlock()
+
+	cordons := map[string]models.CordonedExecutor{}
+	for id, cordon := range bbs.CordonedExecutors {
+		cordons[id] = cordon
+	}
+
+	return cordons
+}
@@ -10,6 +10,11 @@ type FakeMetricsBBS struct {
 		Err    error
 	}
 
+	GetAllFailedLRPStartAuctionsReturns struct {
+		Models []models.FailedLRPStartAuction
+		Err    error
+	}
+
 	GetServiceRegistrationsReturns struct {
 		Registrations models.ServiceRegistrations
 		Err           error
@@ -24,6 +29,10 @@ func (bbs *FakeMetricsBBS) GetAllTasks() ([]models.Task, error) {
 	return bbs.GetAllTasksReturns.Models, bbs.GetAllTasksReturns.Err
 }
 
+func (bbs *FakeMetricsBBS) GetAllFailedLRPStartAuctions() ([]models.FailedLRPStartAuction, error) {
+	return bbs.GetAllFailedLRPStartAuctionsReturns.Models, bbs.GetAllFailedLRPStartAuctionsReturns.Err
+}
+
 func (bbs *FakeMetricsBBS) GetServiceRegistrations() (models.ServiceRegistrations, error) {
 	return bbs.GetServiceRegistrationsReturns.Registrations, bbs.GetServiceRegistrationsReturns.Err
 }
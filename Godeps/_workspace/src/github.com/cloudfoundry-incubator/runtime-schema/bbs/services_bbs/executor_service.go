@@ -37,3 +37,39 @@ func (bbs *ServicesBBS) GetAllExecutors() ([]models.ExecutorPresence, error) {
 
 	return executorPresences, nil
 }
+
+func (bbs *ServicesBBS) WatchForExecutorChanges() (<-chan models.ExecutorPresenceChange, chan<- bool, <-chan error) {
+	changes := make(chan models.ExecutorPresenceChange)
+
+	filter := func(event storeadapter.WatchEvent) (models.ExecutorPresenceChange, bool) {
+		var before *models.ExecutorPresence
+		var after *models.ExecutorPresence
+
+		if event.Node != nil {
+			aft, err := models.NewExecutorPresenceFromJSON(event.Node.Value)
+			if err != nil {
+				return models.ExecutorPresenceChange{}, false
+			}
+
+			after = &aft
+		}
+
+		if event.PrevNode != nil {
+			bef, err := models.NewExecutorPresenceFromJSON(event.PrevNode.Value)
+			if err != nil {
+				return models.ExecutorPresenceChange{}, false
+			}
+
+			before = &bef
+		}
+
+		return models.ExecutorPresenceChange{
+			Before: before,
+			After:  after,
+		}, true
+	}
+
+	stop, err := shared.WatchWithFilter(bbs.store, shared.ExecutorSchemaRoot, changes, filter)
+
+	return changes, stop, err
+}
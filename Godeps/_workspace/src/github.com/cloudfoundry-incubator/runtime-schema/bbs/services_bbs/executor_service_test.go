@@ -128,4 +128,106 @@ var _ = Describe("Fetching all Executors", func() {
 			})
 		})
 	})
+
+	Describe("WatchForExecutorChanges", func() {
+		var (
+			events <-chan models.ExecutorPresenceChange
+			stop   chan<- bool
+			errors <-chan error
+			key    string
+		)
+
+		BeforeEach(func() {
+			key = shared.ExecutorSchemaPath(firstExecutorPresence.ExecutorID)
+			events, stop, errors = bbs.WatchForExecutorChanges()
+		})
+
+		AfterEach(func() {
+			stop <- true
+		})
+
+		It("sends an event down the pipe for creates", func() {
+			err := etcdClient.Create(storeadapter.StoreNode{
+				Key:   key,
+				Value: firstExecutorPresence.ToJSON(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive(Equal(models.ExecutorPresenceChange{
+				Before: nil,
+				After:  &firstExecutorPresence,
+			})))
+		})
+
+		It("sends an event down the pipe for updates", func() {
+			err := etcdClient.Create(storeadapter.StoreNode{
+				Key:   key,
+				Value: firstExecutorPresence.ToJSON(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive())
+
+			changedPresence := firstExecutorPresence
+			changedPresence.Stack = "pancakes"
+
+			err = etcdClient.SetMulti([]storeadapter.StoreNode{
+				{
+					Key:   key,
+					Value: changedPresence.ToJSON(),
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive(Equal(models.ExecutorPresenceChange{
+				Before: &firstExecutorPresence,
+				After:  &changedPresence,
+			})))
+		})
+
+		It("sends an event down the pipe for deletes", func() {
+			err := etcdClient.Create(storeadapter.StoreNode{
+				Key:   key,
+				Value: firstExecutorPresence.ToJSON(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive())
+
+			err = etcdClient.Delete(key)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive(Equal(models.ExecutorPresenceChange{
+				Before: &firstExecutorPresence,
+				After:  nil,
+			})))
+		})
+
+		It("sends an event down the pipe when a presence expires", func() {
+			err := etcdClient.Create(storeadapter.StoreNode{
+				Key:   key,
+				Value: firstExecutorPresence.ToJSON(),
+				TTL:   1,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(events).Should(Receive())
+
+			Eventually(events, 3).Should(Receive(Equal(models.ExecutorPresenceChange{
+				Before: &firstExecutorPresence,
+				After:  nil,
+			})))
+		})
+
+		It("does not send an event down the pipe for unparsable JSON", func() {
+			err := etcdClient.Create(storeadapter.StoreNode{
+				Key:   key,
+				Value: []byte("ß"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Consistently(events).ShouldNot(Receive())
+			Ω(errors).ShouldNot(Receive())
+		})
+	})
 })
@@ -11,17 +11,25 @@ const SchemaRoot = "/v1/"
 const ExecutorSchemaRoot = SchemaRoot + "executor"
 const FileServerSchemaRoot = SchemaRoot + "file_server"
 const LRPStartAuctionSchemaRoot = SchemaRoot + "start"
+const FailedLRPStartAuctionSchemaRoot = SchemaRoot + "failed-start"
 const LRPStopAuctionSchemaRoot = SchemaRoot + "stop"
 const StopLRPInstanceSchemaRoot = SchemaRoot + "stop-instance"
 const ActualLRPSchemaRoot = SchemaRoot + "actual"
 const DesiredLRPSchemaRoot = SchemaRoot + "desired"
 const TaskSchemaRoot = SchemaRoot + "task"
 const LockSchemaRoot = SchemaRoot + "locks"
+const AuctioneerSchemaRoot = SchemaRoot + "auctioneer"
+const AuctioneerPauseSchemaPath = AuctioneerSchemaRoot + "/pause"
+const CordonedExecutorSchemaRoot = AuctioneerSchemaRoot + "/cordoned"
 
 func ExecutorSchemaPath(executorID string) string {
 	return path.Join(ExecutorSchemaRoot, executorID)
 }
 
+func CordonedExecutorSchemaPath(executorID string) string {
+	return path.Join(CordonedExecutorSchemaRoot, executorID)
+}
+
 func FileServerSchemaPath(segments ...string) string {
 	return path.Join(append([]string{FileServerSchemaRoot}, segments...)...)
 }
@@ -30,6 +38,10 @@ func LRPStartAuctionSchemaPath(lrp models.LRPStartAuction) string {
 	return path.Join(LRPStartAuctionSchemaRoot, lrp.ProcessGuid, strconv.Itoa(lrp.Index))
 }
 
+func FailedLRPStartAuctionSchemaPath(failure models.FailedLRPStartAuction) string {
+	return path.Join(FailedLRPStartAuctionSchemaRoot, failure.ProcessGuid, strconv.Itoa(failure.Index))
+}
+
 func LRPStopAuctionSchemaPath(lrp models.LRPStopAuction) string {
 	return path.Join(LRPStopAuctionSchemaRoot, lrp.ProcessGuid, strconv.Itoa(lrp.Index))
 }
@@ -0,0 +1,100 @@
+package start_auction_bbs
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+// The auctioneer calls this whenever it fails to place an instance, so that
+// others can see why.  A later failure of the same instance replaces the
+// record; the store forgets it after the ttl, rounded up to whole seconds.
+func (bbs *StartAuctionBBS) RecordFailedLRPStartAuction(failure models.FailedLRPStartAuction, ttl time.Duration) error {
+	if failure.FailedAt == 0 {
+		failure.FailedAt = bbs.timeProvider.Time().UnixNano()
+	}
+
+	return shared.RetryIndefinitelyOnStoreTimeout(func() error {
+		return bbs.store.SetMulti([]storeadapter.StoreNode{
+			{
+				Key:   shared.FailedLRPStartAuctionSchemaPath(failure),
+				Value: failure.ToJSON(),
+				TTL:   ttlSeconds(ttl),
+			},
+		})
+	})
+}
+
+// the store counts ttls in whole seconds, where 0 means never expire
+func ttlSeconds(ttl time.Duration) uint64 {
+	seconds := (ttl + time.Second - 1) / time.Second
+	if seconds < 1 {
+		return 1
+	}
+
+	return uint64(seconds)
+}
+
+// The auctioneer calls this once an instance that had failed to be placed
+// has been placed after all
+func (bbs *StartAuctionBBS) RemoveFailedLRPStartAuction(lrp models.LRPStartAuction) error {
+	key := shared.FailedLRPStartAuctionSchemaPath(models.FailedLRPStartAuction{
+		ProcessGuid: lrp.ProcessGuid,
+		Index:       lrp.Index,
+	})
+
+	err := shared.RetryIndefinitelyOnStoreTimeout(func() error {
+		return bbs.store.Delete(key)
+	})
+	if err == storeadapter.ErrorKeyNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (bbs *StartAuctionBBS) GetAllFailedLRPStartAuctions() ([]models.FailedLRPStartAuction, error) {
+	return bbs.getFailedLRPStartAuctions(shared.FailedLRPStartAuctionSchemaRoot, 2)
+}
+
+func (bbs *StartAuctionBBS) GetFailedLRPStartAuctionsByProcessGuid(processGuid string) ([]models.FailedLRPStartAuction, error) {
+	return bbs.getFailedLRPStartAuctions(path.Join(shared.FailedLRPStartAuctionSchemaRoot, processGuid), 1)
+}
+
+// depth is how many levels of directories lie between key and the records
+func (bbs *StartAuctionBBS) getFailedLRPStartAuctions(key string, depth int) ([]models.FailedLRPStartAuction, error) {
+	failures := []models.FailedLRPStartAuction{}
+
+	node, err := bbs.store.ListRecursively(key)
+	if err == storeadapter.ErrorKeyNotFound {
+		return failures, nil
+	}
+
+	if err != nil {
+		return failures, err
+	}
+
+	nodes := []storeadapter.StoreNode{node}
+	for i := 0; i < depth; i++ {
+		children := []storeadapter.StoreNode{}
+		for _, node := range nodes {
+			children = append(children, node.ChildNodes...)
+		}
+		nodes = children
+	}
+
+	for _, node := range nodes {
+		failure, err := models.NewFailedLRPStartAuctionFromJSON(node.Value)
+		if err != nil {
+			return failures, fmt.Errorf("cannot parse failed start auction JSON for key %s: %s", node.Key, err.Error())
+		}
+
+		failures = append(failures, failure)
+	}
+
+	return failures, nil
+}
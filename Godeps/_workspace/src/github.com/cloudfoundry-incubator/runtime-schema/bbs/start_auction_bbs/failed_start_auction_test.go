@@ -0,0 +1,228 @@
+package start_auction_bbs_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
+)
+
+var _ = Describe("Failed Start Auction", func() {
+	var failure models.FailedLRPStartAuction
+
+	BeforeEach(func() {
+		failure = models.FailedLRPStartAuction{
+			ProcessGuid:  "some-guid",
+			InstanceGuid: "some-instance-guid",
+			Index:        1,
+			Stack:        "some-stack",
+			Reason:       models.FailedLRPStartAuctionReasonInsufficientResources,
+			Attempts:     1,
+		}
+	})
+
+	Describe("RecordFailedLRPStartAuction", func() {
+		It("creates /v1/failed-start/<guid>/<index>, stamped with when it failed", func() {
+			err := bbs.RecordFailedLRPStartAuction(failure, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			failure.FailedAt = timeProvider.Time().UnixNano()
+			Ω(node.Value).Should(Equal(failure.ToJSON()))
+		})
+
+		It("keeps the time it failed if it is given one", func() {
+			failure.FailedAt = 42
+
+			err := bbs.RecordFailedLRPStartAuction(failure, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(node.Value).Should(Equal(failure.ToJSON()))
+		})
+
+		It("replaces an earlier failure of the same instance", func() {
+			err := bbs.RecordFailedLRPStartAuction(failure, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			failure.Attempts = 2
+			failure.Reason = models.FailedLRPStartAuctionReasonAllBiddersTimedOut
+
+			err = bbs.RecordFailedLRPStartAuction(failure, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			failures, err := bbs.GetAllFailedLRPStartAuctions()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(failures).Should(HaveLen(1))
+			Ω(failures[0].Attempts).Should(Equal(2))
+			Ω(failures[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonAllBiddersTimedOut))
+		})
+
+		It("forgets the failure after the ttl", func() {
+			err := bbs.RecordFailedLRPStartAuction(failure, time.Second)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(node.TTL).Should(BeNumerically(">", 0))
+
+			Eventually(func() error {
+				_, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+				return err
+			}, 3).Should(Equal(storeadapter.ErrorKeyNotFound))
+
+			Ω(bbs.GetAllFailedLRPStartAuctions()).Should(BeEmpty())
+		})
+
+		It("rounds a ttl of under a second up to one, rather than never forgetting the failure", func() {
+			err := bbs.RecordFailedLRPStartAuction(failure, 500*time.Millisecond)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(node.TTL).Should(BeNumerically("==", 1))
+
+			Eventually(func() error {
+				_, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+				return err
+			}, 3).Should(Equal(storeadapter.ErrorKeyNotFound))
+		})
+
+		It("rounds a ttl up to whole seconds", func() {
+			err := bbs.RecordFailedLRPStartAuction(failure, 1500*time.Millisecond)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			node, err := etcdClient.Get("/v1/failed-start/some-guid/1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(node.TTL).Should(BeNumerically(">", 1))
+		})
+
+		Context("when the store is out of commission", func() {
+			itRetriesUntilStoreComesBack(func() error {
+				return bbs.RecordFailedLRPStartAuction(failure, time.Minute)
+			})
+		})
+	})
+
+	Describe("RemoveFailedLRPStartAuction", func() {
+		var startAuction models.LRPStartAuction
+
+		BeforeEach(func() {
+			startAuction = models.LRPStartAuction{
+				ProcessGuid:  "some-guid",
+				InstanceGuid: "some-other-instance-guid",
+				Index:        1,
+			}
+		})
+
+		Context("when the instance has failed", func() {
+			BeforeEach(func() {
+				err := bbs.RecordFailedLRPStartAuction(failure, time.Minute)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				other := failure
+				other.Index = 2
+				err = bbs.RecordFailedLRPStartAuction(other, time.Minute)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("removes the failure of that index, whichever instance failed", func() {
+				err := bbs.RemoveFailedLRPStartAuction(startAuction)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				_, err = etcdClient.Get("/v1/failed-start/some-guid/1")
+				Ω(err).Should(Equal(storeadapter.ErrorKeyNotFound))
+
+				_, err = etcdClient.Get("/v1/failed-start/some-guid/2")
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			Context("when the store is out of commission", func() {
+				itRetriesUntilStoreComesBack(func() error {
+					return bbs.RemoveFailedLRPStartAuction(startAuction)
+				})
+			})
+		})
+
+		Context("when the instance has not failed", func() {
+			It("does not error", func() {
+				err := bbs.RemoveFailedLRPStartAuction(startAuction)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("getting failed start auctions", func() {
+		var otherIndex, otherProcess models.FailedLRPStartAuction
+
+		BeforeEach(func() {
+			otherIndex = failure
+			otherIndex.Index = 2
+			otherIndex.InstanceGuid = "some-other-instance-guid"
+
+			otherProcess = failure
+			otherProcess.ProcessGuid = "some-other-guid"
+			otherProcess.Index = 0
+
+			for _, f := range []models.FailedLRPStartAuction{failure, otherIndex, otherProcess} {
+				err := bbs.RecordFailedLRPStartAuction(f, time.Minute)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+
+			//as stamped when they were recorded
+			failure.FailedAt = timeProvider.Time().UnixNano()
+			otherIndex.FailedAt = timeProvider.Time().UnixNano()
+			otherProcess.FailedAt = timeProvider.Time().UnixNano()
+		})
+
+		Describe("GetAllFailedLRPStartAuctions", func() {
+			It("returns every failure", func() {
+				failures, err := bbs.GetAllFailedLRPStartAuctions()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(failures).Should(ConsistOf(failure, otherIndex, otherProcess))
+			})
+
+			Context("when a failure can't be parsed", func() {
+				BeforeEach(func() {
+					err := etcdClient.SetMulti([]storeadapter.StoreNode{
+						{Key: "/v1/failed-start/bogus-guid/0", Value: []byte("ß")},
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+				})
+
+				It("errors", func() {
+					_, err := bbs.GetAllFailedLRPStartAuctions()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+		})
+
+		Describe("GetFailedLRPStartAuctionsByProcessGuid", func() {
+			It("returns the failures of the process", func() {
+				failures, err := bbs.GetFailedLRPStartAuctionsByProcessGuid("some-guid")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(failures).Should(ConsistOf(failure, otherIndex))
+			})
+
+			It("returns none for a process that hasn't failed", func() {
+				failures, err := bbs.GetFailedLRPStartAuctionsByProcessGuid("unknown-guid")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(failures).Should(BeEmpty())
+			})
+		})
+	})
+
+	Context("when nothing has failed", func() {
+		It("returns no failures", func() {
+			failures, err := bbs.GetAllFailedLRPStartAuctions()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(failures).Should(BeEmpty())
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package start_auction_bbs
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
@@ -38,6 +39,34 @@ func (bbs *StartAuctionBBS) ClaimLRPStartAuction(lrp models.LRPStartAuction) err
 	})
 }
 
+// The auctioneer calls this to put a claimed auction that failed back up for
+// auction.  Attempts, RetryAt and UpdatedAt are stored as given, so that a
+// requeued auction keeps its age.
+func (bbs *StartAuctionBBS) RequeueLRPStartAuction(lrp models.LRPStartAuction) error {
+	return shared.RetryIndefinitelyOnStoreTimeout(func() error {
+		node, err := bbs.store.Get(shared.LRPStartAuctionSchemaPath(lrp))
+		if err != nil {
+			return err
+		}
+
+		current, err := models.NewLRPStartAuctionFromJSON(node.Value)
+		if err != nil {
+			return err
+		}
+
+		if current.State != models.LRPStartAuctionStateClaimed {
+			return errors.New("cannot requeue start auction in non-claimed state")
+		}
+
+		lrp.State = models.LRPStartAuctionStatePending
+
+		return bbs.store.CompareAndSwapByIndex(node.Index, storeadapter.StoreNode{
+			Key:   shared.LRPStartAuctionSchemaPath(lrp),
+			Value: lrp.ToJSON(),
+		})
+	})
+}
+
 func (s *StartAuctionBBS) ResolveLRPStartAuction(lrp models.LRPStartAuction) error {
 	err := shared.RetryIndefinitelyOnStoreTimeout(func() error {
 		return s.store.Delete(shared.LRPStartAuctionSchemaPath(lrp))
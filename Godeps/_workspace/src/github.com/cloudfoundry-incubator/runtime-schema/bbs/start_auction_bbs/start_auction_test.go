@@ -7,9 +7,11 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
+	. "github.com/cloudfoundry-incubator/runtime-schema/bbs/start_auction_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
 	"github.com/cloudfoundry/storeadapter"
 	. "github.com/cloudfoundry/storeadapter/storenodematchers"
+	"github.com/pivotal-golang/lager/lagertest"
 )
 
 var _ = Describe("Start Auction", func() {
@@ -71,7 +73,6 @@ var _ = Describe("Start Auction", func() {
 		var (
 			events     <-chan models.LRPStartAuction
 			stop       chan<- bool
-			errors     <-chan error
 			auctionLRP models.LRPStartAuction
 		)
 
@@ -97,7 +98,7 @@ var _ = Describe("Start Auction", func() {
 					},
 				},
 			}
-			events, stop, errors = bbs.WatchForLRPStartAuction()
+			events, stop, _ = bbs.WatchForLRPStartAuction()
 		})
 
 		AfterEach(func() {
@@ -266,4 +267,141 @@ var _ = Describe("Start Auction", func() {
 			})
 		})
 	})
+
+	Describe("RequeueLRPStartAuction", func() {
+		var auctionLRP models.LRPStartAuction
+
+		BeforeEach(func() {
+			auctionLRP = models.LRPStartAuction{
+				ProcessGuid: "some-guid",
+				Index:       1,
+				Actions: []models.ExecutorAction{
+					{
+						Action: models.RunAction{
+							Path: "cat",
+							Args: []string{"/tmp/file"},
+							Env: []models.EnvironmentVariable{
+								{
+									Name:  "PATH",
+									Value: "the-path",
+								},
+							},
+							Timeout: time.Second,
+						},
+					},
+				},
+			}
+
+			err := bbs.RequestLRPStartAuction(auctionLRP)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			auctionLRP.State = models.LRPStartAuctionStatePending
+			auctionLRP.UpdatedAt = timeProvider.Time().UnixNano()
+		})
+
+		Context("when the auction is claimed", func() {
+			BeforeEach(func() {
+				timeProvider.Increment(time.Minute)
+
+				err := bbs.ClaimLRPStartAuction(auctionLRP)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				timeProvider.Increment(time.Minute)
+
+				auctionLRP.Attempts = 1
+				auctionLRP.FirstAttemptAt = timeProvider.Time().UnixNano()
+				auctionLRP.RetryAt = timeProvider.Time().Add(time.Second).UnixNano()
+			})
+
+			It("puts it back to pending, keeping its attempts, retry time and age", func() {
+				err := bbs.RequeueLRPStartAuction(auctionLRP)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				node, err := etcdClient.Get("/v1/start/some-guid/1")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(node.Value).Should(Equal(auctionLRP.ToJSON()))
+			})
+
+			Context("when the auction changes before it is swapped", func() {
+				var changed models.LRPStartAuction
+
+				BeforeEach(func() {
+					changed = auctionLRP
+					changed.State = models.LRPStartAuctionStateClaimed
+					changed.UpdatedAt = timeProvider.Time().UnixNano()
+
+					bbs = New(&racingStore{
+						StoreAdapter: etcdClient,
+						race: func() {
+							err := etcdClient.SetMulti([]storeadapter.StoreNode{
+								{
+									Key:   shared.LRPStartAuctionSchemaPath(changed),
+									Value: changed.ToJSON(),
+								},
+							})
+							Ω(err).ShouldNot(HaveOccurred())
+						},
+					}, timeProvider, lagertest.NewTestLogger("test"))
+				})
+
+				It("returns an error, leaving the change in place", func() {
+					err := bbs.RequeueLRPStartAuction(auctionLRP)
+					Ω(err).Should(Equal(storeadapter.ErrorKeyComparisonFailed))
+
+					node, err := etcdClient.Get("/v1/start/some-guid/1")
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(node.Value).Should(Equal(changed.ToJSON()))
+				})
+			})
+
+			Context("when the store is out of commission", func() {
+				itRetriesUntilStoreComesBack(func() error {
+					return bbs.RequeueLRPStartAuction(auctionLRP)
+				})
+			})
+		})
+
+		Context("when the auction is not claimed", func() {
+			It("returns an error, leaving it as it is", func() {
+				err := bbs.RequeueLRPStartAuction(auctionLRP)
+				Ω(err).Should(HaveOccurred())
+
+				node, err := etcdClient.Get("/v1/start/some-guid/1")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(node.Value).Should(Equal(auctionLRP.ToJSON()))
+			})
+		})
+
+		Context("when the auction does not exist", func() {
+			BeforeEach(func() {
+				err := bbs.ResolveLRPStartAuction(auctionLRP)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("returns an error, and does not create it", func() {
+				err := bbs.RequeueLRPStartAuction(auctionLRP)
+				Ω(err).Should(Equal(storeadapter.ErrorKeyNotFound))
+
+				_, err = etcdClient.Get("/v1/start/some-guid/1")
+				Ω(err).Should(Equal(storeadapter.ErrorKeyNotFound))
+			})
+		})
+	})
 })
+
+// racingStore makes a change, once, between a Get and whatever follows it
+type racingStore struct {
+	storeadapter.StoreAdapter
+	race func()
+}
+
+func (s *racingStore) Get(key string) (storeadapter.StoreNode, error) {
+	node, err := s.StoreAdapter.Get(key)
+
+	if s.race != nil {
+		s.race()
+		s.race = nil
+	}
+
+	return node, err
+}
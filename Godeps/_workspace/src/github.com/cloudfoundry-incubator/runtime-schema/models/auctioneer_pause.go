@@ -0,0 +1,31 @@
+package models
+
+import "encoding/json"
+
+// AuctioneerPause is which kinds of auction operators have told the
+// auctioneer to stop running; paused auctions are left pending
+type AuctioneerPause struct {
+	StartAuctions bool  `json:"start_auctions"`
+	StopAuctions  bool  `json:"stop_auctions"`
+	UpdatedAt     int64 `json:"updated_at"`
+}
+
+func NewAuctioneerPauseFromJSON(payload []byte) (AuctioneerPause, error) {
+	var pause AuctioneerPause
+
+	err := json.Unmarshal(payload, &pause)
+	if err != nil {
+		return AuctioneerPause{}, err
+	}
+
+	return pause, nil
+}
+
+func (pause AuctioneerPause) ToJSON() []byte {
+	bytes, err := json.Marshal(pause)
+	if err != nil {
+		panic(err)
+	}
+
+	return bytes
+}
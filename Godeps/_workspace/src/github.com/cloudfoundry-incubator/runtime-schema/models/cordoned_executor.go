@@ -0,0 +1,34 @@
+package models
+
+import "encoding/json"
+
+// CordonedExecutor is an executor that operators have told the auctioneer
+// not to place new instances on, e.g. because it is about to be upgraded
+type CordonedExecutor struct {
+	ExecutorID string `json:"executor_id"`
+	CordonedAt int64  `json:"cordoned_at"`
+}
+
+func NewCordonedExecutorFromJSON(payload []byte) (CordonedExecutor, error) {
+	var cordon CordonedExecutor
+
+	err := json.Unmarshal(payload, &cordon)
+	if err != nil {
+		return CordonedExecutor{}, err
+	}
+
+	if cordon.ExecutorID == "" {
+		return CordonedExecutor{}, ErrInvalidJSONMessage{"executor_id"}
+	}
+
+	return cordon, nil
+}
+
+func (cordon CordonedExecutor) ToJSON() []byte {
+	bytes, err := json.Marshal(cordon)
+	if err != nil {
+		panic(err)
+	}
+
+	return bytes
+}
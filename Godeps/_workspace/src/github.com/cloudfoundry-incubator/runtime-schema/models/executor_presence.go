@@ -2,9 +2,16 @@ package models
 
 import "encoding/json"
 
+type ExecutorPresenceChange struct {
+	Before *ExecutorPresence
+	After  *ExecutorPresence
+}
+
 type ExecutorPresence struct {
-	ExecutorID string `json:"executor_id"`
-	Stack      string `json:"stack"`
+	ExecutorID string            `json:"executor_id"`
+	Stack      string            `json:"stack"`
+	Zone       string            `json:"zone,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
 }
 
 func NewExecutorPresenceFromJSON(payload []byte) (ExecutorPresence, error) {
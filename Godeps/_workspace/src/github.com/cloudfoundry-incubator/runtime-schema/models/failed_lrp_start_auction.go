@@ -0,0 +1,69 @@
+package models
+
+import "encoding/json"
+
+// why the auctioneer failed to place an instance
+const (
+	FailedLRPStartAuctionReasonFailedToGetExecutors     = "failed-to-get-executors"
+	FailedLRPStartAuctionReasonFailedToGetActualLRPs    = "failed-to-get-actual-lrps"
+	FailedLRPStartAuctionReasonNoExecutors              = "no-executors"
+	FailedLRPStartAuctionReasonAllExecutorsCordoned     = "all-executors-cordoned"
+	FailedLRPStartAuctionReasonAllExecutorsUnresponsive = "all-executors-unresponsive"
+	FailedLRPStartAuctionReasonAllExecutorsExcluded     = "all-executors-excluded"
+	FailedLRPStartAuctionReasonNoExecutorsWithLabels    = "no-executors-with-required-labels"
+	FailedLRPStartAuctionReasonAntiAffinityExcludesAll  = "anti-affinity-excludes-all-executors"
+	FailedLRPStartAuctionReasonInvalidAntiAffinity      = "invalid-anti-affinity"
+	FailedLRPStartAuctionReasonInsufficientResources    = "insufficient-resources"
+	FailedLRPStartAuctionReasonAllBiddersTimedOut       = "all-bidders-timed-out"
+	FailedLRPStartAuctionReasonAuctionError             = "auction-error"
+)
+
+type FailedLRPStartAuction struct {
+	ProcessGuid  string `json:"process_guid"`
+	InstanceGuid string `json:"instance_guid"`
+	Index        int    `json:"index"`
+	Stack        string `json:"stack"`
+
+	DiskMB   int `json:"disk_mb"`
+	MemoryMB int `json:"memory_mb"`
+
+	Reason string `json:"reason"`
+	Error  string `json:"error,omitempty"`
+
+	//the auction is retried until GaveUp
+	Attempts int  `json:"attempts"`
+	GaveUp   bool `json:"gave_up"`
+
+	NumRounds         int `json:"num_rounds"`
+	NumCommunications int `json:"num_communications"`
+
+	FailedAt int64 `json:"failed_at"`
+}
+
+func NewFailedLRPStartAuctionFromJSON(payload []byte) (FailedLRPStartAuction, error) {
+	var failure FailedLRPStartAuction
+
+	err := json.Unmarshal(payload, &failure)
+	if err != nil {
+		return FailedLRPStartAuction{}, err
+	}
+
+	if failure.ProcessGuid == "" {
+		return FailedLRPStartAuction{}, ErrInvalidJSONMessage{"process_guid"}
+	}
+
+	if failure.Reason == "" {
+		return FailedLRPStartAuction{}, ErrInvalidJSONMessage{"reason"}
+	}
+
+	return failure, nil
+}
+
+func (failure FailedLRPStartAuction) ToJSON() []byte {
+	bytes, err := json.Marshal(failure)
+	if err != nil {
+		panic(err)
+	}
+
+	return bytes
+}
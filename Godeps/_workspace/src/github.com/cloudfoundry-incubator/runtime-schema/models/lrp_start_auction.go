@@ -24,6 +24,24 @@ type LRPStartAuction struct {
 
 	Index int `json:"index"`
 
+	//executors must have every required label, and are preferred if they
+	//have every preferred label
+	RequiredLabels  map[string]string `json:"required_labels,omitempty"`
+	PreferredLabels map[string]string `json:"preferred_labels,omitempty"`
+
+	//overrides the auctioneer's anti-affinity mode: "none", "soft" or "hard"
+	AntiAffinity string `json:"anti_affinity,omitempty"`
+
+	//executors the instance must not be placed on, e.g. the one it replaces
+	//an instance on
+	ExcludedExecutorIDs []string `json:"excluded_executor_ids,omitempty"`
+
+	//failed auctions are requeued by the auctioneer: how many times it has
+	//failed, when it first ran, and when it may next run (unix nanoseconds)
+	Attempts       int   `json:"attempts,omitempty"`
+	FirstAttemptAt int64 `json:"first_attempt_at,omitempty"`
+	RetryAt        int64 `json:"retry_at,omitempty"`
+
 	State     LRPStartAuctionState `json:"state"`
 	UpdatedAt int64                `json:"updated_at"`
 }
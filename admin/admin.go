@@ -0,0 +1,180 @@
+/*
+Package admin lets operators control an auctioneer over HTTP:
+
+	GET  /admin/pause                 200 with which kinds of auction are paused
+	POST /admin/pause/start-auctions  200 once start auctions are paused
+	POST /admin/pause/stop-auctions   200 once stop auctions are paused
+	POST /admin/resume                200 once both kinds of auction are resumed
+	POST /admin/step-down             202 as the auctioneer gives up the lock
+
+	GET    /admin/cordons             200 with the cordoned executors
+	POST   /admin/cordons/:id         200 once new instances are kept off the executor
+	DELETE /admin/cordons/:id         200 once new instances may land on it again
+	POST   /admin/cordons/:id/drain   202 as the instances on the cordoned executor
+	                                  start being moved elsewhere
+
+Each request must carry the shared secret as "Authorization: Bearer <secret>",
+and is refused with a 401 otherwise.  An auctioneer that doesn't hold the lock
+answers actions with a 409, as does one asked to drain an executor that isn't
+cordoned.  Each responds with a JSON body.
+*/
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+type Controller interface {
+	Paused() models.AuctioneerPause
+	PauseStartAuctions() error
+	PauseStopAuctions() error
+	Resume() error
+	StepDown() error
+
+	Cordoned() []models.CordonedExecutor
+	Cordon(executorID string) error
+	Uncordon(executorID string) error
+	DrainExecutor(executorID string) error
+}
+
+type handler struct {
+	controller Controller
+	secret     string
+	mux        *http.ServeMux
+}
+
+func NewHandler(controller Controller, secret string) http.Handler {
+	h := &handler{
+		controller: controller,
+		secret:     secret,
+		mux:        http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("/admin/pause", h.paused)
+	h.mux.HandleFunc("/admin/pause/start-auctions", h.action(controller.PauseStartAuctions, http.StatusOK))
+	h.mux.HandleFunc("/admin/pause/stop-auctions", h.action(controller.PauseStopAuctions, http.StatusOK))
+	h.mux.HandleFunc("/admin/resume", h.action(controller.Resume, http.StatusOK))
+	h.mux.HandleFunc("/admin/step-down", h.action(controller.StepDown, http.StatusAccepted))
+	h.mux.HandleFunc("/admin/cordons", h.cordoned)
+	h.mux.HandleFunc("/admin/cordons/", h.cordon)
+
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// an empty secret authorizes nobody
+func (h *handler) authorized(r *http.Request) bool {
+	expected := "Bearer " + h.secret
+	given := r.Header.Get("Authorization")
+
+	return h.secret != "" && subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
+
+func (h *handler) paused(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		methodNotAllowed(w, "GET")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.controller.Paused())
+}
+
+// action responds with the pause as it is once the action is taken
+func (h *handler) action(act func() error, code int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			methodNotAllowed(w, "POST")
+			return
+		}
+
+		err := act()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		writeJSON(w, code, h.controller.Paused())
+	}
+}
+
+func (h *handler) cordoned(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		methodNotAllowed(w, "GET")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.controller.Cordoned())
+}
+
+// cordon handles /admin/cordons/:id and /admin/cordons/:id/drain, responding
+// with the cordoned executors once the action is taken
+func (h *handler) cordon(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/cordons/"), "/")
+	executorID := segments[0]
+
+	if executorID == "" || len(segments) > 2 || (len(segments) == 2 && segments[1] != "drain") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	var err error
+	code := http.StatusOK
+
+	switch {
+	case len(segments) == 2 && r.Method == "POST":
+		err = h.controller.DrainExecutor(executorID)
+		code = http.StatusAccepted
+	case len(segments) == 2:
+		methodNotAllowed(w, "POST")
+		return
+	case r.Method == "POST":
+		err = h.controller.Cordon(executorID)
+	case r.Method == "DELETE":
+		err = h.controller.Uncordon(executorID)
+	default:
+		methodNotAllowed(w, "POST, DELETE")
+		return
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, code, h.controller.Cordoned())
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	if err == auctioneer.ErrLockNotHeld || err == auctioneer.ErrExecutorNotCordoned {
+		code = http.StatusConflict
+	}
+
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed string) {
+	w.Header().Set("Allow", allowed)
+	writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
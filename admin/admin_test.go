@@ -0,0 +1,277 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/cloudfoundry-incubator/auctioneer/admin"
+	"github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeController struct {
+	pause       models.AuctioneerPause
+	err         error
+	steppedDown bool
+	cordoned    []models.CordonedExecutor
+	drained     []string
+}
+
+func (c *fakeController) Paused() models.AuctioneerPause {
+	return c.pause
+}
+
+func (c *fakeController) PauseStartAuctions() error {
+	if c.err == nil {
+		c.pause.StartAuctions = true
+	}
+	return c.err
+}
+
+func (c *fakeController) PauseStopAuctions() error {
+	if c.err == nil {
+		c.pause.StopAuctions = true
+	}
+	return c.err
+}
+
+func (c *fakeController) Resume() error {
+	if c.err == nil {
+		c.pause = models.AuctioneerPause{}
+	}
+	return c.err
+}
+
+func (c *fakeController) StepDown() error {
+	if c.err == nil {
+		c.steppedDown = true
+	}
+	return c.err
+}
+
+func (c *fakeController) Cordoned() []models.CordonedExecutor {
+	return c.cordoned
+}
+
+func (c *fakeController) Cordon(executorID string) error {
+	if c.err == nil {
+		c.cordoned = append(c.cordoned, models.CordonedExecutor{ExecutorID: executorID})
+	}
+	return c.err
+}
+
+func (c *fakeController) Uncordon(executorID string) error {
+	if c.err == nil {
+		c.cordoned = nil
+	}
+	return c.err
+}
+
+func (c *fakeController) DrainExecutor(executorID string) error {
+	if c.err == nil {
+		c.drained = append(c.drained, executorID)
+	}
+	return c.err
+}
+
+var _ = Describe("Admin", func() {
+	var controller *fakeController
+	var handler http.Handler
+	var secret string
+
+	requestList := func(method string, path string) (int, []map[string]interface{}) {
+		request, err := http.NewRequest(method, path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		request.Header.Set("Authorization", "Bearer "+secret)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		body := []map[string]interface{}{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &body)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return recorder.Code, body
+	}
+
+	request := func(method string, path string) (int, map[string]interface{}) {
+		request, err := http.NewRequest(method, path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		request.Header.Set("Authorization", "Bearer "+secret)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		Ω(recorder.HeaderMap.Get("Content-Type")).Should(Equal("application/json"))
+
+		body := map[string]interface{}{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &body)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return recorder.Code, body
+	}
+
+	BeforeEach(func() {
+		controller = &fakeController{}
+		secret = "s3cret"
+		handler = NewHandler(controller, "s3cret")
+	})
+
+	Describe("authorization", func() {
+		It("should refuse requests without the secret", func() {
+			secret = "guess"
+
+			code, _ := request("POST", "/admin/pause/start-auctions")
+			Ω(code).Should(Equal(http.StatusUnauthorized))
+			Ω(controller.pause.StartAuctions).Should(BeFalse())
+		})
+
+		It("should refuse everyone when there is no secret", func() {
+			secret = ""
+			handler = NewHandler(controller, "")
+
+			code, _ := request("GET", "/admin/pause")
+			Ω(code).Should(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	Describe("GET /admin/pause", func() {
+		BeforeEach(func() {
+			controller.pause = models.AuctioneerPause{StopAuctions: true}
+		})
+
+		It("should report which kinds of auction are paused", func() {
+			code, body := request("GET", "/admin/pause")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body["start_auctions"]).Should(BeFalse())
+			Ω(body["stop_auctions"]).Should(BeTrue())
+		})
+
+		It("should only be read", func() {
+			code, _ := request("POST", "/admin/pause")
+			Ω(code).Should(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Describe("pausing and resuming", func() {
+		It("should pause each kind of auction, and resume both", func() {
+			code, body := request("POST", "/admin/pause/start-auctions")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body["start_auctions"]).Should(BeTrue())
+			Ω(body["stop_auctions"]).Should(BeFalse())
+
+			code, body = request("POST", "/admin/pause/stop-auctions")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body["stop_auctions"]).Should(BeTrue())
+
+			code, body = request("POST", "/admin/resume")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body["start_auctions"]).Should(BeFalse())
+			Ω(body["stop_auctions"]).Should(BeFalse())
+		})
+
+		It("should only take POSTs", func() {
+			code, _ := request("GET", "/admin/pause/start-auctions")
+			Ω(code).Should(Equal(http.StatusMethodNotAllowed))
+			Ω(controller.pause.StartAuctions).Should(BeFalse())
+		})
+
+		Context("when the auctioneer doesn't hold the lock", func() {
+			BeforeEach(func() {
+				controller.err = auctioneer.ErrLockNotHeld
+			})
+
+			It("should conflict", func() {
+				code, body := request("POST", "/admin/pause/start-auctions")
+				Ω(code).Should(Equal(http.StatusConflict))
+				Ω(body["error"]).Should(Equal(auctioneer.ErrLockNotHeld.Error()))
+			})
+		})
+
+		Context("when the pause can't be persisted", func() {
+			BeforeEach(func() {
+				controller.err = errors.New("etcd is down")
+			})
+
+			It("should fail, and say why", func() {
+				code, body := request("POST", "/admin/resume")
+				Ω(code).Should(Equal(http.StatusInternalServerError))
+				Ω(body["error"]).Should(Equal("etcd is down"))
+			})
+		})
+	})
+
+	Describe("POST /admin/step-down", func() {
+		It("should step down", func() {
+			code, _ := request("POST", "/admin/step-down")
+			Ω(code).Should(Equal(http.StatusAccepted))
+			Ω(controller.steppedDown).Should(BeTrue())
+		})
+
+		Context("when the auctioneer doesn't hold the lock", func() {
+			BeforeEach(func() {
+				controller.err = auctioneer.ErrLockNotHeld
+			})
+
+			It("should conflict", func() {
+				code, _ := request("POST", "/admin/step-down")
+				Ω(code).Should(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Describe("cordons", func() {
+		It("should cordon and uncordon executors", func() {
+			code, body := requestList("POST", "/admin/cordons/executor-1")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body).Should(HaveLen(1))
+			Ω(body[0]["executor_id"]).Should(Equal("executor-1"))
+
+			code, body = requestList("GET", "/admin/cordons")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body).Should(HaveLen(1))
+
+			code, body = requestList("DELETE", "/admin/cordons/executor-1")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body).Should(BeEmpty())
+		})
+
+		It("should drain cordoned executors", func() {
+			code, _ := requestList("POST", "/admin/cordons/executor-1/drain")
+			Ω(code).Should(Equal(http.StatusAccepted))
+			Ω(controller.drained).Should(Equal([]string{"executor-1"}))
+		})
+
+		It("should not drain executors that aren't cordoned", func() {
+			controller.err = auctioneer.ErrExecutorNotCordoned
+
+			code, body := request("POST", "/admin/cordons/executor-1/drain")
+			Ω(code).Should(Equal(http.StatusConflict))
+			Ω(body["error"]).Should(Equal(auctioneer.ErrExecutorNotCordoned.Error()))
+		})
+
+		It("should not know other paths", func() {
+			code, _ := request("POST", "/admin/cordons/executor-1/evict")
+			Ω(code).Should(Equal(http.StatusNotFound))
+
+			code, _ = request("POST", "/admin/cordons/")
+			Ω(code).Should(Equal(http.StatusNotFound))
+		})
+
+		It("should only take the right methods", func() {
+			code, _ := request("GET", "/admin/cordons/executor-1")
+			Ω(code).Should(Equal(http.StatusMethodNotAllowed))
+
+			code, _ = request("DELETE", "/admin/cordons/executor-1/drain")
+			Ω(code).Should(Equal(http.StatusMethodNotAllowed))
+
+			code, _ = request("POST", "/admin/cordons")
+			Ω(code).Should(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -0,0 +1,82 @@
+package algorithms
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+/*
+
+abortingClient stops an auction in its tracks once its Abort channel is
+closed: reps are no longer asked to bid or reserve, so the algorithm runs out
+of bids and gives up, and the winner, if there is one, is not told to run.
+
+Whether or not the auction is aborted, it remembers if it told a rep to run
+the instance, since an instance that has been run can't be taken back.
+
+*/
+
+type abortingClient struct {
+	auctiontypes.RepPoolClient
+	abort <-chan struct{}
+
+	lock *sync.Mutex
+	ran  bool
+}
+
+func newAbortingClient(client auctiontypes.RepPoolClient, abort <-chan struct{}) *abortingClient {
+	return &abortingClient{
+		RepPoolClient: client,
+		abort:         abort,
+		lock:          &sync.Mutex{},
+	}
+}
+
+func (c *abortingClient) BidForStartAuction(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	if c.aborted() {
+		return auctiontypes.StartAuctionBids{}
+	}
+
+	return c.RepPoolClient.BidForStartAuction(repGuids, startAuctionInfo)
+}
+
+func (c *abortingClient) RebidThenTentativelyReserve(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	if c.aborted() {
+		return auctiontypes.StartAuctionBids{}
+	}
+
+	return c.RepPoolClient.RebidThenTentativelyReserve(repGuids, startAuctionInfo)
+}
+
+func (c *abortingClient) Run(repGuid string, startAuction models.LRPStartAuction) {
+	c.lock.Lock()
+	if c.aborted() {
+		c.lock.Unlock()
+		return
+	}
+
+	c.ran = true
+	c.lock.Unlock()
+
+	c.RepPoolClient.Run(repGuid, startAuction)
+}
+
+// abortedBeforeRun is true if the auction was aborted before the instance was
+// run
+func (c *abortingClient) abortedBeforeRun() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.aborted() && !c.ran
+}
+
+func (c *abortingClient) aborted() bool {
+	select {
+	case <-c.abort:
+		return true
+	default:
+		return false
+	}
+}
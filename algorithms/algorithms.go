@@ -0,0 +1,146 @@
+package algorithms
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/auction/auctionrunner"
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+)
+
+// Algorithm places the instance described by a start auction request on one
+// of the request's reps.  It returns the winning rep ("" if none could take
+// the instance), the number of rounds it ran and the number of messages it
+// sent to reps.
+type Algorithm interface {
+	RunStartAuction(client auctiontypes.RepPoolClient, request StartAuctionRequest) (winner string, numRounds int, numCommunications int)
+}
+
+// AlgorithmFunc lets an ordinary function be used as an Algorithm
+type AlgorithmFunc func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int)
+
+func (f AlgorithmFunc) RunStartAuction(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+	return f(client, request)
+}
+
+type UnknownAlgorithmError struct {
+	Algorithm string
+}
+
+func (e UnknownAlgorithmError) Error() string {
+	return fmt.Sprintf("unknown auction algorithm '%s'", e.Algorithm)
+}
+
+type DuplicateAlgorithmError struct {
+	Algorithm string
+}
+
+func (e DuplicateAlgorithmError) Error() string {
+	return fmt.Sprintf("auction algorithm '%s' is already registered", e.Algorithm)
+}
+
+var Builtins = []string{
+	"all_rebid",
+	"all_reserve",
+	"pick_among_best",
+	"pick_best",
+	"reserve_n_best",
+	"random",
+	"power_of_d_choices",
+	"bin_pack",
+}
+
+// the built in algorithms that aren't part of the auction package
+var auctioneerAlgorithms = map[string]Algorithm{
+	"power_of_d_choices": placementAwareFunc(powerOfDChoicesAuction),
+	"bin_pack":           placementAwareFunc(binPackAuction),
+}
+
+type Registry struct {
+	lock       *sync.RWMutex
+	algorithms map[string]Algorithm
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		lock:       &sync.RWMutex{},
+		algorithms: map[string]Algorithm{},
+	}
+}
+
+// NewRegistryWithBuiltins returns a registry holding the Builtins
+func NewRegistryWithBuiltins() *Registry {
+	registry := NewRegistry()
+	for _, name := range Builtins {
+		registry.Register(name, builtin(name))
+	}
+
+	return registry
+}
+
+func (r *Registry) Register(name string, algorithm Algorithm) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, exists := r.algorithms[name]; exists {
+		return DuplicateAlgorithmError{name}
+	}
+
+	r.algorithms[name] = algorithm
+	return nil
+}
+
+func (r *Registry) Lookup(name string) (Algorithm, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	algorithm, ok := r.algorithms[name]
+	if !ok {
+		return nil, UnknownAlgorithmError{name}
+	}
+
+	return algorithm, nil
+}
+
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := []string{}
+	for name := range r.algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// the registry consulted by the auctioneer
+var DefaultRegistry = NewRegistryWithBuiltins()
+
+func Register(name string, algorithm Algorithm) error {
+	return DefaultRegistry.Register(name, algorithm)
+}
+
+func Lookup(name string) (Algorithm, error) {
+	return DefaultRegistry.Lookup(name)
+}
+
+func Names() []string {
+	return DefaultRegistry.Names()
+}
+
+// the auction package's algorithms aren't exported, so they are reached
+// through its runner
+func builtin(name string) Algorithm {
+	if algorithm, ok := auctioneerAlgorithms[name]; ok {
+		return algorithm
+	}
+
+	return AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+		request.Rules.Algorithm = name
+		result, _ := auctionrunner.New(client).RunLRPStartAuction(request.auctionRequest())
+		return result.Winner, result.NumRounds, result.NumCommunications
+	})
+}
@@ -0,0 +1,13 @@
+package algorithms_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAlgorithms(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Algorithms Suite")
+}
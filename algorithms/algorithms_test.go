@@ -0,0 +1,280 @@
+package algorithms_test
+
+import (
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *Registry
+	var first Algorithm
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+		first = AlgorithmFunc(func(auctiontypes.RepPoolClient, StartAuctionRequest) (string, int, int) {
+			return "first", 1, 1
+		})
+	})
+
+	It("looks up registered algorithms", func() {
+		err := registry.Register("first", first)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		algorithm, err := registry.Lookup("first")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		winner, _, _ := algorithm.RunStartAuction(nil, StartAuctionRequest{})
+		Ω(winner).Should(Equal("first"))
+	})
+
+	It("returns an UnknownAlgorithmError for unregistered algorithms", func() {
+		_, err := registry.Lookup("bogus")
+		Ω(err).Should(Equal(UnknownAlgorithmError{Algorithm: "bogus"}))
+		Ω(err.Error()).Should(Equal("unknown auction algorithm 'bogus'"))
+	})
+
+	It("refuses to register an algorithm twice", func() {
+		registry.Register("first", first)
+		err := registry.Register("first", first)
+		Ω(err).Should(Equal(DuplicateAlgorithmError{Algorithm: "first"}))
+	})
+
+	It("lists the registered algorithms in order", func() {
+		registry.Register("b", first)
+		registry.Register("a", first)
+		Ω(registry.Names()).Should(Equal([]string{"a", "b"}))
+	})
+
+	Describe("the default registry", func() {
+		It("has the built in algorithms", func() {
+			Ω(Names()).Should(ConsistOf(Builtins))
+		})
+	})
+})
+
+var _ = Describe("Runner", func() {
+	var registry *Registry
+	var pool *simulation.RepPool
+	var runner AuctionRunner
+	var request StartAuctionRequest
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"rep": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		runner = NewRunner(pool, registry)
+
+		request = StartAuctionRequest{
+			LRPStartAuction: models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", MemoryMB: 1, DiskMB: 1},
+			RepGuids:        auctiontypes.RepGuids{"rep"},
+			Rules:           StartAuctionRules{Algorithm: "custom", MaxRounds: 1},
+		}
+	})
+
+	It("runs the start auction with the algorithm named by the rules", func() {
+		var receivedRequest StartAuctionRequest
+		registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+			receivedRequest = request
+			return "rep", 2, 3
+		}))
+
+		result, err := runner.RunLRPStartAuction(request)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(receivedRequest).Should(Equal(request))
+		Ω(result.LRPStartAuction).Should(Equal(request.LRPStartAuction))
+		Ω(result.Winner).Should(Equal("rep"))
+		Ω(result.NumRounds).Should(Equal(2))
+		Ω(result.NumCommunications).Should(Equal(3))
+	})
+
+	It("returns InsufficientResources when the algorithm finds no winner", func() {
+		registry.Register("custom", AlgorithmFunc(func(auctiontypes.RepPoolClient, StartAuctionRequest) (string, int, int) {
+			return "", 1, 1
+		}))
+
+		_, err := runner.RunLRPStartAuction(request)
+		Ω(err).Should(Equal(auctiontypes.InsufficientResources))
+	})
+
+	It("returns InsufficientResources when reps bid but the algorithm finds no winner", func() {
+		registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+			client.BidForStartAuction(request.RepGuids, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+			return "", 1, 1
+		}))
+
+		_, err := runner.RunLRPStartAuction(request)
+		Ω(err).Should(Equal(auctiontypes.InsufficientResources))
+	})
+
+	It("returns AllBiddersTimedOut when no rep answers", func() {
+		runner = NewRunner(silentClient{pool}, registry)
+		registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+			client.BidForStartAuction(request.RepGuids, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+			return "", 1, 1
+		}))
+
+		_, err := runner.RunLRPStartAuction(request)
+		Ω(err).Should(Equal(AllBiddersTimedOut))
+	})
+
+	It("tells its bid observer which reps are asked to bid, as they are asked", func() {
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"rep":       {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"other-rep": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		observed := NewRunner(pool, registry)
+		runner = observed
+
+		asked := [][]string{}
+		observed.SetBidObserver(func(repGuids []string) {
+			asked = append(asked, repGuids)
+		})
+
+		request.RepGuids = auctiontypes.RepGuids{"rep", "other-rep"}
+		registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+			info := auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction)
+			client.BidForStartAuction([]string{"rep"}, info)
+			client.RebidThenTentativelyReserve([]string{"rep"}, info)
+			return "", 2, 2
+		}))
+
+		_, err := runner.RunLRPStartAuction(request)
+		Ω(err).Should(Equal(auctiontypes.InsufficientResources))
+		Ω(asked).Should(Equal([][]string{{"rep"}, {"rep"}}))
+
+		//there is nothing to stop, but every rep is still asked
+		runner.RunLRPStopAuction(StopAuctionRequest{
+			LRPStopAuction: models.LRPStopAuction{ProcessGuid: "pg", Index: 0},
+			RepGuids:       auctiontypes.RepGuids{"rep", "other-rep"},
+		})
+		Ω(asked).Should(HaveLen(3))
+		Ω(asked[2]).Should(ConsistOf("rep", "other-rep"))
+	})
+
+	Context("when the auction is aborted", func() {
+		var abort chan struct{}
+
+		BeforeEach(func() {
+			abort = make(chan struct{})
+			request.Abort = abort
+		})
+
+		It("returns AuctionAborted, without running the instance on the winner", func() {
+			registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+				client.BidForStartAuction(request.RepGuids, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+				close(abort)
+				client.Run("rep", request.LRPStartAuction)
+				return "rep", 1, 2
+			}))
+
+			result, err := runner.RunLRPStartAuction(request)
+			Ω(err).Should(Equal(AuctionAborted))
+			Ω(result.Winner).Should(BeEmpty())
+			Ω(pool.SimulatedInstances("rep")).Should(BeEmpty())
+		})
+
+		It("no longer asks reps to bid", func() {
+			close(abort)
+			registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+				client.BidForStartAuction(request.RepGuids, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+				client.RebidThenTentativelyReserve(request.RepGuids, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+				return "", 2, 0
+			}))
+
+			_, err := runner.RunLRPStartAuction(request)
+			Ω(err).Should(Equal(AuctionAborted))
+			Ω(pool.Communications()).Should(BeZero())
+		})
+
+		It("doesn't tell its bid observer of the requests it doesn't send", func() {
+			observed := NewRunner(pool, registry)
+			asked := 0
+			observed.SetBidObserver(func([]string) {
+				asked++
+			})
+
+			close(abort)
+			registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+				client.BidForStartAuction(request.RepGuids, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+				return "", 1, 0
+			}))
+
+			_, err := observed.RunLRPStartAuction(request)
+			Ω(err).Should(Equal(AuctionAborted))
+			Ω(asked).Should(BeZero())
+		})
+
+		It("succeeds if the winner was told to run the instance first", func() {
+			registry.Register("custom", AlgorithmFunc(func(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+				client.Run("rep", request.LRPStartAuction)
+				close(abort)
+				return "rep", 1, 1
+			}))
+
+			result, err := runner.RunLRPStartAuction(request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result.Winner).Should(Equal("rep"))
+			Ω(pool.SimulatedInstances("rep")).Should(HaveLen(1))
+		})
+	})
+
+	It("returns an UnknownAlgorithmError, rather than panicking, for unknown algorithms", func() {
+		_, err := runner.RunLRPStartAuction(request)
+		Ω(err).Should(Equal(UnknownAlgorithmError{Algorithm: "custom"}))
+	})
+
+	It("runs stop auctions", func() {
+		pool.SetSimulatedInstances("rep", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "pg", InstanceGuid: "a", Index: 0, MemoryMB: 1, DiskMB: 1},
+			{ProcessGuid: "pg", InstanceGuid: "b", Index: 0, MemoryMB: 1, DiskMB: 1},
+		})
+
+		_, err := runner.RunLRPStopAuction(StopAuctionRequest{
+			LRPStopAuction: models.LRPStopAuction{ProcessGuid: "pg", Index: 0},
+			RepGuids:       auctiontypes.RepGuids{"rep"},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(pool.SimulatedInstances("rep")).Should(HaveLen(1))
+	})
+
+	It("keeps the instance off draining reps in stop auctions, where it can", func() {
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"draining": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"busy":     {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		runner = NewRunner(pool, registry)
+
+		pool.SetSimulatedInstances("draining", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "pg", InstanceGuid: "a", Index: 0, MemoryMB: 1, DiskMB: 1},
+		})
+		pool.SetSimulatedInstances("busy", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "pg", InstanceGuid: "b", Index: 0, MemoryMB: 1, DiskMB: 1},
+			{ProcessGuid: "other", InstanceGuid: "c", Index: 0, MemoryMB: 512, DiskMB: 512},
+		})
+
+		result, err := runner.RunLRPStopAuction(StopAuctionRequest{
+			LRPStopAuction:   models.LRPStopAuction{ProcessGuid: "pg", Index: 0},
+			RepGuids:         auctiontypes.RepGuids{"draining", "busy"},
+			DrainingRepGuids: auctiontypes.RepGuids{"draining"},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Winner).Should(Equal("busy"))
+		Ω(pool.SimulatedInstances("draining")).Should(BeEmpty())
+		Ω(pool.SimulatedInstances("busy")).Should(HaveLen(2))
+	})
+})
+
+// silentClient's reps never answer in time
+type silentClient struct {
+	auctiontypes.RepPoolClient
+}
+
+func (silentClient) BidForStartAuction([]string, auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	return auctiontypes.StartAuctionBids{}
+}
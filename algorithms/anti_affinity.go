@@ -0,0 +1,108 @@
+package algorithms
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+)
+
+// values of StartAuctionRequest.AntiAffinity; "" is none
+const (
+	AntiAffinityNone = "none"
+	AntiAffinitySoft = "soft"
+	AntiAffinityHard = "hard"
+)
+
+// added to a rep's bid, under soft anti-affinity, for every instance of the
+// process already on the rep
+const AntiAffinityPenalty = 1.0
+
+var ErrRepHostsProcess = errors.New("rep already hosts an instance of the process")
+
+/*
+
+antiAffinityClient keeps instances of a process off the reps that already host
+it: under hard anti-affinity such reps refuse to bid, under soft anti-affinity
+their bids are penalized.
+
+A rep hosts the process if the request says so (from the actual LRPs in the
+BBS) or if the rep's bid says so (which also counts instances the rep has only
+reserved, and so catches auctions for the same process that are in flight).
+
+*/
+
+type antiAffinityClient struct {
+	auctiontypes.RepPoolClient
+	mode            string
+	instancesPerRep map[string]int
+
+	//the soft penalty last added to each rep's bid
+	penaltiesLock sync.Mutex
+	penalties     map[string]float64
+}
+
+func (c *antiAffinityClient) BidForStartAuction(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	bids, _ := c.apply(c.RepPoolClient.BidForStartAuction(repGuids, startAuctionInfo))
+	return bids
+}
+
+func (c *antiAffinityClient) RebidThenTentativelyReserve(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	bids, refused := c.apply(c.RepPoolClient.RebidThenTentativelyReserve(repGuids, startAuctionInfo))
+
+	//the refused reps reserved before we refused them
+	if len(refused) > 0 {
+		c.RepPoolClient.ReleaseReservation(refused, startAuctionInfo)
+	}
+
+	return bids
+}
+
+func (c *antiAffinityClient) apply(bids auctiontypes.StartAuctionBids) (auctiontypes.StartAuctionBids, []string) {
+	refused := []string{}
+
+	for i := range bids {
+		if bids[i].Error != "" {
+			continue
+		}
+
+		numInstances := bids[i].NumInstances
+		if c.instancesPerRep[bids[i].Rep] > numInstances {
+			numInstances = c.instancesPerRep[bids[i].Rep]
+		}
+
+		if numInstances == 0 {
+			c.penalize(bids[i].Rep, 0)
+			continue
+		}
+
+		if c.mode == AntiAffinityHard {
+			bids[i].Error = ErrRepHostsProcess.Error()
+			refused = append(refused, bids[i].Rep)
+		} else {
+			penalty := AntiAffinityPenalty * float64(numInstances)
+			bids[i].Bid += penalty
+			c.penalize(bids[i].Rep, penalty)
+		}
+	}
+
+	return bids, refused
+}
+
+func (c *antiAffinityClient) penalize(repGuid string, penalty float64) {
+	c.penaltiesLock.Lock()
+	defer c.penaltiesLock.Unlock()
+
+	if c.penalties == nil {
+		c.penalties = map[string]float64{}
+	}
+
+	c.penalties[repGuid] = penalty
+}
+
+func (c *antiAffinityClient) penalty(repGuid string) float64 {
+	c.penaltiesLock.Lock()
+	defer c.penaltiesLock.Unlock()
+
+	return c.penalties[repGuid] + penaltyOf(c.RepPoolClient, repGuid)
+}
@@ -0,0 +1,136 @@
+package algorithms_test
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Anti-affinity", func() {
+	var pool *simulation.RepPool
+	var runner AuctionRunner
+	var request StartAuctionRequest
+
+	BeforeEach(func() {
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"hosting": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"busy":    {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		pool.SetSimulatedInstances("hosting", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "pg", InstanceGuid: "a", Index: 0, MemoryMB: 64, DiskMB: 64},
+		})
+		pool.SetSimulatedInstances("busy", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "other", InstanceGuid: "b", Index: 0, MemoryMB: 512, DiskMB: 512},
+		})
+		runner = NewRunner(pool, DefaultRegistry)
+
+		request = StartAuctionRequest{
+			LRPStartAuction: models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", Index: 1, MemoryMB: 64, DiskMB: 64},
+			RepGuids:        auctiontypes.RepGuids{"hosting", "busy"},
+			Rules: StartAuctionRules{
+				Algorithm:              "reserve_n_best",
+				MaxRounds:              3,
+				MaxBiddingPoolFraction: 1,
+				MinBiddingPool:         2,
+			},
+		}
+	})
+
+	It("places the instance on the emptiest rep without anti-affinity", func() {
+		result, err := runner.RunLRPStartAuction(request)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Winner).Should(Equal("hosting"))
+	})
+
+	for _, mode := range []string{AntiAffinitySoft, AntiAffinityHard} {
+		mode := mode
+
+		Context(fmt.Sprintf("with %s anti-affinity", mode), func() {
+			BeforeEach(func() {
+				request.AntiAffinity = mode
+			})
+
+			It("places the instance on a rep that doesn't host the process, as the rep reports", func() {
+				result, err := runner.RunLRPStartAuction(request)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(result.Winner).Should(Equal("busy"))
+			})
+
+			It("places the instance on a rep that doesn't host the process, as the request reports", func() {
+				pool.Reset("hosting")
+				request.InstancesPerRep = map[string]int{"hosting": 1}
+
+				result, err := runner.RunLRPStartAuction(request)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(result.Winner).Should(Equal("busy"))
+			})
+
+			It("counts instances that are only reserved", func() {
+				pool.Reset("hosting")
+				pool.RebidThenTentativelyReserve([]string{"hosting"}, auctiontypes.StartAuctionInfo{ProcessGuid: "pg", InstanceGuid: "in-flight", MemoryMB: 64, DiskMB: 64})
+
+				result, err := runner.RunLRPStartAuction(request)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(result.Winner).Should(Equal("busy"))
+			})
+		})
+	}
+
+	Context("when packing", func() {
+		BeforeEach(func() {
+			//hosting is the tightest fit
+			pool.SetSimulatedInstances("hosting", []auctiontypes.SimulatedInstance{
+				{ProcessGuid: "pg", InstanceGuid: "a", Index: 0, MemoryMB: 768, DiskMB: 768},
+			})
+
+			request.Rules.Algorithm = "bin_pack"
+			request.Rules.Placement = PlacementPack
+		})
+
+		It("packs onto the tightest fit without anti-affinity", func() {
+			result, err := runner.RunLRPStartAuction(request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result.Winner).Should(Equal("hosting"))
+		})
+
+		It("packs onto a rep that doesn't host the process under soft anti-affinity", func() {
+			request.AntiAffinity = AntiAffinitySoft
+
+			result, err := runner.RunLRPStartAuction(request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result.Winner).Should(Equal("busy"))
+		})
+	})
+
+	Context("when every rep hosts the process", func() {
+		BeforeEach(func() {
+			request.InstancesPerRep = map[string]int{"hosting": 1, "busy": 1}
+		})
+
+		It("still places the instance under soft anti-affinity", func() {
+			request.AntiAffinity = AntiAffinitySoft
+
+			_, err := runner.RunLRPStartAuction(request)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("fails under hard anti-affinity, leaving no reservations behind", func() {
+			request.AntiAffinity = AntiAffinityHard
+
+			for _, name := range Names() {
+				request.Rules.Algorithm = name
+
+				_, err := runner.RunLRPStartAuction(request)
+				Ω(err).Should(Equal(auctiontypes.InsufficientResources), name)
+				Ω(pool.Reservations("hosting")).Should(BeEmpty(), name)
+				Ω(pool.Reservations("busy")).Should(BeEmpty(), name)
+			}
+		})
+	})
+})
@@ -0,0 +1,89 @@
+package algorithms_test
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// the standard deviation, across reps, of the fraction of memory in use
+func memoryImbalance(pool *simulation.RepPool, repGuids auctiontypes.RepGuids) float64 {
+	fractions := []float64{}
+	mean := 0.0
+	for _, repGuid := range repGuids {
+		total := pool.TotalResources(repGuid)
+		remaining := pool.RemainingResources(repGuid)
+		fraction := 1 - float64(remaining.MemoryMB)/float64(total.MemoryMB)
+		fractions = append(fractions, fraction)
+		mean += fraction
+	}
+	mean /= float64(len(fractions))
+
+	variance := 0.0
+	for _, fraction := range fractions {
+		variance += (fraction - mean) * (fraction - mean)
+	}
+
+	return math.Sqrt(variance / float64(len(fractions)))
+}
+
+var _ = Describe("Benchmarks", func() {
+	const numReps = 200
+	const numInstances = 3000
+
+	for _, name := range []string{"reserve_n_best", "power_of_d_choices"} {
+		name := name
+
+		Measure(fmt.Sprintf("placing %d instances on %d reps with %s", numInstances, numReps, name), func(b Benchmarker) {
+			reps := map[string]auctiontypes.Resources{}
+			repGuids := auctiontypes.RepGuids{}
+			for i := 0; i < numReps; i++ {
+				repGuid := fmt.Sprintf("rep-%d", i)
+				reps[repGuid] = auctiontypes.Resources{MemoryMB: 4096, DiskMB: 4096, Containers: 100}
+				repGuids = append(repGuids, repGuid)
+			}
+			pool := simulation.NewRepPool(reps)
+			runner := NewRunner(pool, DefaultRegistry)
+
+			rules := StartAuctionRules{
+				Algorithm:              name,
+				MaxRounds:              40,
+				MaxBiddingPoolFraction: 0.2,
+				MinBiddingPool:         10,
+				NumChoices:             DefaultNumChoices,
+			}
+
+			numRounds, numCommunications := 0, 0
+			b.Time("placement", func() {
+				for i := 0; i < numInstances; i++ {
+					result, err := runner.RunLRPStartAuction(StartAuctionRequest{
+						LRPStartAuction: models.LRPStartAuction{
+							ProcessGuid:  fmt.Sprintf("pg-%d", i%50),
+							InstanceGuid: fmt.Sprintf("ig-%d", i),
+							Index:        i / 50,
+							MemoryMB:     64 * (1 + i%4),
+							DiskMB:       64,
+						},
+						RepGuids: repGuids,
+						Rules:    rules,
+					})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					numRounds += result.NumRounds
+					numCommunications += result.NumCommunications
+				}
+			})
+
+			b.RecordValue("rounds per auction", float64(numRounds)/numInstances)
+			b.RecordValue("communications per auction", float64(numCommunications)/numInstances)
+			b.RecordValue("memory imbalance (std dev of fraction used)", memoryImbalance(pool, repGuids))
+		}, 3)
+	}
+})
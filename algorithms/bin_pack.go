@@ -0,0 +1,46 @@
+package algorithms
+
+import "github.com/cloudfoundry-incubator/auction/auctiontypes"
+
+/*
+
+Get the bids from the subset of reps
+	Rank them by Rules.Placement
+		Tell them to reserve, in order, until one succeeds -- we have a winner
+
+When packing, this is best fit: the instance goes to the fullest rep that can
+still take it, so that emptier reps can drain and be scaled down.
+
+*/
+
+func binPackAuction(client auctiontypes.RepPoolClient, auctionRequest StartAuctionRequest) (string, int, int) {
+	rounds, numCommunications := 1, 0
+	auctionInfo := auctiontypes.NewStartAuctionInfoFromLRPStartAuction(auctionRequest.LRPStartAuction)
+
+	for ; rounds <= auctionRequest.Rules.MaxRounds; rounds++ {
+		//pick a subset
+		reps := auctionRequest.RepGuids.RandomSubsetByFraction(auctionRequest.Rules.MaxBiddingPoolFraction, auctionRequest.Rules.MinBiddingPool)
+
+		//get everyone's bid, if they're all full: try again
+		numCommunications += len(reps)
+		scores := client.BidForStartAuction(reps, auctionInfo)
+		if scores.AllFailed() {
+			continue
+		}
+
+		//walk down the ranking until a rep reserves
+		for _, candidate := range rankBids(client, scores, auctionRequest.Rules.Placement) {
+			numCommunications += 1
+			if client.RebidThenTentativelyReserve([]string{candidate.Rep}, auctionInfo).AllFailed() {
+				continue
+			}
+
+			numCommunications += 1
+			client.Run(candidate.Rep, auctionRequest.LRPStartAuction)
+
+			return candidate.Rep, rounds, numCommunications
+		}
+	}
+
+	return "", rounds, numCommunications
+}
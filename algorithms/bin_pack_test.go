@@ -0,0 +1,116 @@
+package algorithms_test
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bin_pack", func() {
+	var algorithm Algorithm
+	var pool *simulation.RepPool
+	var request StartAuctionRequest
+
+	BeforeEach(func() {
+		var err error
+		algorithm, err = Lookup("bin_pack")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"empty":       {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"half-full":   {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"nearly-full": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"full":        {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		pool.SetSimulatedInstances("half-full", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "other", InstanceGuid: "a", MemoryMB: 512, DiskMB: 512},
+		})
+		pool.SetSimulatedInstances("nearly-full", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "other", InstanceGuid: "b", MemoryMB: 768, DiskMB: 768},
+		})
+		pool.SetSimulatedInstances("full", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "other", InstanceGuid: "c", MemoryMB: 1000, DiskMB: 1000},
+		})
+
+		request = StartAuctionRequest{
+			LRPStartAuction: models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", MemoryMB: 256, DiskMB: 256},
+			RepGuids:        auctiontypes.RepGuids{"empty", "half-full", "nearly-full", "full"},
+			Rules: StartAuctionRules{
+				Algorithm:              "bin_pack",
+				MaxRounds:              1,
+				MaxBiddingPoolFraction: 1,
+				MinBiddingPool:         1,
+			},
+		}
+	})
+
+	It("can pack as well as spread", func() {
+		Ω(HonoursPlacement(algorithm)).Should(BeTrue())
+	})
+
+	Context("when packing", func() {
+		BeforeEach(func() {
+			request.Rules.Placement = PlacementPack
+		})
+
+		It("places the instance on the fullest rep that it fits on", func() {
+			winner, _, _ := algorithm.RunStartAuction(pool, request)
+			Ω(winner).Should(Equal("nearly-full"))
+		})
+
+		It("keeps filling a rep until it is full", func() {
+			for i := 0; i < 4; i++ {
+				request.LRPStartAuction.InstanceGuid = fmt.Sprintf("ig-%d", i)
+				request.LRPStartAuction.MemoryMB = 128
+				request.LRPStartAuction.DiskMB = 128
+				algorithm.RunStartAuction(pool, request)
+			}
+
+			Ω(pool.SimulatedInstances("nearly-full")).Should(HaveLen(3))
+			Ω(pool.SimulatedInstances("half-full")).Should(HaveLen(3))
+			Ω(pool.SimulatedInstances("empty")).Should(BeEmpty())
+		})
+	})
+
+	Context("when spreading", func() {
+		BeforeEach(func() {
+			request.Rules.Placement = PlacementSpread
+		})
+
+		It("places the instance on the emptiest rep", func() {
+			winner, _, _ := algorithm.RunStartAuction(pool, request)
+			Ω(winner).Should(Equal("empty"))
+		})
+	})
+
+	Context("when reps don't report their remaining resources", func() {
+		It("packs onto the reps that do first", func() {
+			client := &unreportingClient{RepPool: pool, unreporting: "nearly-full"}
+			request.Rules.Placement = PlacementPack
+
+			winner, _, _ := algorithm.RunStartAuction(client, request)
+			Ω(winner).Should(Equal("half-full"))
+		})
+	})
+})
+
+type unreportingClient struct {
+	*simulation.RepPool
+	unreporting string
+}
+
+func (c *unreportingClient) BidForStartAuction(repGuids []string, info auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	bids := c.RepPool.BidForStartAuction(repGuids, info)
+	for i := range bids {
+		if bids[i].Rep == c.unreporting {
+			bids[i].Resources = auctiontypes.Resources{}
+		}
+	}
+	return bids
+}
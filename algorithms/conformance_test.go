@@ -0,0 +1,150 @@
+package algorithms_test
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// every registered algorithm must pass these
+var _ = Describe("Conformance", func() {
+	for _, name := range Names() {
+		name := name
+
+		Describe(name, func() {
+			var algorithm Algorithm
+			var pool *simulation.RepPool
+			var repGuids auctiontypes.RepGuids
+			var request StartAuctionRequest
+
+			roomy := auctiontypes.Resources{MemoryMB: 1024, DiskMB: 1024, Containers: 10}
+			full := auctiontypes.Resources{MemoryMB: 1024, DiskMB: 1024, Containers: 0}
+
+			BeforeEach(func() {
+				var err error
+				algorithm, err = Lookup(name)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				reps := map[string]auctiontypes.Resources{
+					"not-in-the-request": roomy,
+				}
+				repGuids = auctiontypes.RepGuids{}
+				for i := 0; i < 10; i++ {
+					repGuid := fmt.Sprintf("rep-%d", i)
+					reps[repGuid] = roomy
+					repGuids = append(repGuids, repGuid)
+				}
+				pool = simulation.NewRepPool(reps)
+
+				request = StartAuctionRequest{
+					LRPStartAuction: models.LRPStartAuction{
+						ProcessGuid:  "pg",
+						InstanceGuid: "ig",
+						Index:        0,
+						MemoryMB:     256,
+						DiskMB:       256,
+					},
+					RepGuids: repGuids,
+					Rules: StartAuctionRules{
+						Algorithm:              name,
+						MaxRounds:              100,
+						MaxBiddingPoolFraction: 0.2,
+						MinBiddingPool:         3,
+					},
+				}
+			})
+
+			allReservations := func() []auctiontypes.StartAuctionInfo {
+				reservations := []auctiontypes.StartAuctionInfo{}
+				for _, repGuid := range append(repGuids, "not-in-the-request") {
+					reservations = append(reservations, pool.Reservations(repGuid)...)
+				}
+				return reservations
+			}
+
+			allInstances := func() map[string][]auctiontypes.SimulatedInstance {
+				instances := map[string][]auctiontypes.SimulatedInstance{}
+				for _, repGuid := range append(repGuids, "not-in-the-request") {
+					if repInstances := pool.SimulatedInstances(repGuid); len(repInstances) > 0 {
+						instances[repGuid] = repInstances
+					}
+				}
+				return instances
+			}
+
+			Context("when there is room", func() {
+				It("runs the instance, once, on the winner", func() {
+					winner, numRounds, numCommunications := algorithm.RunStartAuction(pool, request)
+					Ω(repGuids).Should(ContainElement(winner))
+					Ω(numRounds).Should(BeNumerically(">=", 1))
+					Ω(numCommunications).Should(BeNumerically(">", 0))
+
+					instances := allInstances()
+					Ω(instances).Should(HaveLen(1))
+					Ω(instances[winner]).Should(HaveLen(1))
+					Ω(instances[winner][0].InstanceGuid).Should(Equal("ig"))
+				})
+
+				It("leaves no reservations behind", func() {
+					algorithm.RunStartAuction(pool, request)
+					Ω(allReservations()).Should(BeEmpty())
+				})
+
+				It("only contacts the reps in the request", func() {
+					algorithm.RunStartAuction(pool, request)
+					Ω(pool.Contacted()).ShouldNot(HaveKey("not-in-the-request"))
+				})
+			})
+
+			Context("when only one rep has room", func() {
+				BeforeEach(func() {
+					reps := map[string]auctiontypes.Resources{}
+					for _, repGuid := range repGuids {
+						reps[repGuid] = full
+					}
+					reps["rep-7"] = roomy
+					reps["not-in-the-request"] = roomy
+					pool = simulation.NewRepPool(reps)
+				})
+
+				It("finds it", func() {
+					winner, _, _ := algorithm.RunStartAuction(pool, request)
+					Ω(winner).Should(Equal("rep-7"))
+					Ω(pool.SimulatedInstances("rep-7")).Should(HaveLen(1))
+					Ω(allReservations()).Should(BeEmpty())
+				})
+			})
+
+			Context("when every rep is full", func() {
+				BeforeEach(func() {
+					reps := map[string]auctiontypes.Resources{}
+					for _, repGuid := range repGuids {
+						reps[repGuid] = full
+					}
+					reps["not-in-the-request"] = roomy
+					pool = simulation.NewRepPool(reps)
+
+					request.Rules.MaxRounds = 5
+				})
+
+				It("gives up, without a winner, after the maximum number of rounds", func() {
+					winner, numRounds, _ := algorithm.RunStartAuction(pool, request)
+					Ω(winner).Should(BeEmpty())
+					Ω(numRounds).Should(BeNumerically("<=", request.Rules.MaxRounds+1))
+				})
+
+				It("runs nothing and leaves no reservations behind", func() {
+					algorithm.RunStartAuction(pool, request)
+					Ω(allInstances()).Should(BeEmpty())
+					Ω(allReservations()).Should(BeEmpty())
+				})
+			})
+		})
+	}
+})
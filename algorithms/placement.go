@@ -0,0 +1,115 @@
+package algorithms
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+)
+
+// values of Rules.Placement; "" spreads
+const (
+	PlacementSpread = "spread"
+	PlacementPack   = "pack"
+)
+
+// PlacementAware is implemented by algorithms that honour Rules.Placement.
+// Other algorithms always spread.
+type PlacementAware interface {
+	HonoursPlacement() bool
+}
+
+// HonoursPlacement reports whether the algorithm can pack as well as spread
+func HonoursPlacement(algorithm Algorithm) bool {
+	placementAware, ok := algorithm.(PlacementAware)
+	return ok && placementAware.HonoursPlacement()
+}
+
+type placementAwareFunc AlgorithmFunc
+
+func (f placementAwareFunc) RunStartAuction(client auctiontypes.RepPoolClient, request StartAuctionRequest) (string, int, int) {
+	return f(client, request)
+}
+
+func (f placementAwareFunc) HonoursPlacement() bool {
+	return true
+}
+
+// penalizer is implemented by the clients that add to reps' bids to keep
+// instances apart, so that packing can tell what they added
+type penalizer interface {
+	penalty(repGuid string) float64
+}
+
+// penaltyOf totals what the client, and those it wraps, added to the rep's
+// bid
+func penaltyOf(client auctiontypes.RepPoolClient, repGuid string) float64 {
+	p, ok := client.(penalizer)
+	if !ok {
+		return 0
+	}
+
+	return p.penalty(repGuid)
+}
+
+/*
+
+rankBids orders the bids that didn't fail, best first.
+
+Spreading prefers the lowest bid.  Packing prefers the rep with the smallest
+penalty for zone spreading and soft anti-affinity, and among those the rep
+with the least remaining memory, then disk, then containers: the tightest fit
+for the instance, since the rep has already checked that it fits.  Reps that
+don't report their remaining resources come last among reps with the same
+penalty.
+
+Ties are broken randomly.
+
+*/
+
+func rankBids(client auctiontypes.RepPoolClient, bids auctiontypes.StartAuctionBids, placement string) auctiontypes.StartAuctionBids {
+	ranked := bids.FilterErrors().Shuffle()
+	if placement != PlacementPack {
+		return ranked.Sort()
+	}
+
+	penalties := make([]float64, len(ranked))
+	for i, bid := range ranked {
+		penalties[i] = penaltyOf(client, bid.Rep)
+	}
+
+	sort.Stable(tightestFirst{bids: ranked, penalties: penalties})
+	return ranked
+}
+
+type tightestFirst struct {
+	bids      auctiontypes.StartAuctionBids
+	penalties []float64
+}
+
+func (t tightestFirst) Len() int { return len(t.bids) }
+
+func (t tightestFirst) Swap(i, j int) {
+	t.bids[i], t.bids[j] = t.bids[j], t.bids[i]
+	t.penalties[i], t.penalties[j] = t.penalties[j], t.penalties[i]
+}
+
+func (t tightestFirst) Less(i, j int) bool {
+	if t.penalties[i] != t.penalties[j] {
+		return t.penalties[i] < t.penalties[j]
+	}
+
+	a, b := t.bids[i].Resources, t.bids[j].Resources
+
+	aReported, bReported := a != auctiontypes.Resources{}, b != auctiontypes.Resources{}
+	if aReported != bReported {
+		return aReported
+	}
+
+	if a.MemoryMB != b.MemoryMB {
+		return a.MemoryMB < b.MemoryMB
+	}
+	if a.DiskMB != b.DiskMB {
+		return a.DiskMB < b.DiskMB
+	}
+	return a.Containers < b.Containers
+}
@@ -0,0 +1,65 @@
+package algorithms
+
+import "github.com/cloudfoundry-incubator/auction/auctiontypes"
+
+const DefaultNumChoices = 2
+
+// consecutive failed rounds before power_of_d_choices widens its pool
+const powerOfDChoicesFallbackRounds = 2
+
+/*
+
+Get the bids from d random reps (Rules.NumChoices, DefaultNumChoices if unset)
+	Tell the best, as ranked by Rules.Placement, to reserve
+		If the reservation succeeds -- we have a winner
+
+After powerOfDChoicesFallbackRounds failed rounds, bid the wider pool that
+MaxBiddingPoolFraction and MinBiddingPool describe instead, so that a nearly
+full fleet is still searched thoroughly.
+
+*/
+
+func powerOfDChoicesAuction(client auctiontypes.RepPoolClient, auctionRequest StartAuctionRequest) (string, int, int) {
+	rounds, numCommunications := 1, 0
+	auctionInfo := auctiontypes.NewStartAuctionInfoFromLRPStartAuction(auctionRequest.LRPStartAuction)
+
+	d := auctionRequest.Rules.NumChoices
+	if d < 1 {
+		d = DefaultNumChoices
+	}
+
+	for ; rounds <= auctionRequest.Rules.MaxRounds; rounds++ {
+		//pick d reps, or the wider pool once d have failed us
+		var reps auctiontypes.RepGuids
+		if rounds <= powerOfDChoicesFallbackRounds {
+			reps = auctionRequest.RepGuids.RandomSubsetByCount(d)
+		} else {
+			minBiddingPool := auctionRequest.Rules.MinBiddingPool
+			if minBiddingPool < d {
+				minBiddingPool = d
+			}
+			reps = auctionRequest.RepGuids.RandomSubsetByFraction(auctionRequest.Rules.MaxBiddingPoolFraction, minBiddingPool)
+		}
+
+		//get their bids, if they're all full: try again
+		numCommunications += len(reps)
+		scores := client.BidForStartAuction(reps, auctionInfo)
+		if scores.AllFailed() {
+			continue
+		}
+
+		winner := rankBids(client, scores, auctionRequest.Rules.Placement)[0]
+
+		numCommunications += 1
+		if client.RebidThenTentativelyReserve([]string{winner.Rep}, auctionInfo).AllFailed() {
+			continue
+		}
+
+		numCommunications += 1
+		client.Run(winner.Rep, auctionRequest.LRPStartAuction)
+
+		return winner.Rep, rounds, numCommunications
+	}
+
+	return "", rounds, numCommunications
+}
@@ -0,0 +1,79 @@
+package algorithms_test
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("power_of_d_choices", func() {
+	var algorithm Algorithm
+	var reps map[string]auctiontypes.Resources
+	var request StartAuctionRequest
+
+	BeforeEach(func() {
+		var err error
+		algorithm, err = Lookup("power_of_d_choices")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		reps = map[string]auctiontypes.Resources{}
+		repGuids := auctiontypes.RepGuids{}
+		for i := 0; i < 100; i++ {
+			repGuid := fmt.Sprintf("rep-%d", i)
+			reps[repGuid] = auctiontypes.Resources{MemoryMB: 1024, DiskMB: 1024, Containers: 10}
+			repGuids = append(repGuids, repGuid)
+		}
+
+		request = StartAuctionRequest{
+			LRPStartAuction: models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", MemoryMB: 256, DiskMB: 256},
+			RepGuids:        repGuids,
+			Rules: StartAuctionRules{
+				Algorithm:              "power_of_d_choices",
+				MaxRounds:              10,
+				MaxBiddingPoolFraction: 0.5,
+				MinBiddingPool:         10,
+				NumChoices:             3,
+			},
+		}
+	})
+
+	It("bids d reps and reserves the best of them", func() {
+		pool := simulation.NewRepPool(reps)
+
+		_, numRounds, numCommunications := algorithm.RunStartAuction(pool, request)
+		Ω(numRounds).Should(Equal(1))
+		Ω(numCommunications).Should(Equal(3 + 2))
+		Ω(pool.Communications()).Should(Equal(3 + 2))
+	})
+
+	It("bids two reps when d isn't given", func() {
+		request.Rules.NumChoices = 0
+		pool := simulation.NewRepPool(reps)
+
+		_, _, numCommunications := algorithm.RunStartAuction(pool, request)
+		Ω(numCommunications).Should(Equal(DefaultNumChoices + 2))
+	})
+
+	Context("when d reps keep turning out to be full", func() {
+		BeforeEach(func() {
+			for repGuid := range reps {
+				reps[repGuid] = auctiontypes.Resources{MemoryMB: 1024, DiskMB: 1024, Containers: 0}
+			}
+		})
+
+		It("widens the pool after two rounds", func() {
+			pool := simulation.NewRepPool(reps)
+			request.Rules.MaxRounds = 3
+
+			winner, _, numCommunications := algorithm.RunStartAuction(pool, request)
+			Ω(winner).Should(BeEmpty())
+			Ω(numCommunications).Should(Equal(3 + 3 + 50))
+		})
+	})
+})
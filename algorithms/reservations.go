@@ -0,0 +1,167 @@
+package algorithms
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+/*
+
+ReservationTracker is a RepPoolClient that remembers, by instance guid, the
+tentative reservations made through it until they are run or released, and
+which instances it has sent to reps to run.
+
+Fencing the tracker releases every outstanding reservation and stops any more
+instances being run, so that auctions abandoned mid-flight (say, by an
+auctioneer that is shutting down or has lost its lock) leave nothing behind on
+the reps.
+
+It should wrap the client that talks to the reps directly, so that it sees
+the reservations made and released by every other client wrapped around it.
+
+*/
+
+type ReservationTracker struct {
+	auctiontypes.RepPoolClient
+
+	lock     sync.Mutex
+	auctions map[string]*trackedAuction
+	fenced   bool
+}
+
+type trackedAuction struct {
+	info     auctiontypes.StartAuctionInfo
+	reserved map[string]bool
+	ran      bool
+}
+
+func NewReservationTracker(client auctiontypes.RepPoolClient) *ReservationTracker {
+	return &ReservationTracker{
+		RepPoolClient: client,
+		auctions:      map[string]*trackedAuction{},
+	}
+}
+
+func (t *ReservationTracker) RebidThenTentativelyReserve(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	bids := t.RepPoolClient.RebidThenTentativelyReserve(repGuids, startAuctionInfo)
+
+	reserved := []string{}
+	for _, bid := range bids {
+		if bid.Error == "" {
+			reserved = append(reserved, bid.Rep)
+		}
+	}
+
+	t.lock.Lock()
+	if !t.fenced {
+		auction := t.auction(startAuctionInfo)
+		for _, repGuid := range reserved {
+			auction.reserved[repGuid] = true
+		}
+		reserved = nil
+	}
+	t.lock.Unlock()
+
+	//reservations made after the fence went up are released straight away
+	if len(reserved) > 0 {
+		t.RepPoolClient.ReleaseReservation(reserved, startAuctionInfo)
+	}
+
+	return bids
+}
+
+func (t *ReservationTracker) ReleaseReservation(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) {
+	t.lock.Lock()
+	auction, ok := t.auctions[startAuctionInfo.InstanceGuid]
+	if ok {
+		for _, repGuid := range repGuids {
+			delete(auction.reserved, repGuid)
+		}
+	}
+	t.lock.Unlock()
+
+	t.RepPoolClient.ReleaseReservation(repGuids, startAuctionInfo)
+}
+
+// Run sends the instance to the rep, unless the tracker is fenced
+func (t *ReservationTracker) Run(repGuid string, startAuction models.LRPStartAuction) {
+	t.lock.Lock()
+	if t.fenced {
+		t.lock.Unlock()
+		return
+	}
+
+	auction := t.auction(auctiontypes.NewStartAuctionInfoFromLRPStartAuction(startAuction))
+	auction.ran = true
+	delete(auction.reserved, repGuid)
+	t.lock.Unlock()
+
+	t.RepPoolClient.Run(repGuid, startAuction)
+}
+
+// Fence releases every outstanding reservation and stops any more instances
+// being run; it returns the number of reservations released
+func (t *ReservationTracker) Fence() int {
+	t.lock.Lock()
+	t.fenced = true
+
+	outstanding := []*trackedAuction{}
+	for _, auction := range t.auctions {
+		if len(auction.reserved) > 0 {
+			outstanding = append(outstanding, &trackedAuction{info: auction.info, reserved: auction.reserved})
+			auction.reserved = map[string]bool{}
+		}
+	}
+	t.lock.Unlock()
+
+	released := 0
+	for _, auction := range outstanding {
+		repGuids := []string{}
+		for repGuid := range auction.reserved {
+			repGuids = append(repGuids, repGuid)
+		}
+
+		t.RepPoolClient.ReleaseReservation(repGuids, auction.info)
+		released += len(repGuids)
+	}
+
+	return released
+}
+
+// Unfence lets instances be run again, once whatever the fence was put up for
+// is over
+func (t *ReservationTracker) Unfence() {
+	t.lock.Lock()
+	t.fenced = false
+	t.lock.Unlock()
+}
+
+// Ran is true if the instance was sent to a rep to run
+func (t *ReservationTracker) Ran(instanceGuid string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	auction, ok := t.auctions[instanceGuid]
+	return ok && auction.ran
+}
+
+// Forget stops tracking an instance once its auction is over; any
+// reservations still outstanding for it are no longer released by Fence
+func (t *ReservationTracker) Forget(instanceGuid string) {
+	t.lock.Lock()
+	delete(t.auctions, instanceGuid)
+	t.lock.Unlock()
+}
+
+// must be called with the lock held
+func (t *ReservationTracker) auction(info auctiontypes.StartAuctionInfo) *trackedAuction {
+	auction, ok := t.auctions[info.InstanceGuid]
+	if !ok {
+		auction = &trackedAuction{info: info, reserved: map[string]bool{}}
+		t.auctions[info.InstanceGuid] = auction
+	}
+
+	return auction
+}
@@ -0,0 +1,84 @@
+package algorithms_test
+
+import (
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReservationTracker", func() {
+	var pool *simulation.RepPool
+	var tracker *ReservationTracker
+	var startAuction models.LRPStartAuction
+	var info auctiontypes.StartAuctionInfo
+
+	BeforeEach(func() {
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"a": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"b": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		tracker = NewReservationTracker(pool)
+
+		startAuction = models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", MemoryMB: 64, DiskMB: 64}
+		info = auctiontypes.NewStartAuctionInfoFromLRPStartAuction(startAuction)
+	})
+
+	It("should release outstanding reservations when fenced", func() {
+		tracker.RebidThenTentativelyReserve([]string{"a", "b", "missing"}, info)
+		Ω(pool.Reservations("a")).Should(HaveLen(1))
+
+		Ω(tracker.Fence()).Should(Equal(2))
+		Ω(pool.Reservations("a")).Should(BeEmpty())
+		Ω(pool.Reservations("b")).Should(BeEmpty())
+	})
+
+	It("should not release reservations twice", func() {
+		tracker.RebidThenTentativelyReserve([]string{"a", "b"}, info)
+		tracker.ReleaseReservation([]string{"b"}, info)
+		tracker.Run("a", startAuction)
+
+		Ω(tracker.Fence()).Should(Equal(0))
+		Ω(pool.SimulatedInstances("a")).Should(HaveLen(1))
+	})
+
+	It("should remember which instances it ran", func() {
+		Ω(tracker.Ran("ig")).Should(BeFalse())
+
+		tracker.Run("a", startAuction)
+		Ω(tracker.Ran("ig")).Should(BeTrue())
+
+		tracker.Forget("ig")
+		Ω(tracker.Ran("ig")).Should(BeFalse())
+	})
+
+	Context("once fenced", func() {
+		BeforeEach(func() {
+			tracker.Fence()
+		})
+
+		It("should not run instances", func() {
+			tracker.Run("a", startAuction)
+
+			Ω(pool.SimulatedInstances("a")).Should(BeEmpty())
+			Ω(tracker.Ran("ig")).Should(BeFalse())
+		})
+
+		It("should run instances again once unfenced", func() {
+			tracker.Unfence()
+			tracker.Run("a", startAuction)
+
+			Ω(pool.SimulatedInstances("a")).Should(HaveLen(1))
+		})
+
+		It("should release any new reservations straight away", func() {
+			bids := tracker.RebidThenTentativelyReserve([]string{"a"}, info)
+
+			Ω(bids).Should(HaveLen(1))
+			Ω(pool.Reservations("a")).Should(BeEmpty())
+		})
+	})
+})
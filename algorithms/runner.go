@@ -0,0 +1,121 @@
+package algorithms
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+)
+
+// BidObserver is told which reps each request for bids is sent to
+type BidObserver func(repGuids []string)
+
+type Runner struct {
+	client   auctiontypes.RepPoolClient
+	registry *Registry
+
+	observerLock *sync.RWMutex
+	observer     BidObserver
+}
+
+// NewRunner returns an AuctionRunner that runs start auctions with the
+// registry's algorithm named by the request's rules, spreading them across
+// the request's zones and keeping them apart as its anti-affinity asks.
+// Auctions that no rep answers fail with AllBiddersTimedOut, and those aborted
+// before their instance is run fail with AuctionAborted.
+// Stop auctions keep the instance off the request's draining reps where they
+// can.
+func NewRunner(client auctiontypes.RepPoolClient, registry *Registry) *Runner {
+	return &Runner{
+		client:       client,
+		registry:     registry,
+		observerLock: &sync.RWMutex{},
+	}
+}
+
+// SetBidObserver has the observer told of the requests for bids made from now
+// on, as they are sent
+func (r *Runner) SetBidObserver(observer BidObserver) {
+	r.observerLock.Lock()
+	r.observer = observer
+	r.observerLock.Unlock()
+}
+
+func (r *Runner) bidObserver() BidObserver {
+	r.observerLock.RLock()
+	defer r.observerLock.RUnlock()
+
+	return r.observer
+}
+
+func (r *Runner) RunLRPStartAuction(auctionRequest StartAuctionRequest) (StartAuctionResult, error) {
+	result := StartAuctionResult{
+		LRPStartAuction: auctionRequest.LRPStartAuction,
+	}
+
+	algorithm, err := r.registry.Lookup(auctionRequest.Rules.Algorithm)
+	if err != nil {
+		return result, err
+	}
+
+	//responses are tracked beneath the aborting client, so that only the
+	//requests actually sent are counted
+	responses := &responseTrackingClient{RepPoolClient: r.client, observer: r.bidObserver()}
+	aborting := newAbortingClient(responses, auctionRequest.Abort)
+
+	var client auctiontypes.RepPoolClient = aborting
+	if len(auctionRequest.RepZones) > 0 {
+		client = &zoneSpreadingClient{
+			RepPoolClient:    client,
+			repZones:         auctionRequest.RepZones,
+			instancesPerZone: auctionRequest.InstancesPerZone,
+		}
+	}
+
+	switch auctionRequest.AntiAffinity {
+	case AntiAffinitySoft, AntiAffinityHard:
+		client = &antiAffinityClient{
+			RepPoolClient:   client,
+			mode:            auctionRequest.AntiAffinity,
+			instancesPerRep: auctionRequest.InstancesPerRep,
+		}
+	}
+
+	t := time.Now()
+	result.Winner, result.NumRounds, result.NumCommunications = algorithm.RunStartAuction(client, auctionRequest)
+	result.BiddingDuration = time.Since(t)
+
+	if aborting.abortedBeforeRun() {
+		result.Winner = ""
+		return result, AuctionAborted
+	}
+
+	if result.Winner == "" {
+		if responses.nobodyAnswered() {
+			return result, AllBiddersTimedOut
+		}
+
+		return result, auctiontypes.InsufficientResources
+	}
+
+	if len(auctionRequest.RepZones) > 0 {
+		result.Zone, result.InstancesPerZone = instancesPerZoneAfter(auctionRequest, result.Winner)
+	}
+
+	return result, nil
+}
+
+func (r *Runner) RunLRPStopAuction(auctionRequest StopAuctionRequest) (auctiontypes.StopAuctionResult, error) {
+	result := auctiontypes.StopAuctionResult{
+		LRPStopAuction: auctionRequest.LRPStopAuction,
+	}
+
+	responses := &responseTrackingClient{RepPoolClient: r.client, observer: r.bidObserver()}
+
+	var err error
+	t := time.Now()
+	result.Winner, result.NumCommunications, err = stopAuction(responses, auctionRequest)
+	result.BiddingDuration = time.Since(t)
+
+	return result, err
+}
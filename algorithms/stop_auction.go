@@ -0,0 +1,88 @@
+package algorithms
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+/*
+
+Get the bids of every rep
+	Keep one instance on the rep with the lowest bid once the rest are stopped
+		Stop every other instance
+
+This is the auction package's stop auction, except that the instance is kept
+off the request's draining reps where another rep holds one.
+
+*/
+
+func stopAuction(client auctiontypes.RepPoolClient, auctionRequest StopAuctionRequest) (string, int, error) {
+	numCommunication := 0
+
+	stopAuctionInfo := auctiontypes.StopAuctionInfo{
+		ProcessGuid: auctionRequest.LRPStopAuction.ProcessGuid,
+		Index:       auctionRequest.LRPStopAuction.Index,
+	}
+
+	numCommunication += len(auctionRequest.RepGuids)
+	stopAuctionBids := client.BidForStopAuction(auctionRequest.RepGuids, stopAuctionInfo)
+	stopAuctionBids = stopAuctionBids.FilterErrors()
+
+	instanceGuids := stopAuctionBids.InstanceGuids()
+	if len(instanceGuids) <= 1 {
+		return "", numCommunication, auctiontypes.NothingToStop
+	}
+
+	stopAuctionBids = stopAuctionBids.Shuffle()
+
+	draining := map[string]bool{}
+	for _, repGuid := range auctionRequest.DrainingRepGuids {
+		draining[repGuid] = true
+	}
+
+	keepers := auctiontypes.StopAuctionBids{}
+	for _, stopAuctionBid := range stopAuctionBids {
+		if !draining[stopAuctionBid.Rep] && len(stopAuctionBid.InstanceGuids) > 0 {
+			keepers = append(keepers, stopAuctionBid)
+		}
+	}
+	if len(keepers) == 0 {
+		keepers = stopAuctionBids
+	}
+
+	var repGuidWithLoneRemainingInstance string
+	lowestScore := 1e9
+
+	for _, stopAuctionBid := range keepers {
+		bidIfRepGuidWins := stopAuctionBid.Bid - float64(len(stopAuctionBid.InstanceGuids)) + 1
+		if bidIfRepGuidWins < lowestScore {
+			lowestScore = bidIfRepGuidWins
+			repGuidWithLoneRemainingInstance = stopAuctionBid.Rep
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, stopAuctionBid := range stopAuctionBids {
+		instanceGuidsToStop := stopAuctionBid.InstanceGuids
+		if stopAuctionBid.Rep == repGuidWithLoneRemainingInstance {
+			instanceGuidsToStop = instanceGuidsToStop[1:]
+		}
+		for _, instanceGuid := range instanceGuidsToStop {
+			numCommunication += 1
+			wg.Add(1)
+			go func(repGuid string, instanceGuid string) {
+				client.Stop(repGuid, models.StopLRPInstance{
+					ProcessGuid:  stopAuctionInfo.ProcessGuid,
+					InstanceGuid: instanceGuid,
+					Index:        stopAuctionInfo.Index,
+				})
+				wg.Done()
+			}(stopAuctionBid.Rep, instanceGuid)
+		}
+	}
+	wg.Wait()
+
+	return repGuidWithLoneRemainingInstance, numCommunication, nil
+}
@@ -0,0 +1,59 @@
+package algorithms
+
+import (
+	"sync/atomic"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+)
+
+/*
+
+responseTrackingClient notes whether any rep answered a start auction, so
+that an auction nobody answered can be told apart from one that every rep
+turned down.  Reps that miss the timeout are left out of the bids altogether,
+whereas reps that refuse still bid, with an Error.
+
+It also tells its observer, if it has one, which reps are asked to bid as
+they are asked, since an algorithm needn't ask every rep it is given.
+
+*/
+
+type responseTrackingClient struct {
+	auctiontypes.RepPoolClient
+	observer  BidObserver
+	asked     int32
+	responses int32
+}
+
+func (c *responseTrackingClient) BidForStartAuction(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	c.observe(repGuids)
+	return c.track(repGuids, c.RepPoolClient.BidForStartAuction(repGuids, startAuctionInfo))
+}
+
+func (c *responseTrackingClient) RebidThenTentativelyReserve(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	c.observe(repGuids)
+	return c.track(repGuids, c.RepPoolClient.RebidThenTentativelyReserve(repGuids, startAuctionInfo))
+}
+
+func (c *responseTrackingClient) BidForStopAuction(repGuids []string, stopAuctionInfo auctiontypes.StopAuctionInfo) auctiontypes.StopAuctionBids {
+	c.observe(repGuids)
+	return c.RepPoolClient.BidForStopAuction(repGuids, stopAuctionInfo)
+}
+
+func (c *responseTrackingClient) observe(repGuids []string) {
+	if c.observer != nil && len(repGuids) > 0 {
+		c.observer(repGuids)
+	}
+}
+
+func (c *responseTrackingClient) track(repGuids []string, bids auctiontypes.StartAuctionBids) auctiontypes.StartAuctionBids {
+	atomic.AddInt32(&c.asked, int32(len(repGuids)))
+	atomic.AddInt32(&c.responses, int32(len(bids)))
+
+	return bids
+}
+
+// nobodyAnswered is true if reps were asked to bid and none did
+func (c *responseTrackingClient) nobodyAnswered() bool {
+	return atomic.LoadInt32(&c.asked) > 0 && atomic.LoadInt32(&c.responses) == 0
+}
@@ -0,0 +1,90 @@
+package algorithms
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+/*
+
+The auctioneer's own auction types.  They carry what the auction package's
+requests, results and rules don't: the zones, anti-affinity, abort channel
+and draining reps that the runner's clients act on, and the rules that only
+this package's algorithms read.  The auction package's own algorithms are
+given its types, see auctionRequest.
+
+*/
+
+// returned by the runner, as well as the auction package's errors
+var AllBiddersTimedOut = errors.New("no bidder responded in time")
+var AuctionAborted = errors.New("auction aborted")
+
+// AuctionRunner runs auctions as the Runner does.  Start auctions are aborted
+// by closing their request's Abort channel; an aborted auction sends no
+// further Run and fails with AuctionAborted unless its winner was already
+// told to run.
+type AuctionRunner interface {
+	RunLRPStartAuction(auctionRequest StartAuctionRequest) (StartAuctionResult, error)
+	RunLRPStopAuction(auctionRequest StopAuctionRequest) (auctiontypes.StopAuctionResult, error)
+}
+
+type StartAuctionRequest struct {
+	LRPStartAuction models.LRPStartAuction
+	RepGuids        auctiontypes.RepGuids
+	Rules           StartAuctionRules
+
+	RepZones         map[string]string //rep guid -> zone, for reps that have one
+	InstancesPerZone map[string]int    //instances of the process already in each zone
+
+	AntiAffinity    string         //"", "none", "soft" or "hard"
+	InstancesPerRep map[string]int //instances of the process already on each rep
+
+	Abort <-chan struct{} //closed to abort the auction; nil if it can't be
+}
+
+type StartAuctionResult struct {
+	LRPStartAuction   models.LRPStartAuction
+	Winner            string
+	NumRounds         int
+	NumCommunications int
+	BiddingDuration   time.Duration
+	Duration          time.Duration
+
+	Zone             string         //of the winner
+	InstancesPerZone map[string]int //including the instance just placed
+}
+
+type StopAuctionRequest struct {
+	LRPStopAuction models.LRPStopAuction
+	RepGuids       auctiontypes.RepGuids
+
+	DrainingRepGuids auctiontypes.RepGuids //reps that keep no instance if another rep can
+}
+
+// StartAuctionRules are the auction package's rules, along with NumChoices
+// for power_of_d_choices and Placement for the algorithms that honour it
+type StartAuctionRules struct {
+	Algorithm              string
+	MaxRounds              int
+	MaxBiddingPoolFraction float64
+	MinBiddingPool         int
+	NumChoices             int
+	Placement              string
+}
+
+// the request as the auction package's algorithms take it
+func (r StartAuctionRequest) auctionRequest() auctiontypes.StartAuctionRequest {
+	return auctiontypes.StartAuctionRequest{
+		LRPStartAuction: r.LRPStartAuction,
+		RepGuids:        r.RepGuids,
+		Rules: auctiontypes.StartAuctionRules{
+			Algorithm:              r.Rules.Algorithm,
+			MaxRounds:              r.Rules.MaxRounds,
+			MaxBiddingPoolFraction: r.Rules.MaxBiddingPoolFraction,
+			MinBiddingPool:         r.Rules.MinBiddingPool,
+		},
+	}
+}
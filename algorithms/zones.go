@@ -0,0 +1,72 @@
+package algorithms
+
+import "github.com/cloudfoundry-incubator/auction/auctiontypes"
+
+// added to a rep's bid for every instance of the process already in the rep's
+// zone; bids are fractions of a rep in use, so this outweighs any difference
+// in load between reps
+const ZoneSpreadPenalty = 1.0
+
+/*
+
+zoneSpreadingClient penalizes the start auction bids of reps in zones that
+already host instances of the process, so that whichever algorithm is running
+spreads the process across zones before it spreads it across reps.
+
+Packing ranks reps by their penalties before their fit, and so packs within
+the zones with the fewest instances of the process.
+
+*/
+
+type zoneSpreadingClient struct {
+	auctiontypes.RepPoolClient
+	repZones         map[string]string
+	instancesPerZone map[string]int
+}
+
+func (c *zoneSpreadingClient) BidForStartAuction(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	return c.penalize(c.RepPoolClient.BidForStartAuction(repGuids, startAuctionInfo))
+}
+
+func (c *zoneSpreadingClient) RebidThenTentativelyReserve(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	return c.penalize(c.RepPoolClient.RebidThenTentativelyReserve(repGuids, startAuctionInfo))
+}
+
+func (c *zoneSpreadingClient) penalize(bids auctiontypes.StartAuctionBids) auctiontypes.StartAuctionBids {
+	for i := range bids {
+		zone, ok := c.repZones[bids[i].Rep]
+		if !ok || bids[i].Error != "" {
+			continue
+		}
+
+		bids[i].Bid += ZoneSpreadPenalty * float64(c.instancesPerZone[zone])
+	}
+
+	return bids
+}
+
+func (c *zoneSpreadingClient) penalty(repGuid string) float64 {
+	penalty := penaltyOf(c.RepPoolClient, repGuid)
+
+	zone, ok := c.repZones[repGuid]
+	if ok {
+		penalty += ZoneSpreadPenalty * float64(c.instancesPerZone[zone])
+	}
+
+	return penalty
+}
+
+// the distribution of the process across zones once the winner runs it
+func instancesPerZoneAfter(auctionRequest StartAuctionRequest, winner string) (string, map[string]int) {
+	instancesPerZone := map[string]int{}
+	for zone, instances := range auctionRequest.InstancesPerZone {
+		instancesPerZone[zone] = instances
+	}
+
+	zone, ok := auctionRequest.RepZones[winner]
+	if ok {
+		instancesPerZone[zone]++
+	}
+
+	return zone, instancesPerZone
+}
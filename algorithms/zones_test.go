@@ -0,0 +1,118 @@
+package algorithms_test
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spreading across zones", func() {
+	var pool *simulation.RepPool
+	var runner AuctionRunner
+	var request StartAuctionRequest
+
+	BeforeEach(func() {
+		pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+			"z1-busy":  {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"z1-empty": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+			"z2-busy":  {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+		})
+		pool.SetSimulatedInstances("z1-busy", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "pg", InstanceGuid: "a", Index: 0, MemoryMB: 256, DiskMB: 256},
+		})
+		pool.SetSimulatedInstances("z2-busy", []auctiontypes.SimulatedInstance{
+			{ProcessGuid: "other", InstanceGuid: "b", Index: 0, MemoryMB: 512, DiskMB: 512},
+		})
+		runner = NewRunner(pool, DefaultRegistry)
+
+		request = StartAuctionRequest{
+			LRPStartAuction: models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", Index: 1, MemoryMB: 256, DiskMB: 256},
+			RepGuids:        auctiontypes.RepGuids{"z1-busy", "z1-empty", "z2-busy"},
+			Rules: StartAuctionRules{
+				MaxRounds:              1,
+				MaxBiddingPoolFraction: 1,
+				MinBiddingPool:         3,
+			},
+			RepZones: map[string]string{
+				"z1-busy":  "z1",
+				"z1-empty": "z1",
+				"z2-busy":  "z2",
+			},
+			InstancesPerZone: map[string]int{"z1": 1},
+		}
+	})
+
+	for _, name := range []string{"pick_best", "reserve_n_best", "power_of_d_choices"} {
+		name := name
+
+		It(fmt.Sprintf("%s prefers a busier rep in a zone without the process to an empty rep in a zone with it", name), func() {
+			request.Rules.Algorithm = name
+			request.Rules.NumChoices = 3
+
+			result, err := runner.RunLRPStartAuction(request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result.Winner).Should(Equal("z2-busy"))
+		})
+	}
+
+	Context("when packing", func() {
+		BeforeEach(func() {
+			//z1-busy is the tightest fit, but in the zone with the process
+			pool.SetSimulatedInstances("z1-busy", []auctiontypes.SimulatedInstance{
+				{ProcessGuid: "pg", InstanceGuid: "a", Index: 0, MemoryMB: 768, DiskMB: 768},
+			})
+
+			request.Rules.Placement = PlacementPack
+			request.Rules.NumChoices = 3
+		})
+
+		for _, name := range []string{"bin_pack", "power_of_d_choices"} {
+			name := name
+
+			It(fmt.Sprintf("%s packs into the zone without the process", name), func() {
+				request.Rules.Algorithm = name
+
+				result, err := runner.RunLRPStartAuction(request)
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(result.Winner).Should(Equal("z2-busy"))
+			})
+		}
+
+		It("packs onto the tightest fit when there are no zones", func() {
+			request.Rules.Algorithm = "bin_pack"
+			request.RepZones = nil
+			request.InstancesPerZone = nil
+
+			result, err := runner.RunLRPStartAuction(request)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(result.Winner).Should(Equal("z1-busy"))
+		})
+	})
+
+	It("reports the winner's zone and the resulting distribution", func() {
+		request.Rules.Algorithm = "pick_best"
+
+		result, err := runner.RunLRPStartAuction(request)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Zone).Should(Equal("z2"))
+		Ω(result.InstancesPerZone).Should(Equal(map[string]int{"z1": 1, "z2": 1}))
+		Ω(request.InstancesPerZone).Should(Equal(map[string]int{"z1": 1}))
+	})
+
+	It("places on the emptiest rep when there are no zones", func() {
+		request.Rules.Algorithm = "pick_best"
+		request.RepZones = nil
+		request.InstancesPerZone = nil
+
+		result, err := runner.RunLRPStartAuction(request)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(result.Winner).Should(Equal("z1-empty"))
+		Ω(result.InstancesPerZone).Should(BeNil())
+	})
+})
@@ -1,12 +1,18 @@
 package auctioneer
 
 import (
+	"errors"
 	"os"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/cloudfoundry-incubator/auction/auctionrunner"
 	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/breaker"
+	"github.com/cloudfoundry-incubator/auctioneer/metrics"
+	"github.com/cloudfoundry/storeadapter"
 	"github.com/nu7hatch/gouuid"
 	"github.com/pivotal-golang/lager"
 
@@ -15,205 +21,1559 @@ import (
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
 )
 
+const DefaultFailedStartAuctionTTL = time.Hour
+
+// ErrLockNotHeld is returned by the admin actions of an auctioneer that isn't
+// running auctions for anyone to control
+var ErrLockNotHeld = errors.New("this auctioneer does not hold the lock")
+
+// watches that fail (or are closed out from under us) while we hold the lock
+// are re-established with capped exponential backoff
+const (
+	watchRetryMinInterval = 100 * time.Millisecond
+	watchRetryMaxInterval = 10 * time.Second
+)
+
+type Config struct {
+	// number of workers running auctions, shared by start and stop auctions
+	MaxConcurrent int
+
+	// number of auctions that may wait for a worker before the watches are
+	// no longer drained
+	MaxQueuedAuctions int
+
+	// relative share of the workers given to each kind of auction when both
+	// are waiting
+	StartAuctionShare int
+	StopAuctionShare  int
+
+	// maximum number of start auctions for a single process guid running at
+	// once; 0 means no limit
+	MaxConcurrentPerProcess int
+
+	// order in which waiting start auctions are run; defaults to
+	// DefaultStartAuctionPriorityCriteria
+	StartAuctionPriority StartAuctionPriority
+
+	// rules for start auctions, overridden per stack by StackStartAuctionRules
+	StartAuctionRules      algorithms.StartAuctionRules
+	StackStartAuctionRules map[string]algorithms.StartAuctionRules
+
+	// which executors' stacks can run apps asking for which stacks
+	Stacks StackCompatibility
+
+	// whether instances of a process are kept off reps that already host it
+	// ("none", "soft" or "hard"), unless a start auction says otherwise
+	AntiAffinity string
+
+	// how start auctions that fail are retried
+	StartAuctionRetry RetryPolicy
+
+	// how long the BBS keeps the record of why a start auction failed;
+	// defaults to DefaultFailedStartAuctionTTL
+	FailedStartAuctionTTL time.Duration
+
+	// how often the cached executors are re-listed in full, in case their
+	// watch missed anything
+	ExecutorRelistInterval time.Duration
+
+	// where the auctioneer's metrics are registered; they are kept privately
+	// if nil
+	Metrics *metrics.Registry
+
+	// how long a stopping auctioneer waits for the auctions it is running to
+	// finish before abandoning them; 0 means it does not wait
+	DrainTimeout time.Duration
+
+	// how often instances are moved off cordoned executors being drained, and
+	// how long each may take to be replaced before it is skipped; default to
+	// DefaultExecutorDrainInterval and DefaultExecutorDrainReplacementTimeout
+	ExecutorDrainInterval           time.Duration
+	ExecutorDrainReplacementTimeout time.Duration
+
+	// the reps whose load is sampled, every RebalanceInterval, to move
+	// instances off the most loaded; rebalancing is off if either is unset
+	RepPool           auctiontypes.RepPoolClient
+	RebalanceInterval time.Duration
+
+	// how much more loaded than the least loaded rep the most loaded may be
+	// before its instances are moved, how many are moved each interval, how
+	// many instances of a process may be unavailable at once, and how long
+	// each may take to be replaced; default to DefaultRebalanceThreshold,
+	// DefaultRebalanceMaxMoves, DefaultRebalanceMaxUnavailable and
+	// DefaultRebalanceReplacementTimeout
+	RebalanceThreshold          float64
+	RebalanceMaxMoves           int
+	RebalanceMaxUnavailable     int
+	RebalanceReplacementTimeout time.Duration
+
+	// whether moves are only logged
+	RebalanceDryRun bool
+
+	// the reservations the runner makes on reps, released when auctions are
+	// abandoned; if nil, abandoned start auctions are left claimed for the
+	// converger
+	Reservations *algorithms.ReservationTracker
+
+	// the breakers that keep reps that aren't answering out of auctions; if
+	// nil, every rep is asked
+	RepBreakers *breaker.Breakers
+
+	LockInterval time.Duration
+}
+
 type Auctioneer struct {
-	bbs           Bbs.AuctioneerBBS
-	runner        auctiontypes.AuctionRunner
-	maxConcurrent int
-	maxRounds     int
-	logger        lager.Logger
-	semaphore     chan bool
-	lockInterval  time.Duration
+	bbs          Bbs.AuctioneerBBS
+	runner       algorithms.AuctionRunner
+	held         *heldStartAuctions
+	executors    *executorRegistry
+	logger       lager.Logger
+	lockInterval time.Duration
+	scheduler    *scheduler
+	metrics      *auctioneerMetrics
+	reservations *algorithms.ReservationTracker
+	repBreakers  *breaker.Breakers
+
+	cordons         *cordons
+	replacer        *replacer
+	executorDrainer *executorDrainer
+	rebalancer      *rebalancer
+
+	// replaced, never modified, by Reconfigure
+	settingsLock *sync.RWMutex
+	settings     *settings
+
+	// start auctions claimed by this auctioneer and not yet over, which are
+	// aborted if the lock is lost and taken back by a drain that times out
+	claimLock     *sync.Mutex
+	claimedStarts map[auctionKey]*claimedStartAuction
+	draining      bool
+
+	// 1 while the lock is held, accessed atomically
+	haveLock int32
+
+	// admin actions for the Run loop
+	pauseChanged chan struct{}
+	stepDown     chan struct{}
+
+	// serializes changes to, and reloads of, the pause, which is guarded by
+	// the statusLock
+	pauseUpdateLock *sync.Mutex
+
+	statusLock     *sync.RWMutex
+	id             string
+	watchingStarts bool
+	watchingStops  bool
+	lastAuctionAt  time.Time
+	pause          models.AuctioneerPause
+}
+
+// settings are the parts of the Config that auctions read as they run
+type settings struct {
+	rules        algorithms.StartAuctionRules
+	stackRules   map[string]algorithms.StartAuctionRules
+	stacks       StackCompatibility
+	antiAffinity string
+	retryPolicy  RetryPolicy
+	failedTTL    time.Duration
+	drainTimeout time.Duration
+
+	executorDrainInterval           time.Duration
+	executorDrainReplacementTimeout time.Duration
+
+	rebalanceThreshold          float64
+	rebalanceMaxMoves           int
+	rebalanceMaxUnavailable     int
+	rebalanceReplacementTimeout time.Duration
+	rebalanceDryRun             bool
+}
+
+func newSettings(config Config) *settings {
+	failedTTL := config.FailedStartAuctionTTL
+	if failedTTL == 0 {
+		failedTTL = DefaultFailedStartAuctionTTL
+	}
+
+	executorDrainInterval := config.ExecutorDrainInterval
+	if executorDrainInterval == 0 {
+		executorDrainInterval = DefaultExecutorDrainInterval
+	}
+
+	executorDrainReplacementTimeout := config.ExecutorDrainReplacementTimeout
+	if executorDrainReplacementTimeout == 0 {
+		executorDrainReplacementTimeout = DefaultExecutorDrainReplacementTimeout
+	}
+
+	rebalanceThreshold := config.RebalanceThreshold
+	if rebalanceThreshold == 0 {
+		rebalanceThreshold = DefaultRebalanceThreshold
+	}
+
+	rebalanceMaxMoves := config.RebalanceMaxMoves
+	if rebalanceMaxMoves == 0 {
+		rebalanceMaxMoves = DefaultRebalanceMaxMoves
+	}
+
+	rebalanceMaxUnavailable := config.RebalanceMaxUnavailable
+	if rebalanceMaxUnavailable == 0 {
+		rebalanceMaxUnavailable = DefaultRebalanceMaxUnavailable
+	}
+
+	rebalanceReplacementTimeout := config.RebalanceReplacementTimeout
+	if rebalanceReplacementTimeout == 0 {
+		rebalanceReplacementTimeout = DefaultRebalanceReplacementTimeout
+	}
+
+	return &settings{
+		rules:        config.StartAuctionRules,
+		stackRules:   config.StackStartAuctionRules,
+		stacks:       config.Stacks,
+		antiAffinity: config.AntiAffinity,
+		retryPolicy:  config.StartAuctionRetry,
+		failedTTL:    failedTTL,
+		drainTimeout: config.DrainTimeout,
+
+		executorDrainInterval:           executorDrainInterval,
+		executorDrainReplacementTimeout: executorDrainReplacementTimeout,
+
+		rebalanceThreshold:          rebalanceThreshold,
+		rebalanceMaxMoves:           rebalanceMaxMoves,
+		rebalanceMaxUnavailable:     rebalanceMaxUnavailable,
+		rebalanceReplacementTimeout: rebalanceReplacementTimeout,
+		rebalanceDryRun:             config.RebalanceDryRun,
+	}
+}
+
+type claimedStartAuction struct {
+	startAuction models.LRPStartAuction
+	abort        chan struct{}
+	aborted      bool
+}
+
+// Status is what an auctioneer reports about itself
+type Status struct {
+	AuctioneerID           string          `json:"auctioneer_id"`
+	HaveLock               bool            `json:"have_lock"`
+	WatchingStartAuctions  bool            `json:"watching_start_auctions"`
+	WatchingStopAuctions   bool            `json:"watching_stop_auctions"`
+	StartAuctionsPaused    bool            `json:"start_auctions_paused"`
+	StopAuctionsPaused     bool            `json:"stop_auctions_paused"`
+	CordonedExecutors      []string        `json:"cordoned_executors"`
+	DrainingExecutors      []string        `json:"draining_executors"`
+	Drains                 []ExecutorDrain `json:"drains"`
+	RebalancingInstances   int             `json:"rebalancing_instances"`
+	WatchingExecutors      bool            `json:"watching_executors"`
+	InFlightStartAuctions  int             `json:"in_flight_start_auctions"`
+	InFlightStopAuctions   int             `json:"in_flight_stop_auctions"`
+	QueuedAuctions         int             `json:"queued_auctions"`
+	LastAuctionAt          time.Time       `json:"last_auction_at"`
+	ExecutorCacheStaleness float64         `json:"executor_cache_staleness_seconds"`
+}
+
+// Ready is true when the auctioneer holds the lock and is watching for both
+// kinds of auction, or has been told not to
+func (s Status) Ready() bool {
+	return s.HaveLock &&
+		(s.WatchingStartAuctions || s.StartAuctionsPaused) &&
+		(s.WatchingStopAuctions || s.StopAuctionsPaused)
+}
+
+func New(bbs Bbs.AuctioneerBBS, runner algorithms.AuctionRunner, config Config, logger lager.Logger) *Auctioneer {
+	a := &Auctioneer{
+		bbs:          bbs,
+		runner:       runner,
+		held:         newHeldStartAuctions(),
+		logger:       logger.Session("auctioneer"),
+		lockInterval: config.LockInterval,
+		reservations: config.Reservations,
+		repBreakers:  config.RepBreakers,
+		statusLock:   &sync.RWMutex{},
+
+		settingsLock: &sync.RWMutex{},
+		settings:     newSettings(config),
+
+		claimLock:     &sync.Mutex{},
+		claimedStarts: map[auctionKey]*claimedStartAuction{},
+
+		pauseChanged:    make(chan struct{}, 1),
+		stepDown:        make(chan struct{}, 1),
+		pauseUpdateLock: &sync.Mutex{},
+
+		cordons:  newCordons(),
+		replacer: newReplacer(bbs),
+	}
+
+	a.executors = newExecutorRegistry(bbs, config.ExecutorRelistInterval, a.logger)
+	a.executorDrainer = newExecutorDrainer(bbs, a.replacer, a.currentSettings, a.logger)
+	a.rebalancer = newRebalancer(
+		config.RepPool,
+		bbs,
+		a.executors,
+		a.cordons,
+		a.replacer,
+		a.canRebalance,
+		a.currentSettings,
+		config.RebalanceInterval,
+		a.logger,
+	)
+
+	registry := config.Metrics
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
+	a.metrics = newAuctioneerMetrics(registry, a)
+
+	a.scheduler = newScheduler(
+		config.MaxConcurrent,
+		config.MaxQueuedAuctions,
+		config.StartAuctionShare,
+		config.StopAuctionShare,
+		config.MaxConcurrentPerProcess,
+		startAuctionPriority(config),
+		a.runStartAuction,
+		a.runStopAuction,
+		a.countRunningInstances,
+		a.metrics.waited,
+		a.logger,
+	)
+
+	return a
+}
+
+func startAuctionPriority(config Config) StartAuctionPriority {
+	if config.StartAuctionPriority == nil {
+		priority, _ := NewStartAuctionPriority(DefaultStartAuctionPriorityCriteria...)
+		return priority
+	}
+
+	return config.StartAuctionPriority
+}
+
+// Reconfigure changes how the auctioneer runs auctions, while it runs.
+// Auctions already running finish with the settings they started with, and
+// workers beyond a lowered MaxConcurrent stop once their auction is over.
+// The ExecutorRelistInterval, Metrics, Reservations, RepPool,
+// RebalanceInterval, RepBreakers and LockInterval can't be changed, and are
+// ignored.
+func (a *Auctioneer) Reconfigure(config Config) {
+	a.settingsLock.Lock()
+	a.settings = newSettings(config)
+	a.settingsLock.Unlock()
+
+	a.scheduler.reconfigure(
+		config.MaxConcurrent,
+		config.MaxQueuedAuctions,
+		config.StartAuctionShare,
+		config.StopAuctionShare,
+		config.MaxConcurrentPerProcess,
+		startAuctionPriority(config),
+	)
+
+	a.logger.Info("reconfigured")
+}
+
+func (a *Auctioneer) currentSettings() *settings {
+	a.settingsLock.RLock()
+	defer a.settingsLock.RUnlock()
+
+	return a.settings
+}
+
+// QueueDepth is the number of auctions waiting for a worker
+func (a *Auctioneer) QueueDepth() int {
+	return a.scheduler.depth()
+}
+
+func (a *Auctioneer) Status() Status {
+	a.statusLock.RLock()
+	defer a.statusLock.RUnlock()
+
+	staleness := a.ExecutorCacheStaleness()
+
+	return Status{
+		AuctioneerID:           a.id,
+		HaveLock:               a.HasLock(),
+		WatchingStartAuctions:  a.watchingStarts,
+		WatchingStopAuctions:   a.watchingStops,
+		StartAuctionsPaused:    a.pause.StartAuctions,
+		StopAuctionsPaused:     a.pause.StopAuctions,
+		CordonedExecutors:      a.cordons.guids(),
+		DrainingExecutors:      a.executorDrainer.draining(),
+		Drains:                 a.executorDrainer.reported(),
+		RebalancingInstances:   a.rebalancer.inFlight(),
+		WatchingExecutors:      staleness == 0,
+		InFlightStartAuctions:  int(a.metrics.inFlight.Value(startAuctionKind)),
+		InFlightStopAuctions:   int(a.metrics.inFlight.Value(stopAuctionKind)),
+		QueuedAuctions:         a.QueueDepth(),
+		LastAuctionAt:          a.lastAuctionAt,
+		ExecutorCacheStaleness: staleness.Seconds(),
+	}
+}
+
+// HasLock reports whether this auctioneer holds the auctioneer lock
+func (a *Auctioneer) HasLock() bool {
+	return atomic.LoadInt32(&a.haveLock) == 1
+}
+
+// Paused reports which kinds of auction operators have paused
+func (a *Auctioneer) Paused() models.AuctioneerPause {
+	a.statusLock.RLock()
+	defer a.statusLock.RUnlock()
+
+	return a.pause
+}
+
+// PauseStartAuctions stops start auctions being run, leaving them pending
+// until they are resumed.  Auctions already running are let finish.
+func (a *Auctioneer) PauseStartAuctions() error {
+	return a.updatePause(func(pause *models.AuctioneerPause) {
+		pause.StartAuctions = true
+	})
+}
+
+// PauseStopAuctions is PauseStartAuctions for stop auctions
+func (a *Auctioneer) PauseStopAuctions() error {
+	return a.updatePause(func(pause *models.AuctioneerPause) {
+		pause.StopAuctions = true
+	})
+}
+
+// Resume runs both kinds of auction again, starting with those left pending
+func (a *Auctioneer) Resume() error {
+	return a.updatePause(func(pause *models.AuctioneerPause) {
+		pause.StartAuctions = false
+		pause.StopAuctions = false
+	})
+}
+
+// updatePause persists the new pause before acting on it, so that the next
+// auctioneer to hold the lock inherits it
+func (a *Auctioneer) updatePause(update func(*models.AuctioneerPause)) error {
+	a.pauseUpdateLock.Lock()
+	defer a.pauseUpdateLock.Unlock()
+
+	if !a.HasLock() {
+		return ErrLockNotHeld
+	}
+
+	pause := a.Paused()
+	update(&pause)
+	pause.UpdatedAt = time.Now().UnixNano()
+
+	err := a.bbs.SetAuctioneerPause(pause)
+	if err != nil {
+		a.logger.Error("failed-to-persist-pause", err)
+		return err
+	}
+
+	a.logger.Info("pause-changed", lager.Data{
+		"start-auctions-paused": pause.StartAuctions,
+		"stop-auctions-paused":  pause.StopAuctions,
+	})
+
+	a.setPause(pause)
+
+	select {
+	case a.pauseChanged <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// loadPause picks up the pause left by whoever held the lock before.  It
+// holds the pauseUpdateLock so that a pause read just before an update is
+// persisted can't then overwrite it.
+func (a *Auctioneer) loadPause() {
+	a.pauseUpdateLock.Lock()
+	defer a.pauseUpdateLock.Unlock()
+
+	pause, err := a.bbs.GetAuctioneerPause()
+	if err != nil {
+		a.logger.Error("failed-to-get-pause", err)
+		return
+	}
+
+	a.setPause(pause)
+}
+
+func (a *Auctioneer) setPause(pause models.AuctioneerPause) {
+	a.statusLock.Lock()
+	a.pause = pause
+	a.statusLock.Unlock()
+}
+
+// StepDown gives up the lock so that a standby auctioneer takes over.  The
+// auctioneer stops auctioning as if it had lost the lock, and contends for it
+// again once the standbys have had time to take it.
+func (a *Auctioneer) StepDown() error {
+	if !a.HasLock() {
+		return ErrLockNotHeld
+	}
+
+	select {
+	case a.stepDown <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// ExecutorCacheStaleness is how long the cached executors may have been
+// missing changes; zero while they are being watched
+func (a *Auctioneer) ExecutorCacheStaleness() time.Duration {
+	return a.executors.staleness()
+}
+
+func (a *Auctioneer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	guid, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	a.statusLock.Lock()
+	a.id = guid.String()
+	a.statusLock.Unlock()
+
+	haveLockChan, stopMaintainingLockChan, err := a.bbs.MaintainAuctioneerLock(a.lockInterval, guid.String())
+	if err != nil {
+		return err
+	}
+
+	a.scheduler.start()
+	a.executors.start()
+	a.rebalancer.start()
+
+	var haveLock bool
+
+	var startAuctionChan <-chan models.LRPStartAuction
+	var startErrorChan <-chan error
+	var cancelStartWatchChan chan<- bool
+	var startWatchRetryChan <-chan time.Time
+	startWatchRetryInterval := watchRetryMinInterval
+
+	var stopAuctionChan <-chan models.LRPStopAuction
+	var stopErrorChan <-chan error
+	var cancelStopWatchChan chan<- bool
+	var stopWatchRetryChan <-chan time.Time
+	stopWatchRetryInterval := watchRetryMinInterval
+
+	//closed once a stopping auctioneer has drained its auctions
+	var drainedChan <-chan struct{}
+
+	//fires once an auctioneer that stepped down may contend for the lock again
+	var rejoinChan <-chan time.Time
+
+	stopWatchingStarts := func() {
+		if cancelStartWatchChan != nil {
+			a.logger.Info("stopping-start-watch")
+			close(cancelStartWatchChan)
+		}
+
+		startAuctionChan, cancelStartWatchChan, startErrorChan = nil, nil, nil
+		startWatchRetryChan, startWatchRetryInterval = nil, watchRetryMinInterval
+	}
+
+	stopWatchingStops := func() {
+		if cancelStopWatchChan != nil {
+			a.logger.Info("stopping-stop-watch")
+			close(cancelStopWatchChan)
+		}
+
+		stopAuctionChan, cancelStopWatchChan, stopErrorChan = nil, nil, nil
+		stopWatchRetryChan, stopWatchRetryInterval = nil, watchRetryMinInterval
+	}
+
+	//watch for the kinds of auction that aren't paused, and drop those that are
+	applyPause := func() {
+		pause := a.Paused()
+
+		if pause.StartAuctions {
+			if startAuctionChan != nil || startWatchRetryChan != nil {
+				a.logger.Info("pausing-start-auctions")
+				stopWatchingStarts()
+			}
+
+			a.releaseHeldStartAuctions()
+			a.scheduler.dropStarts()
+		} else if startAuctionChan == nil {
+			startAuctionChan, cancelStartWatchChan, startErrorChan = a.bbs.WatchForLRPStartAuction()
+			startWatchRetryChan = nil
+
+			a.logger.Info("watching-for-start-auctions")
+
+			a.listPendingStartAuctions()
+		}
+
+		if pause.StopAuctions {
+			if stopAuctionChan != nil || stopWatchRetryChan != nil {
+				a.logger.Info("pausing-stop-auctions")
+				stopWatchingStops()
+			}
+
+			a.scheduler.dropStops()
+		} else if stopAuctionChan == nil {
+			stopAuctionChan, cancelStopWatchChan, stopErrorChan = a.bbs.WatchForLRPStopAuction()
+			stopWatchRetryChan = nil
+
+			a.logger.Info("watching-for-stop-auctions")
+
+			a.listPendingStopAuctions()
+		}
+	}
+
+	for {
+		a.setWatching(startAuctionChan != nil, stopAuctionChan != nil)
+
+		//the watches are left unread while the queue is full, pushing back on
+		//the BBS, but signals and the lock are still seen to
+		roomChan := a.scheduler.room()
+		watchedStarts, watchedStops := startAuctionChan, stopAuctionChan
+		if roomChan != nil {
+			watchedStarts, watchedStops = nil, nil
+		}
+
+		select {
+		case haveLock = <-haveLockChan:
+			a.logger.Info("lock-state", lager.Data{"have-lock": haveLock})
+			a.setHaveLock(haveLock)
+
+			if haveLock && drainedChan == nil {
+				if a.reservations != nil {
+					a.reservations.Unfence()
+				}
+
+				//the pause and cordons are re-read each time the lock is
+				//renewed, so changes made directly in the BBS are picked up too
+				a.loadPause()
+				a.loadCordons()
+				applyPause()
+
+				if ready != nil {
+					close(ready)
+					ready = nil
+				}
+			} else if !haveLock {
+				stopWatchingStarts()
+				stopWatchingStops()
+				a.releaseHeldStartAuctions()
+				a.stopAuctioning()
+			}
+
+		case <-roomChan:
+
+		case startAuction, ok := <-watchedStarts:
+			if !ok {
+				a.logger.Info("start-auction-watch-closed")
+				startAuctionChan, cancelStartWatchChan, startErrorChan = nil, nil, nil
+				startWatchRetryChan = time.After(startWatchRetryInterval)
+				startWatchRetryInterval = nextWatchRetryInterval(startWatchRetryInterval)
+				continue
+			}
+
+			startWatchRetryInterval = watchRetryMinInterval
+			a.dispatchStartAuction(startAuction)
+
+		case stopAuction, ok := <-watchedStops:
+			if !ok {
+				a.logger.Info("stop-auction-watch-closed")
+				stopAuctionChan, cancelStopWatchChan, stopErrorChan = nil, nil, nil
+				stopWatchRetryChan = time.After(stopWatchRetryInterval)
+				stopWatchRetryInterval = nextWatchRetryInterval(stopWatchRetryInterval)
+				continue
+			}
+
+			stopWatchRetryInterval = watchRetryMinInterval
+			a.dispatchStopAuction(stopAuction)
+
+		case err := <-startErrorChan:
+			a.logger.Error("watching-start-auctions-failed", err, lager.Data{"retry-in": startWatchRetryInterval.String()})
+			startAuctionChan, cancelStartWatchChan, startErrorChan = nil, nil, nil
+			startWatchRetryChan = time.After(startWatchRetryInterval)
+			startWatchRetryInterval = nextWatchRetryInterval(startWatchRetryInterval)
+
+		case err := <-stopErrorChan:
+			a.logger.Error("watching-stop-auctions-failed", err, lager.Data{"retry-in": stopWatchRetryInterval.String()})
+			stopAuctionChan, cancelStopWatchChan, stopErrorChan = nil, nil, nil
+			stopWatchRetryChan = time.After(stopWatchRetryInterval)
+			stopWatchRetryInterval = nextWatchRetryInterval(stopWatchRetryInterval)
+
+		case <-startWatchRetryChan:
+			startWatchRetryChan = nil
+			if !haveLock || startAuctionChan != nil {
+				continue
+			}
+
+			startAuctionChan, cancelStartWatchChan, startErrorChan = a.bbs.WatchForLRPStartAuction()
+			a.logger.Info("rewatching-for-start-auctions")
+
+			a.listPendingStartAuctions()
+
+		case <-stopWatchRetryChan:
+			stopWatchRetryChan = nil
+			if !haveLock || stopAuctionChan != nil {
+				continue
+			}
+
+			stopAuctionChan, cancelStopWatchChan, stopErrorChan = a.bbs.WatchForLRPStopAuction()
+			a.logger.Info("rewatching-for-stop-auctions")
+
+			a.listPendingStopAuctions()
+
+		case <-a.pauseChanged:
+			if haveLock && drainedChan == nil {
+				applyPause()
+			}
+
+		case <-a.stepDown:
+			if !haveLock || drainedChan != nil {
+				continue
+			}
+
+			a.logger.Info("stepping-down", lager.Data{"rejoin-in": a.stepDownHoldoff().String()})
+
+			haveLock = false
+			a.setHaveLock(false)
+
+			stopWatchingStarts()
+			stopWatchingStops()
+			a.releaseHeldStartAuctions()
+			a.stopAuctioning()
+
+			a.releaseLock(haveLockChan, stopMaintainingLockChan)
+			haveLockChan, stopMaintainingLockChan = nil, nil
+			rejoinChan = time.After(a.stepDownHoldoff())
+
+		case <-rejoinChan:
+			rejoinChan = nil
+
+			haveLockChan, stopMaintainingLockChan, err = a.bbs.MaintainAuctioneerLock(a.lockInterval, guid.String())
+			if err != nil {
+				a.logger.Error("failed-to-contend-for-lock", err)
+				rejoinChan = time.After(a.stepDownHoldoff())
+				continue
+			}
+
+			a.logger.Info("contending-for-lock")
+
+		case sig := <-signals:
+			if a.shouldStop(sig) && drainedChan == nil {
+				stopWatchingStarts()
+				stopWatchingStops()
+				rejoinChan = nil
+
+				a.executorDrainer.stop()
+				a.rebalancer.abandon()
+				a.releaseHeldStartAuctions()
+				drainedChan = a.drain()
+			}
+
+		//the lock is held, and so kept alive, until the drain is over
+		case <-drainedChan:
+			a.logger.Info("releasing-lock")
+			a.releaseLock(haveLockChan, stopMaintainingLockChan)
+			a.setHaveLock(false)
+			a.rebalancer.stop()
+			a.executors.stop()
+			return nil
+		}
+	}
 }
 
-func New(bbs Bbs.AuctioneerBBS, runner auctiontypes.AuctionRunner, maxConcurrent int, maxRounds int, lockInterval time.Duration, logger lager.Logger) *Auctioneer {
-	return &Auctioneer{
-		bbs:           bbs,
-		runner:        runner,
-		maxConcurrent: maxConcurrent,
-		maxRounds:     maxRounds,
-		logger:        logger.Session("auctioneer"),
-		semaphore:     make(chan bool, maxConcurrent),
-		lockInterval:  lockInterval,
+// releaseLock stops maintaining the lock, giving it up if it is held.  The
+// lock's state may be being reported as we ask, so reports are drained until
+// the lock is released.
+func (a *Auctioneer) releaseLock(haveLockChan <-chan bool, stopMaintainingLockChan chan<- chan bool) {
+	if stopMaintainingLockChan == nil {
+		return
+	}
+
+	released := make(chan bool)
+	for {
+		select {
+		case stopMaintainingLockChan <- released:
+			<-released
+			return
+		case <-haveLockChan:
+		}
+	}
+}
+
+// an auctioneer that steps down waits long enough for the standbys, which
+// retry at least every half lock interval, to take the lock
+func (a *Auctioneer) stepDownHoldoff() time.Duration {
+	return 2 * a.lockInterval
+}
+
+func (a *Auctioneer) setHaveLock(haveLock bool) {
+	if haveLock {
+		atomic.StoreInt32(&a.haveLock, 1)
+	} else {
+		atomic.StoreInt32(&a.haveLock, 0)
+	}
+}
+
+func (a *Auctioneer) setWatching(starts bool, stops bool) {
+	a.statusLock.Lock()
+	a.watchingStarts, a.watchingStops = starts, stops
+	a.statusLock.Unlock()
+}
+
+func (a *Auctioneer) auctioned() {
+	a.statusLock.Lock()
+	a.lastAuctionAt = time.Now()
+	a.statusLock.Unlock()
+}
+
+// instances are only moved by an auctioneer that would run the start
+// auctions for their replacements
+func (a *Auctioneer) canRebalance() bool {
+	if !a.HasLock() || a.Paused().StartAuctions {
+		return false
+	}
+
+	a.claimLock.Lock()
+	defer a.claimLock.Unlock()
+
+	return !a.draining
+}
+
+// drain stops any more auctions being run and waits, for up to the drain
+// timeout, for those already running to finish.  Any still running after
+// that are abandoned.
+func (a *Auctioneer) drain() <-chan struct{} {
+	drainTimeout := a.currentSettings().drainTimeout
+
+	a.logger.Info("draining", lager.Data{
+		"in-flight-start-auctions": a.metrics.inFlight.Value(startAuctionKind),
+		"in-flight-stop-auctions":  a.metrics.inFlight.Value(stopAuctionKind),
+		"drain-timeout":            drainTimeout.String(),
+	})
+
+	a.claimLock.Lock()
+	a.draining = true
+	a.claimLock.Unlock()
+
+	a.scheduler.stop()
+	finished := a.scheduler.finished()
+
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+
+		select {
+		case <-finished:
+			a.logger.Info("drained")
+		case <-time.After(drainTimeout):
+			a.logger.Info("drain-timed-out")
+			a.abandonStartAuctions()
+		}
+	}()
+
+	return drained
+}
+
+// stopAuctioning is called when the lock is lost, so that another auctioneer
+// can take over without the same instances being placed twice.  Queued
+// auctions are dropped, running start auctions are aborted before they run
+// their instance, and the fence makes sure none gets through.  The aborted
+// auctions return themselves to pending.  Executor drains and rebalancing
+// moves are abandoned.
+func (a *Auctioneer) stopAuctioning() {
+	a.scheduler.drop()
+	a.executorDrainer.stop()
+	a.rebalancer.abandon()
+
+	aborted := a.abortStartAuctions()
+	if aborted > 0 {
+		a.logger.Info("aborted-start-auctions", lager.Data{"start-auctions": aborted})
+	}
+
+	a.fenceReservations()
+}
+
+func (a *Auctioneer) abortStartAuctions() int {
+	a.claimLock.Lock()
+	defer a.claimLock.Unlock()
+
+	aborted := 0
+	for _, claimed := range a.claimedStarts {
+		if !claimed.aborted {
+			claimed.aborted = true
+			close(claimed.abort)
+			aborted++
+		}
+	}
+
+	return aborted
+}
+
+func (a *Auctioneer) fenceReservations() {
+	if a.reservations == nil {
+		return
+	}
+
+	released := a.reservations.Fence()
+	if released > 0 {
+		a.logger.Info("released-reservations", lager.Data{"reservations": released})
+	}
+}
+
+// abandonStartAuctions takes back the claimed start auctions that are still
+// running.  Once they are aborted and the reservations are fenced, no more
+// instances are run, so the auctions that had not run their instance are
+// returned to pending and those that had are resolved.  Without the
+// reservations we can't tell which is which, and the auctions are left
+// claimed.
+func (a *Auctioneer) abandonStartAuctions() {
+	a.abortStartAuctions()
+	a.fenceReservations()
+
+	a.claimLock.Lock()
+	abandoned := a.claimedStarts
+	a.claimedStarts = map[auctionKey]*claimedStartAuction{}
+	a.claimLock.Unlock()
+
+	for _, claimed := range abandoned {
+		startAuction := claimed.startAuction
+		logger := a.logger.Session("abandon", lager.Data{"start-auction": startAuction})
+
+		if a.reservations == nil {
+			logger.Info("left-claimed")
+			continue
+		}
+
+		if a.reservations.Ran(startAuction.InstanceGuid) {
+			logger.Info("resolved")
+			a.bbs.ResolveLRPStartAuction(startAuction)
+		} else {
+			a.returnStartAuction(logger, startAuction)
+		}
+
+		a.reservations.Forget(startAuction.InstanceGuid)
+	}
+}
+
+// returnStartAuction puts a claimed start auction back to pending, for
+// whoever holds the lock next
+func (a *Auctioneer) returnStartAuction(logger lager.Logger, startAuction models.LRPStartAuction) {
+	err := a.bbs.RequeueLRPStartAuction(startAuction)
+	if err != nil {
+		logger.Error("failed-to-return-to-pending", err)
+		return
+	}
+
+	logger.Info("returned-to-pending")
+}
+
+// claimedStart notes that a start auction has been claimed, returning the
+// channel that aborts it; it returns false if the auctioneer is draining or
+// has lost the lock, and the auction should not be run
+func (a *Auctioneer) claimedStart(startAuction models.LRPStartAuction) (<-chan struct{}, bool) {
+	a.claimLock.Lock()
+	defer a.claimLock.Unlock()
+
+	if a.draining || !a.HasLock() {
+		return nil, false
+	}
+
+	claimed := &claimedStartAuction{
+		startAuction: startAuction,
+		abort:        make(chan struct{}),
+	}
+
+	a.claimedStarts[auctionKey{startAuction.ProcessGuid, startAuction.Index}] = claimed
+	return claimed.abort, true
+}
+
+// finishedStart returns false if a drain has abandoned the auction, in which
+// case it is no longer ours to resolve
+func (a *Auctioneer) finishedStart(startAuction models.LRPStartAuction) bool {
+	key := auctionKey{startAuction.ProcessGuid, startAuction.Index}
+
+	a.claimLock.Lock()
+	_, ok := a.claimedStarts[key]
+	delete(a.claimedStarts, key)
+	a.claimLock.Unlock()
+
+	if ok && a.reservations != nil {
+		a.reservations.Forget(startAuction.InstanceGuid)
+	}
+
+	return ok
+}
+
+func nextWatchRetryInterval(interval time.Duration) time.Duration {
+	interval *= 2
+	if interval > watchRetryMaxInterval {
+		return watchRetryMaxInterval
+	}
+
+	return interval
+}
+
+// the watches only report auctions that change after they are established, so
+// anything already pending is picked up by listing.  the scheduler drops
+// auctions that are both listed and reported by the watch.
+func (a *Auctioneer) listPendingStartAuctions() {
+	startAuctions, err := a.bbs.GetAllLRPStartAuctions()
+	if err != nil {
+		a.logger.Error("failed-to-list-start-auctions", err)
+		return
+	}
+
+	for _, startAuction := range startAuctions {
+		if startAuction.State != models.LRPStartAuctionStatePending {
+			continue
+		}
+
+		a.dispatchStartAuction(startAuction)
 	}
 }
 
-func (a *Auctioneer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
-	guid, err := uuid.NewV4()
+func (a *Auctioneer) listPendingStopAuctions() {
+	stopAuctions, err := a.bbs.GetAllLRPStopAuctions()
 	if err != nil {
-		return err
+		a.logger.Error("failed-to-list-stop-auctions", err)
+		return
 	}
 
-	haveLockChan, stopMaintainingLockChan, err := a.bbs.MaintainAuctioneerLock(a.lockInterval, guid.String())
+	for _, stopAuction := range stopAuctions {
+		if stopAuction.State != models.LRPStopAuctionStatePending {
+			continue
+		}
+
+		a.dispatchStopAuction(stopAuction)
+	}
+}
+
+// auctions that have been requeued after failing wait until they are due
+func (a *Auctioneer) dispatchStartAuction(startAuction models.LRPStartAuction) {
+	a.replacer.remember(startAuction)
+
+	if startAuction.RetryAt != 0 {
+		delay := time.Unix(0, startAuction.RetryAt).Sub(time.Now())
+		if delay > 0 {
+			if !a.held.hold(startAuction, delay, a.submitStartAuction) {
+				a.logger.Debug("start-auction-already-held", lager.Data{"start-auction": startAuction})
+			}
+			return
+		}
+	}
+
+	a.submitStartAuction(startAuction)
+}
+
+func (a *Auctioneer) submitStartAuction(startAuction models.LRPStartAuction) {
+	if !a.scheduler.submitStart(startAuction) {
+		a.logger.Debug("start-auction-already-queued", lager.Data{"start-auction": startAuction})
+	}
+}
+
+func (a *Auctioneer) dispatchStopAuction(stopAuction models.LRPStopAuction) {
+	if !a.scheduler.submitStop(stopAuction) {
+		a.logger.Debug("stop-auction-already-queued", lager.Data{"stop-auction": stopAuction})
+	}
+}
+
+func (a *Auctioneer) releaseHeldStartAuctions() {
+	released := a.held.releaseAll()
+	if released > 0 {
+		a.logger.Info("released-held-start-auctions", lager.Data{"start-auctions": released})
+	}
+}
+
+func (a *Auctioneer) countRunningInstances(processGuid string) int {
+	actualLRPs, err := a.bbs.GetActualLRPsByProcessGuid(processGuid)
 	if err != nil {
-		return err
+		a.logger.Error("failed-to-get-actual-lrps", err, lager.Data{"process-guid": processGuid})
+		return 0
 	}
 
-	var startAuctionChan <-chan models.LRPStartAuction
-	var startErrorChan <-chan error
-	var cancelStartWatchChan chan<- bool
+	running := 0
+	for _, actualLRP := range actualLRPs {
+		if actualLRP.State == models.ActualLRPStateRunning {
+			running++
+		}
+	}
 
-	var stopAuctionChan <-chan models.LRPStopAuction
-	var stopErrorChan <-chan error
-	var cancelStopWatchChan chan<- bool
+	return running
+}
 
-	for {
-		select {
-		case haveLock := <-haveLockChan:
-			a.logger.Info("lock-state", lager.Data{"have-lock": haveLock})
+func (a *Auctioneer) shouldStop(sig os.Signal) bool {
+	return sig == syscall.SIGINT || sig == syscall.SIGTERM
+}
 
-			if haveLock {
-				if startAuctionChan == nil {
-					startAuctionChan, cancelStartWatchChan, startErrorChan = a.bbs.WatchForLRPStartAuction()
+func (a *Auctioneer) runStartAuction(startAuction models.LRPStartAuction) {
+	logger := a.logger.Session("start", lager.Data{
+		"start-auction": startAuction,
+	})
 
-					a.logger.Info("watching-for-start-auctions")
-				}
+	logger.Info("received")
 
-				if stopAuctionChan == nil {
-					stopAuctionChan, cancelStopWatchChan, stopErrorChan = a.bbs.WatchForLRPStopAuction()
+	if !a.HasLock() {
+		logger.Info("lock-not-held")
+		return
+	}
 
-					a.logger.Info("watching-for-stop-auctions")
-				}
+	if a.Paused().StartAuctions {
+		logger.Info("paused")
+		return
+	}
 
-				if ready != nil {
-					close(ready)
-					ready = nil
-				}
-			} else {
-				if startAuctionChan != nil {
-					close(cancelStartWatchChan)
-					startAuctionChan, cancelStartWatchChan, startErrorChan = nil, nil, nil
-				}
+	//claim
+	err := a.bbs.ClaimLRPStartAuction(startAuction)
+	if err != nil {
+		logger.Debug("failed-to-claim", lager.Data{"error": err.Error()})
+		return
+	}
 
-				if stopAuctionChan != nil {
-					close(cancelStopWatchChan)
-					stopAuctionChan, cancelStopWatchChan, stopErrorChan = nil, nil, nil
-				}
-			}
+	abort, ok := a.claimedStart(startAuction)
+	if !ok {
+		a.returnStartAuction(logger, startAuction)
+		return
+	}
 
-		case startAuction, ok := <-startAuctionChan:
-			if !ok {
-				startAuctionChan = nil
-				continue
-			}
+	a.metrics.startsStarted.Inc(startAuction.Stack)
+	a.metrics.inFlight.Add(1, startAuctionKind)
+	defer a.metrics.inFlight.Add(-1, startAuctionKind)
+	defer a.auctioned()
 
-			logger := a.logger.Session("start", lager.Data{
-				"start-auction": startAuction,
-			})
+	attemptedAt := time.Now()
 
-			go a.runStartAuction(startAuction, logger)
+	failure := a.auctionStart(logger, startAuction, abort)
 
-		case stopAuction, ok := <-stopAuctionChan:
-			if !ok {
-				stopAuctionChan = nil
-				continue
-			}
+	if !a.finishedStart(startAuction) {
+		logger.Info("abandoned")
+		return
+	}
 
-			logger := a.logger.Session("stop", lager.Data{
-				"stop-auction": stopAuction,
-			})
+	if failure != nil && failure.err == algorithms.AuctionAborted {
+		logger.Info("aborted")
+		a.returnStartAuction(logger, startAuction)
+		return
+	}
 
-			go a.runStopAuction(stopAuction, logger)
+	if failure != nil {
+		a.metrics.startsFailed.Inc(startAuction.Stack, failure.reason)
+		a.startAuctionFailed(logger, startAuction, attemptedAt, failure)
+		return
+	}
 
-		case err := <-startErrorChan:
-			a.logger.Error("watching-start-auctions-failed", err)
-			startAuctionChan = nil
+	a.metrics.startsSucceeded.Inc(startAuction.Stack)
 
-		case err := <-stopErrorChan:
-			a.logger.Error("watching-stop-auctions-failed", err)
-			stopAuctionChan = nil
+	//an earlier auction at this index may have failed even if this one is a
+	//first attempt, e.g. one whose failure wasn't retried, so its record is
+	//removed whether or not there is one
+	err = a.bbs.RemoveFailedLRPStartAuction(startAuction)
+	if err != nil && err != storeadapter.ErrorKeyNotFound {
+		logger.Error("failed-to-remove-failure", err)
+	}
 
-		case sig := <-signals:
-			if a.shouldStop(sig) {
-				a.logger.Info("releasing-lock")
-				stoppedMaintainingLockChan := make(chan bool)
-				stopMaintainingLockChan <- stoppedMaintainingLockChan
-				<-stoppedMaintainingLockChan
-				if cancelStartWatchChan != nil {
-					a.logger.Info("stopping-start-watch")
-					close(cancelStartWatchChan)
-				}
-				if cancelStopWatchChan != nil {
-					a.logger.Info("stopping-stop-watch")
-					close(cancelStopWatchChan)
-				}
-				return nil
+	a.bbs.ResolveLRPStartAuction(startAuction)
+}
+
+// startAuctionFailed records why the auction failed, then puts it back up for
+// auction if the retry policy allows, and otherwise gives up on it
+func (a *Auctioneer) startAuctionFailed(logger lager.Logger, startAuction models.LRPStartAuction, attemptedAt time.Time, failure *startAuctionFailure) {
+	retry := startAuction
+	retry.Attempts++
+	if retry.FirstAttemptAt == 0 {
+		retry.FirstAttemptAt = attemptedAt.UnixNano()
+	}
+
+	requeued := false
+	if !failure.permanent {
+		retryAt, ok := a.currentSettings().retryPolicy.nextAttempt(retry, time.Now())
+		if ok {
+			retry.RetryAt = retryAt.UnixNano()
+
+			err := a.bbs.RequeueLRPStartAuction(retry)
+			if err == nil {
+				requeued = true
+				logger.Info("requeued", lager.Data{
+					"reason":   failure.reason,
+					"attempts": retry.Attempts,
+					"retry-in": retryAt.Sub(attemptedAt).String(),
+				})
+			} else {
+				logger.Error("failed-to-requeue", err)
 			}
 		}
 	}
 
-	return nil
-}
+	a.recordFailure(logger, retry, failure, !requeued)
 
-func (a *Auctioneer) shouldStop(sig os.Signal) bool {
-	return sig == syscall.SIGINT || sig == syscall.SIGTERM
+	if requeued {
+		return
+	}
+
+	logger.Error("gave-up", failure.err, lager.Data{
+		"reason":   failure.reason,
+		"attempts": retry.Attempts,
+	})
+
+	a.bbs.ResolveLRPStartAuction(startAuction)
 }
 
-func (a *Auctioneer) runStartAuction(startAuction models.LRPStartAuction, logger lager.Logger) {
-	a.semaphore <- true
-	defer func() {
-		<-a.semaphore
-	}()
+func (a *Auctioneer) recordFailure(logger lager.Logger, startAuction models.LRPStartAuction, failure *startAuctionFailure, gaveUp bool) {
+	record := models.FailedLRPStartAuction{
+		ProcessGuid:       startAuction.ProcessGuid,
+		InstanceGuid:      startAuction.InstanceGuid,
+		Index:             startAuction.Index,
+		Stack:             startAuction.Stack,
+		DiskMB:            startAuction.DiskMB,
+		MemoryMB:          startAuction.MemoryMB,
+		Reason:            failure.reason,
+		Attempts:          startAuction.Attempts,
+		GaveUp:            gaveUp,
+		NumRounds:         failure.numRounds,
+		NumCommunications: failure.numCommunications,
+		FailedAt:          time.Now().UnixNano(),
+	}
 
-	logger.Info("received")
+	if failure.err != nil {
+		record.Error = failure.err.Error()
+	}
 
-	//claim
-	err := a.bbs.ClaimLRPStartAuction(startAuction)
+	err := a.bbs.RecordFailedLRPStartAuction(record, a.currentSettings().failedTTL)
 	if err != nil {
-		logger.Debug("failed-to-claim", lager.Data{"error": err.Error()})
-		return
+		logger.Error("failed-to-record-failure", err)
 	}
+}
+
+func (a *Auctioneer) auctionStart(logger lager.Logger, startAuction models.LRPStartAuction, abort <-chan struct{}) *startAuctionFailure {
+	//the whole auction runs with the settings it started with
+	settings := a.currentSettings()
 
-	defer a.bbs.ResolveLRPStartAuction(startAuction)
+	stack := settings.stacks.Resolve(startAuction.Stack)
+	tiers := settings.stacks.Tiers(stack)
 
-	executorGuids, err := a.getExecutorsforStack(startAuction.Stack)
+	executors, err := a.executors.forStacks(settings.stacks.advertisedAs(flatten(tiers)))
 	if err != nil {
 		logger.Error("failed-to-get-executors", err)
-		return
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonFailedToGetExecutors, err: err}
 	}
-	if len(executorGuids) == 0 {
+
+	stackExecutors := settings.stacks.executorsForStacks(executors, flatten(tiers))
+	if len(stackExecutors) == 0 {
 		logger.Error("no-available-executors", nil)
-		return
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonNoExecutors}
+	}
+
+	stackExecutors = a.cordons.exclude(stackExecutors)
+	if len(stackExecutors) == 0 {
+		logger.Error("all-executors-cordoned", nil)
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAllExecutorsCordoned}
+	}
+
+	eligibleExecutors := executorsWithLabels(stackExecutors, startAuction.RequiredLabels)
+	if len(eligibleExecutors) == 0 {
+		logger.Error("no-executors-match-required-labels", nil, lager.Data{
+			"required-labels": startAuction.RequiredLabels,
+		})
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonNoExecutorsWithLabels}
+	}
+
+	eligibleExecutors = executorsExcept(eligibleExecutors, startAuction.ExcludedExecutorIDs)
+	if len(eligibleExecutors) == 0 {
+		logger.Error("all-executors-excluded", nil, lager.Data{
+			"excluded-executor-ids": startAuction.ExcludedExecutorIDs,
+		})
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAllExecutorsExcluded}
+	}
+
+	antiAffinity := startAuction.AntiAffinity
+	if antiAffinity == "" {
+		antiAffinity = settings.antiAffinity
+	}
+
+	err = ValidateAntiAffinity(antiAffinity)
+	if err != nil {
+		logger.Error("invalid-anti-affinity", err)
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonInvalidAntiAffinity, err: err, permanent: true}
+	}
+
+	var actualLRPs []models.ActualLRP
+	if hasZones(executors) || antiAffinity == algorithms.AntiAffinitySoft || antiAffinity == algorithms.AntiAffinityHard {
+		actualLRPs, err = a.bbs.GetActualLRPsByProcessGuid(startAuction.ProcessGuid)
+		if err != nil {
+			logger.Error("failed-to-get-actual-lrps", err)
+
+			//zones and soft anti-affinity only guide placement, but hard
+			//anti-affinity can't be honoured without knowing where the
+			//process runs
+			if antiAffinity == algorithms.AntiAffinityHard {
+				return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonFailedToGetActualLRPs, err: err}
+			}
+		}
+	}
+
+	instancesPerRep := map[string]int{}
+	for _, actualLRP := range actualLRPs {
+		instancesPerRep[actualLRP.ExecutorID]++
+	}
+
+	if antiAffinity == algorithms.AntiAffinityHard {
+		eligibleExecutors = executorsWithout(eligibleExecutors, instancesPerRep)
+		if len(eligibleExecutors) == 0 {
+			logger.Error("no-executors-without-instances-of-process", nil)
+			return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAntiAffinityExcludesAll}
+		}
+	}
+
+	//the breakers go last, so that the reps they leave out are only those the
+	//auction would otherwise have asked
+	eligibleExecutors = a.responsiveExecutors(eligibleExecutors)
+	if len(eligibleExecutors) == 0 {
+		logger.Error("all-executors-unresponsive", nil)
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAllExecutorsUnresponsive}
 	}
 
 	//perform auction
 	logger.Info("performing")
 
-	rules := auctionrunner.DefaultStartAuctionRules
-	rules.MaxRounds = a.maxRounds
+	rules, ok := settings.stackRules[stack]
+	if !ok {
+		rules = settings.rules
+	}
 
-	request := auctiontypes.StartAuctionRequest{
+	request := algorithms.StartAuctionRequest{
 		LRPStartAuction: startAuction,
-		RepGuids:        executorGuids,
 		Rules:           rules,
+		AntiAffinity:    antiAffinity,
+		InstancesPerRep: instancesPerRep,
+		Abort:           abort,
+	}
+
+	request.RepZones, request.InstancesPerZone = zonesOf(executors, actualLRPs)
+
+	//try the most preferred executors first, moving on only when they have no room
+	candidates := tieredCandidates(settings.stacks, eligibleExecutors, tiers, startAuction.PreferredLabels)
+
+	var result algorithms.StartAuctionResult
+	numRounds, numCommunications := 0, 0
+	var biddingDuration time.Duration
+	for i, repGuids := range candidates {
+		request.RepGuids = repGuids
+
+		result, err = a.runner.RunLRPStartAuction(request)
+		numRounds += result.NumRounds
+		numCommunications += result.NumCommunications
+		biddingDuration += result.BiddingDuration
+
+		if err != auctiontypes.InsufficientResources || i == len(candidates)-1 {
+			break
+		}
+
+		logger.Info("no-room-on-preferred-executors")
 	}
 
-	_, err = a.runner.RunLRPStartAuction(request)
+	a.metrics.rounds.Observe(float64(numRounds), startAuction.Stack)
+	a.metrics.communications.Observe(float64(numCommunications), startAuctionKind)
+	a.metrics.biddingDuration.Observe(biddingDuration.Seconds(), startAuctionKind)
+
 	if err != nil {
 		logger.Error("auction-failed", err)
-		return
+
+		failure := auctionFailure(err)
+		failure.numRounds, failure.numCommunications = numRounds, numCommunications
+
+		return failure
 	}
+
+	logger.Info("succeeded", lager.Data{
+		"winner":             result.Winner,
+		"stack":              stackOf(executors, result.Winner),
+		"zone":               result.Zone,
+		"instances-per-zone": result.InstancesPerZone,
+	})
+
+	return nil
 }
 
-func (a *Auctioneer) getExecutorsforStack(stack string) ([]string, error) {
-	executors, err := a.bbs.GetAllExecutors()
-	if err != nil {
-		return nil, err
+func auctionFailure(err error) *startAuctionFailure {
+	switch err.(type) {
+	case algorithms.UnknownAlgorithmError:
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAuctionError, err: err, permanent: true}
+	}
+
+	switch err {
+	case auctiontypes.InsufficientResources:
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonInsufficientResources, err: err}
+	case algorithms.AllBiddersTimedOut:
+		return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAllBiddersTimedOut, err: err}
+	}
+
+	return &startAuctionFailure{reason: models.FailedLRPStartAuctionReasonAuctionError, err: err}
+}
+
+// tieredCandidates splits the executors into the sets that a start auction is
+// run against, most preferred first: by stack tier, and within each tier
+// those with the preferred labels before the rest
+func tieredCandidates(stacks StackCompatibility, executors []models.ExecutorPresence, tiers [][]string, preferredLabels map[string]string) [][]string {
+	candidates := [][]string{}
+
+	for _, tier := range tiers {
+		tierExecutors := stacks.executorsForStacks(executors, tier)
+		if len(tierExecutors) == 0 {
+			continue
+		}
+
+		preferredExecutors := executorsWithLabels(tierExecutors, preferredLabels)
+		if len(preferredExecutors) > 0 && len(preferredExecutors) < len(tierExecutors) {
+			candidates = append(candidates, guidsOf(preferredExecutors))
+		}
+
+		candidates = append(candidates, guidsOf(tierExecutors))
+	}
+
+	return candidates
+}
+
+func flatten(tiers [][]string) []string {
+	stacks := []string{}
+	for _, tier := range tiers {
+		stacks = append(stacks, tier...)
+	}
+
+	return stacks
+}
+
+func stackOf(executors []models.ExecutorPresence, executorGuid string) string {
+	for _, executor := range executors {
+		if executor.ExecutorID == executorGuid {
+			return executor.Stack
+		}
+	}
+
+	return ""
+}
+
+func executorsWithLabels(executors []models.ExecutorPresence, labels map[string]string) []models.ExecutorPresence {
+	filteredExecutors := []models.ExecutorPresence{}
+
+	for _, executor := range executors {
+		if hasLabels(executor, labels) {
+			filteredExecutors = append(filteredExecutors, executor)
+		}
+	}
+
+	return filteredExecutors
+}
+
+func hasLabels(executor models.ExecutorPresence, labels map[string]string) bool {
+	for key, value := range labels {
+		executorValue, ok := executor.Labels[key]
+		if !ok || executorValue != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+func guidsOf(executors []models.ExecutorPresence) []string {
+	executorGuids := []string{}
+
+	for _, executor := range executors {
+		executorGuids = append(executorGuids, executor.ExecutorID)
+	}
+
+	return executorGuids
+}
+
+func executorsExcept(executors []models.ExecutorPresence, executorIDs []string) []models.ExecutorPresence {
+	if len(executorIDs) == 0 {
+		return executors
+	}
+
+	filteredExecutors := []models.ExecutorPresence{}
+
+	for _, executor := range executors {
+		if !contains(executorIDs, executor.ExecutorID) {
+			filteredExecutors = append(filteredExecutors, executor)
+		}
+	}
+
+	return filteredExecutors
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
 
-	filteredExecutorGuids := []string{}
+	return false
+}
+
+func executorsWithout(executors []models.ExecutorPresence, instancesPerRep map[string]int) []models.ExecutorPresence {
+	filteredExecutors := []models.ExecutorPresence{}
+
+	for _, executor := range executors {
+		if instancesPerRep[executor.ExecutorID] == 0 {
+			filteredExecutors = append(filteredExecutors, executor)
+		}
+	}
+
+	return filteredExecutors
+}
 
+func hasZones(executors []models.ExecutorPresence) bool {
 	for _, executor := range executors {
-		if executor.Stack == stack {
-			filteredExecutorGuids = append(filteredExecutorGuids, executor.ExecutorID)
+		if executor.Zone != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// zonesOf maps executors to their zones, and counts the instances of the
+// process already in each zone.  Without zones, the auction ignores them.
+func zonesOf(executors []models.ExecutorPresence, actualLRPs []models.ActualLRP) (map[string]string, map[string]int) {
+	repZones := map[string]string{}
+	for _, executor := range executors {
+		if executor.Zone != "" {
+			repZones[executor.ExecutorID] = executor.Zone
+		}
+	}
+
+	if len(repZones) == 0 {
+		return nil, nil
+	}
+
+	instancesPerZone := map[string]int{}
+	for _, actualLRP := range actualLRPs {
+		zone, ok := repZones[actualLRP.ExecutorID]
+		if ok {
+			instancesPerZone[zone]++
 		}
 	}
 
-	return filteredExecutorGuids, nil
+	return repZones, instancesPerZone
 }
 
-func (a *Auctioneer) runStopAuction(stopAuction models.LRPStopAuction, logger lager.Logger) {
+func (a *Auctioneer) runStopAuction(stopAuction models.LRPStopAuction) {
+	logger := a.logger.Session("stop", lager.Data{
+		"stop-auction": stopAuction,
+	})
+
 	logger.Debug("received")
 
+	if !a.HasLock() {
+		logger.Info("lock-not-held")
+		return
+	}
+
+	if a.Paused().StopAuctions {
+		logger.Info("paused")
+		return
+	}
+
 	//claim
 	err := a.bbs.ClaimLRPStopAuction(stopAuction)
 	if err != nil {
@@ -223,34 +1583,86 @@ func (a *Auctioneer) runStopAuction(stopAuction models.LRPStopAuction, logger la
 
 	defer a.bbs.ResolveLRPStopAuction(stopAuction)
 
+	a.metrics.stopsStarted.Inc()
+	a.metrics.inFlight.Add(1, stopAuctionKind)
+	defer a.metrics.inFlight.Add(-1, stopAuctionKind)
+	defer a.auctioned()
+
 	executorGuids, err := a.getExecutors()
 	if err != nil {
 		logger.Error("failed-to-get-executors", err)
+		a.metrics.stopsFailed.Inc(stopFailureFailedToGetExecutors)
 		return
 	}
 
 	if len(executorGuids) == 0 {
 		logger.Error("no-available-executors", nil)
+		a.metrics.stopsFailed.Inc(stopFailureNoExecutors)
+		return
+	}
+
+	executorGuids = a.responsiveExecutorGuids(executorGuids)
+	if len(executorGuids) == 0 {
+		logger.Error("all-executors-unresponsive", nil)
+		a.metrics.stopsFailed.Inc(stopFailureNoExecutors)
 		return
 	}
 
 	//perform auction
 	logger.Info("perform")
 
-	request := auctiontypes.StopAuctionRequest{
-		LRPStopAuction: stopAuction,
-		RepGuids:       executorGuids,
+	request := algorithms.StopAuctionRequest{
+		LRPStopAuction:   stopAuction,
+		RepGuids:         executorGuids,
+		DrainingRepGuids: a.cordons.guids(),
 	}
-	_, err = a.runner.RunLRPStopAuction(request)
+	result, err := a.runner.RunLRPStopAuction(request)
+
+	a.metrics.communications.Observe(float64(result.NumCommunications), stopAuctionKind)
+	a.metrics.biddingDuration.Observe(result.BiddingDuration.Seconds(), stopAuctionKind)
 
 	if err != nil {
 		logger.Error("auction-failed", err)
+		a.metrics.stopsFailed.Inc(stopFailureAuctionError)
 		return
 	}
+
+	a.metrics.stopsSucceeded.Inc()
+}
+
+// responsiveExecutors leaves out the executors whose reps' breakers are open
+func (a *Auctioneer) responsiveExecutors(executors []models.ExecutorPresence) []models.ExecutorPresence {
+	if a.repBreakers == nil {
+		return executors
+	}
+
+	responsive := []models.ExecutorPresence{}
+	for _, executor := range executors {
+		if a.repBreakers.Allow(executor.ExecutorID) {
+			responsive = append(responsive, executor)
+		}
+	}
+
+	return responsive
+}
+
+func (a *Auctioneer) responsiveExecutorGuids(executorGuids []string) []string {
+	if a.repBreakers == nil {
+		return executorGuids
+	}
+
+	responsive := []string{}
+	for _, executorGuid := range executorGuids {
+		if a.repBreakers.Allow(executorGuid) {
+			responsive = append(responsive, executorGuid)
+		}
+	}
+
+	return responsive
 }
 
 func (a *Auctioneer) getExecutors() ([]string, error) {
-	executors, err := a.bbs.GetAllExecutors()
+	executors, err := a.executors.all()
 	if err != nil {
 		return nil, err
 	}
@@ -1,17 +1,24 @@
 package auctioneer_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/cloudfoundry-incubator/auction/auctionrunner/fake_auctionrunner"
 	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms/fake_runner"
 	. "github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+	"github.com/cloudfoundry-incubator/auctioneer/breaker"
+	"github.com/cloudfoundry-incubator/auctioneer/metrics"
+	"github.com/cloudfoundry-incubator/auctioneer/simulation"
 	"github.com/cloudfoundry-incubator/runtime-schema/bbs/fake_bbs"
 	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/cloudfoundry/storeadapter"
 	"github.com/pivotal-golang/lager/lagertest"
 	"github.com/tedsuo/ifrit"
 
@@ -26,7 +33,7 @@ var _ = Describe("Auctioneer", func() {
 	var (
 		bbs            *fake_bbs.FakeAuctioneerBBS
 		auctioneer     *Auctioneer
-		runner         *fake_auctionrunner.FakeAuctionRunner
+		runner         *fake_runner.FakeAuctionRunner
 		process        ifrit.Process
 		firstExecutor  models.ExecutorPresence
 		secondExecutor models.ExecutorPresence
@@ -34,10 +41,25 @@ var _ = Describe("Auctioneer", func() {
 		logger         *lagertest.TestLogger
 		startAuction   models.LRPStartAuction
 		stopAuction    models.LRPStopAuction
+		config         Config
 	)
 
 	BeforeEach(func() {
 		logger = lagertest.NewTestLogger("test")
+
+		config = Config{
+			MaxConcurrent:     2,
+			MaxQueuedAuctions: 100,
+			StartAuctionShare: 1,
+			StopAuctionShare:  1,
+			StartAuctionRules: algorithms.StartAuctionRules{
+				Algorithm:              "reserve_n_best",
+				MaxRounds:              MAX_AUCTION_ROUNDS_FOR_TEST,
+				MaxBiddingPoolFraction: 0.2,
+				MinBiddingPool:         10,
+			},
+			LockInterval: time.Second,
+		}
 		bbs = fake_bbs.NewFakeAuctioneerBBS()
 
 		firstExecutor = models.ExecutorPresence{
@@ -79,8 +101,8 @@ var _ = Describe("Auctioneer", func() {
 		var errors chan error
 
 		BeforeEach(func() {
-			runner = &fake_auctionrunner.FakeAuctionRunner{}
-			auctioneer = New(bbs, runner, 2, MAX_AUCTION_ROUNDS_FOR_TEST, time.Second, logger)
+			runner = &fake_runner.FakeAuctionRunner{}
+			auctioneer = New(bbs, runner, config, logger)
 			signals = make(chan os.Signal)
 			ready = make(chan struct{})
 			errors = make(chan error)
@@ -100,6 +122,63 @@ var _ = Describe("Auctioneer", func() {
 			It("should not be ready", func() {
 				Consistently(ready).ShouldNot(BeClosed())
 			})
+
+			It("should report that it is on standby", func() {
+				Eventually(func() string { return auctioneer.Status().AuctioneerID }).ShouldNot(BeEmpty())
+
+				status := auctioneer.Status()
+				Ω(status.HaveLock).Should(BeFalse())
+				Ω(status.WatchingStartAuctions).Should(BeFalse())
+				Ω(status.Ready()).Should(BeFalse())
+			})
+		})
+
+		Context("when auctions are already pending when the lock is obtained", func() {
+			BeforeEach(func() {
+				runner.RunLRPStartAuctionStub = func(algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+					time.Sleep(500 * time.Millisecond)
+					return algorithms.StartAuctionResult{}, nil
+				}
+				runner.RunLRPStopAuctionStub = func(algorithms.StopAuctionRequest) (auctiontypes.StopAuctionResult, error) {
+					time.Sleep(500 * time.Millisecond)
+					return auctiontypes.StopAuctionResult{}, nil
+				}
+
+				startAuction.State = models.LRPStartAuctionStatePending
+				stopAuction.State = models.LRPStopAuctionStatePending
+
+				bbs.Lock()
+				bbs.LRPStartAuctions = []models.LRPStartAuction{
+					startAuction,
+					{ProcessGuid: "claimed-guid", Stack: "lucid64", State: models.LRPStartAuctionStateClaimed},
+				}
+				bbs.LRPStopAuctions = []models.LRPStopAuction{
+					stopAuction,
+					{ProcessGuid: "claimed-stop-guid", State: models.LRPStopAuctionStateClaimed},
+				}
+				bbs.Unlock()
+
+				bbs.LockChannel <- true
+			})
+
+			It("should run the pending auctions", func() {
+				Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+				Ω(runner.RunLRPStartAuctionArgsForCall(0).LRPStartAuction).Should(Equal(startAuction))
+
+				Eventually(runner.RunLRPStopAuctionCallCount).Should(Equal(1))
+				Ω(runner.RunLRPStopAuctionArgsForCall(0).LRPStopAuction).Should(Equal(stopAuction))
+			})
+
+			It("should not run an auction twice if the watch reports it while it is in flight", func() {
+				bbs.LRPStartAuctionChan <- startAuction
+				bbs.LRPStopAuctionChan <- stopAuction
+
+				Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+				Eventually(runner.RunLRPStopAuctionCallCount).Should(Equal(1))
+
+				Consistently(runner.RunLRPStartAuctionCallCount, 1.5).Should(Equal(1))
+				Consistently(runner.RunLRPStopAuctionCallCount, 1.5).Should(Equal(1))
+			})
 		})
 
 		Context("once the lock is obtained", func() {
@@ -121,6 +200,22 @@ var _ = Describe("Auctioneer", func() {
 				Eventually(ready).Should(BeClosed())
 			})
 
+			It("should report that it holds the lock and is watching", func() {
+				Eventually(func() bool { return auctioneer.Status().Ready() }).Should(BeTrue())
+
+				status := auctioneer.Status()
+				Ω(status.HaveLock).Should(BeTrue())
+				Ω(status.WatchingStartAuctions).Should(BeTrue())
+				Ω(status.WatchingStopAuctions).Should(BeTrue())
+			})
+
+			It("should report when it last ran an auction", func() {
+				Ω(auctioneer.Status().LastAuctionAt.IsZero()).Should(BeTrue())
+
+				bbs.LRPStartAuctionChan <- startAuction
+				Eventually(func() time.Time { return auctioneer.Status().LastAuctionAt }).ShouldNot(BeZero())
+			})
+
 			Context("if the start watch channel is closed", func() {
 				BeforeEach(func() {
 					close(bbs.LRPStartAuctionChan)
@@ -158,22 +253,86 @@ var _ = Describe("Auctioneer", func() {
 					bbs.LRPStartAuctionErrorChan <- fmt.Errorf("boom")
 				})
 
-				It("should start watching again on the next lock tick", func() {
-					bbs.LRPStartAuctionChan = make(chan models.LRPStartAuction)
-					bbs.LockChannel <- true
+				It("should start watching again without waiting for the next lock tick", func() {
+					bbs.LRPStartAuctionChan <- startAuction
+					Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+				})
+
+				It("should keep retrying if the watch continues to error", func() {
+					bbs.LRPStartAuctionErrorChan <- fmt.Errorf("boom")
+					bbs.LRPStartAuctionErrorChan <- fmt.Errorf("boom")
+
+					bbs.LRPStartAuctionChan <- startAuction
+					Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+				})
+			})
+
+			Context("if the start auction watch errors while there are pending start auctions", func() {
+				var claimedAuction models.LRPStartAuction
+
+				BeforeEach(func() {
+					startAuction.State = models.LRPStartAuctionStatePending
+
+					claimedAuction = models.LRPStartAuction{
+						ProcessGuid: "my-claimed-guid",
+						Stack:       "lucid64",
+						State:       models.LRPStartAuctionStateClaimed,
+					}
+
+					bbs.Lock()
+					bbs.LRPStartAuctions = []models.LRPStartAuction{startAuction, claimedAuction}
+					bbs.Unlock()
+
+					bbs.LRPStartAuctionErrorChan <- fmt.Errorf("boom")
+				})
+
+				It("should run the pending auctions once the watch is re-established", func() {
+					Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+					Ω(runner.RunLRPStartAuctionArgsForCall(0).LRPStartAuction).Should(Equal(startAuction))
+					Consistently(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+				})
+			})
+
+			Context("if listing start auctions fails after the watch is re-established", func() {
+				BeforeEach(func() {
+					bbs.Lock()
+					bbs.LRPStartAuctionsErr = fmt.Errorf("oops")
+					bbs.Unlock()
+
+					bbs.LRPStartAuctionErrorChan <- fmt.Errorf("boom")
+				})
+
+				It("should log the failure and keep watching", func() {
+					Eventually(logger.TestSink.Buffer).Should(gbytes.Say("failed-to-list-start-auctions"))
+
 					bbs.LRPStartAuctionChan <- startAuction
 					Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
 				})
 			})
 
+			Context("if the stop auction watch errors while there are pending stop auctions", func() {
+				BeforeEach(func() {
+					stopAuction.State = models.LRPStopAuctionStatePending
+
+					bbs.Lock()
+					bbs.LRPStopAuctions = []models.LRPStopAuction{stopAuction}
+					bbs.Unlock()
+
+					bbs.LRPStopAuctionErrorChan <- fmt.Errorf("boom")
+				})
+
+				It("should run the pending auctions once the watch is re-established", func() {
+					Eventually(runner.RunLRPStopAuctionCallCount).Should(Equal(1))
+					Ω(runner.RunLRPStopAuctionArgsForCall(0).LRPStopAuction).Should(Equal(stopAuction))
+				})
+			})
+
 			Context("if the stop auction watch errors", func() {
 				BeforeEach(func() {
 					bbs.LRPStopAuctionErrorChan <- fmt.Errorf("boom")
 				})
 
-				It("should start watching again on the next lock tick", func() {
-					bbs.LRPStopAuctionChan = make(chan models.LRPStopAuction)
-					bbs.LockChannel <- true
+				It("should start watching again without waiting for the next lock tick", func() {
 					bbs.LRPStopAuctionChan <- stopAuction
 					Eventually(runner.RunLRPStopAuctionCallCount).ShouldNot(BeZero())
 				})
@@ -188,6 +347,11 @@ var _ = Describe("Auctioneer", func() {
 					Eventually(bbs.LRPStartAuctionStopChan).Should(BeClosed())
 				})
 
+				It("should no longer report itself ready", func() {
+					Eventually(func() bool { return auctioneer.Status().Ready() }).Should(BeFalse())
+					Ω(auctioneer.Status().HaveLock).Should(BeFalse())
+				})
+
 				Context("when the lock is regained", func() {
 					BeforeEach(func() {
 						bbs.LRPStartAuctionChan = make(chan models.LRPStartAuction)
@@ -208,168 +372,2134 @@ var _ = Describe("Auctioneer", func() {
 				})
 			})
 		})
-	})
 
-	Describe("the start auction lifecycle", func() {
-		BeforeEach(func() {
-			runner = &fake_auctionrunner.FakeAuctionRunner{}
-			auctioneer = New(bbs, runner, 2, MAX_AUCTION_ROUNDS_FOR_TEST, time.Second, logger)
+		Context("when auctions were paused by whoever held the lock before", func() {
+			BeforeEach(func() {
+				bbs.Lock()
+				bbs.AuctioneerPause = models.AuctioneerPause{StartAuctions: true}
+				bbs.Unlock()
 
-			go func() {
 				bbs.LockChannel <- true
-			}()
+			})
 
-			process = ifrit.Envoke(auctioneer)
-		})
+			It("should inherit the pause, and only watch for stop auctions", func() {
+				Eventually(ready).Should(BeClosed())
+				Eventually(func() bool { return auctioneer.Status().Ready() }).Should(BeTrue())
 
-		AfterEach(func(done Done) {
-			//send a shut down signal
-			process.Signal(syscall.SIGTERM)
-			//which releases the lock, which we need to acknowledge by closing the channel sent to the release lock channel
-			close(<-bbs.ReleaseLockChannel)
-			//which (eventually) causes the process to exit
-			Eventually(process.Wait()).Should(Receive())
-			//and should stop the auction
-			Ω(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+				status := auctioneer.Status()
+				Ω(status.StartAuctionsPaused).Should(BeTrue())
+				Ω(status.StopAuctionsPaused).Should(BeFalse())
+				Ω(status.WatchingStartAuctions).Should(BeFalse())
 
-			close(done)
+				bbs.LRPStopAuctionChan <- stopAuction
+				Eventually(runner.RunLRPStopAuctionCallCount).ShouldNot(BeZero())
+			})
 		})
 
-		Context("when a pending auction request arrives over ETCD", func() {
-			JustBeforeEach(func(done Done) {
-				bbs.LRPStartAuctionChan <- startAuction
-				close(done)
+		Describe("pausing and resuming", func() {
+			Context("without the lock", func() {
+				It("should refuse", func() {
+					Ω(auctioneer.PauseStartAuctions()).Should(Equal(ErrLockNotHeld))
+					Ω(auctioneer.Resume()).Should(Equal(ErrLockNotHeld))
+					Ω(auctioneer.StepDown()).Should(Equal(ErrLockNotHeld))
+					Ω(bbs.GetStoredAuctioneerPause().StartAuctions).Should(BeFalse())
+				})
 			})
 
-			It("should attempt to claim the auction", func() {
-				Eventually(bbs.GetClaimedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
-			})
+			Context("with the lock", func() {
+				BeforeEach(func() {
+					startAuction.State = models.LRPStartAuctionStatePending
 
-			Context("when the claim succeeds", func() {
-				It("should run the auction with reps of the proper stack", func() {
-					Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+					bbs.LockChannel <- true
+					Eventually(ready).Should(BeClosed())
+				})
 
-					request := runner.RunLRPStartAuctionArgsForCall(0)
-					Ω(request.LRPStartAuction).Should(Equal(startAuction))
-					Ω(request.RepGuids).Should(HaveLen(2))
-					Ω(request.RepGuids).Should(ContainElement(firstExecutor.ExecutorID))
-					Ω(request.RepGuids).Should(ContainElement(thirdExecutor.ExecutorID))
-					Ω(request.RepGuids).ShouldNot(ContainElement(secondExecutor.ExecutorID))
-					Ω(request.Rules.Algorithm).Should(Equal("reserve_n_best"))
-					Ω(request.Rules.MaxBiddingPoolFraction).Should(Equal(0.2))
-					Ω(request.Rules.MaxRounds).Should(Equal(MAX_AUCTION_ROUNDS_FOR_TEST))
+				Context("when start auctions are paused", func() {
+					BeforeEach(func() {
+						Ω(auctioneer.PauseStartAuctions()).ShouldNot(HaveOccurred())
+					})
+
+					It("should persist the pause", func() {
+						pause := bbs.GetStoredAuctioneerPause()
+						Ω(pause.StartAuctions).Should(BeTrue())
+						Ω(pause.StopAuctions).Should(BeFalse())
+						Ω(pause.UpdatedAt).ShouldNot(BeZero())
+					})
+
+					It("should stop watching for start auctions, but keep watching for stop auctions", func() {
+						Eventually(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+						Eventually(func() bool { return auctioneer.Status().WatchingStartAuctions }).Should(BeFalse())
+						Ω(auctioneer.Status().Ready()).Should(BeTrue())
+
+						bbs.LRPStopAuctionChan <- stopAuction
+						Eventually(runner.RunLRPStopAuctionCallCount).ShouldNot(BeZero())
+					})
+
+					It("should leave pending start auctions unclaimed, even as the lock is renewed", func() {
+						bbs.Lock()
+						bbs.LRPStartAuctions = []models.LRPStartAuction{startAuction}
+						bbs.Unlock()
+
+						bbs.LockChannel <- true
+
+						Consistently(bbs.GetClaimedLRPStartAuctions).Should(BeEmpty())
+					})
+
+					Context("and then resumed", func() {
+						BeforeEach(func() {
+							Eventually(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+
+							bbs.Lock()
+							bbs.LRPStartAuctions = []models.LRPStartAuction{startAuction}
+							bbs.LRPStartAuctionChan = make(chan models.LRPStartAuction)
+							bbs.LRPStartAuctionStopChan = make(chan bool)
+							bbs.Unlock()
+
+							Ω(auctioneer.Resume()).ShouldNot(HaveOccurred())
+						})
+
+						It("should run the start auctions left pending, and watch for more", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+							Ω(runner.RunLRPStartAuctionArgsForCall(0).LRPStartAuction).Should(Equal(startAuction))
+
+							bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "other-guid", Stack: "lucid64"}
+							Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(2))
+
+							Ω(bbs.GetStoredAuctioneerPause().StartAuctions).Should(BeFalse())
+						})
+					})
 				})
 
-				Context("when the auction succeeds", func() {
-					It("should resolve the auction in etcd", func() {
-						Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+				Context("when start auctions are paused while the lock's renewal is reading the pause", func() {
+					var reading, release chan struct{}
+
+					BeforeEach(func() {
+						reading, release = make(chan struct{}), make(chan struct{})
+
+						bbs.Lock()
+						bbs.WhenGettingAuctioneerPause = func(models.AuctioneerPause) {
+							close(reading)
+							<-release
+						}
+						bbs.Unlock()
+
+						bbs.LockChannel <- true
+						Eventually(reading).Should(BeClosed())
+					})
+
+					AfterEach(func() {
+						select {
+						case <-release:
+						default:
+							close(release)
+						}
+					})
+
+					It("should keep the new pause rather than the one read before it", func() {
+						paused := make(chan error, 1)
+						go func() {
+							paused <- auctioneer.PauseStartAuctions()
+						}()
+
+						Consistently(paused).ShouldNot(Receive())
+
+						bbs.Lock()
+						bbs.WhenGettingAuctioneerPause = nil
+						bbs.Unlock()
+						close(release)
+
+						Eventually(paused).Should(Receive(BeNil()))
+						Consistently(func() bool { return auctioneer.Paused().StartAuctions }).Should(BeTrue())
 					})
 				})
 
-				Context("when the auction fails", func() {
+				Context("when stop auctions are paused", func() {
 					BeforeEach(func() {
-						runner.RunLRPStartAuctionReturns(auctiontypes.StartAuctionResult{}, errors.New("the auction failed"))
+						Ω(auctioneer.PauseStopAuctions()).ShouldNot(HaveOccurred())
 					})
 
-					It("should log that the auction failed and nontheless resolve the auction", func() {
-						Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					It("should stop watching for stop auctions, but keep watching for start auctions", func() {
+						Eventually(bbs.LRPStopAuctionStopChan).Should(BeClosed())
+						Ω(auctioneer.Status().StopAuctionsPaused).Should(BeTrue())
 
-						Ω(logger.TestSink.Buffer).Should(gbytes.Say("auction-failed"))
+						bbs.LRPStartAuctionChan <- startAuction
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
 					})
 				})
-			})
 
-			Context("when the claim fails", func() {
-				BeforeEach(func() {
-					bbs.Lock()
-					bbs.ClaimLRPStartAuctionError = errors.New("already claimed")
-					bbs.Unlock()
+				Context("when the pause can't be persisted", func() {
+					BeforeEach(func() {
+						bbs.Lock()
+						bbs.SetAuctioneerPauseError = fmt.Errorf("etcd is down")
+						bbs.Unlock()
+					})
+
+					It("should fail, and keep auctioning", func() {
+						Ω(auctioneer.PauseStartAuctions()).Should(MatchError("etcd is down"))
+						Ω(auctioneer.Status().StartAuctionsPaused).Should(BeFalse())
+
+						bbs.LRPStartAuctionChan <- startAuction
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+					})
 				})
 
-				It("should not run the auction", func() {
-					Consistently(runner.RunLRPStartAuctionCallCount).Should(BeZero())
+				Context("when stepping down", func() {
+					BeforeEach(func() {
+						Ω(auctioneer.StepDown()).ShouldNot(HaveOccurred())
+					})
+
+					It("should give up the lock, and contend for it again after a while", func() {
+						close(<-bbs.ReleaseLockChannel)
+
+						Ω(auctioneer.HasLock()).Should(BeFalse())
+						Eventually(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+						Eventually(bbs.LRPStopAuctionStopChan).Should(BeClosed())
+
+						Consistently(logger.TestSink.Buffer, config.LockInterval).ShouldNot(gbytes.Say("contending-for-lock"))
+						Eventually(logger.TestSink.Buffer, 3*config.LockInterval).Should(gbytes.Say("contending-for-lock"))
+
+						bbs.Lock()
+						bbs.LRPStartAuctionChan = make(chan models.LRPStartAuction)
+						bbs.LRPStartAuctionStopChan = make(chan bool)
+						bbs.LRPStopAuctionChan = make(chan models.LRPStopAuction)
+						bbs.LRPStopAuctionStopChan = make(chan bool)
+						bbs.Unlock()
+
+						bbs.LockChannel <- true
+
+						bbs.LRPStartAuctionChan <- startAuction
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+					})
 				})
 			})
 		})
 
-		Describe("Sad cases", func() {
-			Context("when there are no reps that match the desired stack", func() {
-				BeforeEach(func(done Done) {
-					startAuction = models.LRPStartAuction{
-						ProcessGuid: "my-guid",
-						Stack:       "monkey-bunnies",
+		Describe("cordoning and draining executors", func() {
+			Context("without the lock", func() {
+				It("should refuse", func() {
+					Ω(auctioneer.Cordon("first-rep")).Should(Equal(ErrLockNotHeld))
+					Ω(auctioneer.DrainExecutor("first-rep")).Should(Equal(ErrLockNotHeld))
+					Ω(bbs.GetStoredCordonedExecutors()).Should(BeEmpty())
+				})
+			})
+
+			Context("when executors were cordoned by whoever held the lock before", func() {
+				BeforeEach(func() {
+					bbs.Lock()
+					bbs.CordonedExecutors = map[string]models.CordonedExecutor{
+						"first-rep": {ExecutorID: "first-rep"},
 					}
-					bbs.LRPStartAuctionChan <- startAuction
+					bbs.Unlock()
 
-					Eventually(bbs.GetClaimedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
-					close(done)
+					bbs.LockChannel <- true
+					Eventually(ready).Should(BeClosed())
 				})
 
-				It("should not run the auction", func() {
-					Consistently(runner.RunLRPStartAuctionCallCount).Should(BeZero())
+				It("should keep start auctions off them", func() {
+					Ω(auctioneer.Status().CordonedExecutors).Should(Equal([]string{"first-rep"}))
+
+					bbs.LRPStartAuctionChan <- startAuction
+					Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+					Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("third-rep"))
 				})
 
-				It("should nonetheless resolve the auction in etcd", func() {
-					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+				It("should have stop auctions keep instances off them", func() {
+					bbs.LRPStopAuctionChan <- stopAuction
+					Eventually(runner.RunLRPStopAuctionCallCount).Should(Equal(1))
+
+					request := runner.RunLRPStopAuctionArgsForCall(0)
+					Ω(request.RepGuids).Should(ConsistOf("first-rep", "second-rep", "third-rep"))
+					Ω(request.DrainingRepGuids).Should(ConsistOf("first-rep"))
 				})
 			})
-		})
-	})
 
-	Describe("rate limiting many auctions", func() {
-		var startAuction1, startAuction2, startAuction3 models.LRPStartAuction
+			Context("with the lock", func() {
+				BeforeEach(func() {
+					config.ExecutorDrainInterval = 10 * time.Millisecond
+					config.ExecutorDrainReplacementTimeout = time.Second
+					auctioneer.Reconfigure(config)
 
-		BeforeEach(func() {
-			runner = &fake_auctionrunner.FakeAuctionRunner{}
-			runner.RunLRPStartAuctionStub = func(auctionRequest auctiontypes.StartAuctionRequest) (auctiontypes.StartAuctionResult, error) {
-				time.Sleep(time.Second)
-				return auctiontypes.StartAuctionResult{}, nil
-			}
+					bbs.LockChannel <- true
+					Eventually(ready).Should(BeClosed())
+				})
 
-			auctioneer = New(bbs, runner, 2, MAX_AUCTION_ROUNDS_FOR_TEST, time.Second, logger)
+				It("should persist cordons, and lift them", func() {
+					Ω(auctioneer.Cordon("first-rep")).ShouldNot(HaveOccurred())
+					Ω(bbs.GetStoredCordonedExecutors()).Should(HaveKey("first-rep"))
+					Ω(auctioneer.Cordoned()).Should(HaveLen(1))
 
-			go func() {
-				bbs.LockChannel <- true
-			}()
+					Ω(auctioneer.Uncordon("first-rep")).ShouldNot(HaveOccurred())
+					Ω(bbs.GetStoredCordonedExecutors()).Should(BeEmpty())
+					Ω(auctioneer.Cordoned()).Should(BeEmpty())
 
-			process = ifrit.Envoke(auctioneer)
+					bbs.LRPStartAuctionChan <- startAuction
+					Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+					Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+				})
 
-			startAuction1 = models.LRPStartAuction{
-				ProcessGuid: "my-guid-1",
-				Stack:       "lucid64",
-			}
-			startAuction2 = models.LRPStartAuction{
-				ProcessGuid: "my-guid-2",
-				Stack:       "lucid64",
-			}
-			startAuction3 = models.LRPStartAuction{
-				ProcessGuid: "my-guid-3",
-				Stack:       "lucid64",
-			}
-		})
+				It("should not cordon executors if the cordon can't be persisted", func() {
+					bbs.Lock()
+					bbs.CordonExecutorError = fmt.Errorf("etcd is down")
+					bbs.Unlock()
 
-		AfterEach(func() {
-			process.Signal(syscall.SIGTERM)
+					Ω(auctioneer.Cordon("first-rep")).Should(MatchError("etcd is down"))
+					Ω(auctioneer.Cordoned()).Should(BeEmpty())
+				})
+
+				It("should not drain executors that aren't cordoned", func() {
+					Ω(auctioneer.DrainExecutor("first-rep")).Should(Equal(ErrExecutorNotCordoned))
+				})
+
+				Context("when draining a cordoned executor", func() {
+					var replacementsRun bool
+
+					BeforeEach(func() {
+						replacementsRun = true
+
+						bbs.Lock()
+						bbs.ActualLRPs = []models.ActualLRP{
+							{ProcessGuid: "my-guid", InstanceGuid: "original", ExecutorID: "first-rep", Index: 0, State: models.ActualLRPStateRunning},
+							{ProcessGuid: "unknown-guid", InstanceGuid: "unknown", ExecutorID: "first-rep", Index: 0, State: models.ActualLRPStateRunning},
+							{ProcessGuid: "my-guid", InstanceGuid: "elsewhere", ExecutorID: "third-rep", Index: 1, State: models.ActualLRPStateRunning},
+						}
+						bbs.WhenRequestingLRPStartAuctions = func(auction models.LRPStartAuction) error {
+							if replacementsRun {
+								bbs.ActualLRPs = append(bbs.ActualLRPs, models.ActualLRP{
+									ProcessGuid:  auction.ProcessGuid,
+									InstanceGuid: auction.InstanceGuid,
+									ExecutorID:   "third-rep",
+									Index:        auction.Index,
+									State:        models.ActualLRPStateRunning,
+								})
+							}
+							return nil
+						}
+						bbs.DesiredLRPs = []models.DesiredLRP{
+							{ProcessGuid: "my-guid", Source: "http://example.com/droplet", Stack: "lucid64", MemoryMB: 512, DiskMB: 1024, StartCommand: "./run", LogGuid: "my-log-guid"},
+						}
+						bbs.Unlock()
+
+						Ω(auctioneer.Cordon("first-rep")).ShouldNot(HaveOccurred())
+					})
+
+					It("should replace the instances on it as they are desired, then stop-auction the originals", func() {
+						Ω(auctioneer.DrainExecutor("first-rep")).ShouldNot(HaveOccurred())
+
+						Eventually(bbs.GetRequestedLRPStopAuctions).Should(Equal([]models.LRPStopAuction{
+							{ProcessGuid: "my-guid", Index: 0},
+						}))
+
+						requested := bbs.GetRequestedLRPStartAuctions()
+						Ω(requested).Should(HaveLen(1))
+						Ω(requested[0].ProcessGuid).Should(Equal("my-guid"))
+						Ω(requested[0].Index).Should(Equal(0))
+						Ω(requested[0].InstanceGuid).ShouldNot(Equal("original"))
+						Ω(requested[0].Stack).Should(Equal("lucid64"))
+						Ω(requested[0].MemoryMB).Should(Equal(512))
+						Ω(requested[0].DiskMB).Should(Equal(1024))
+						Ω(requested[0].Log.Guid).Should(Equal("my-log-guid"))
+						Ω(requested[0].Actions).Should(HaveLen(2))
+						Ω(requested[0].Actions[0].Action).Should(Equal(models.DownloadAction{From: "http://example.com/droplet", To: "/app", Extract: true}))
+						Ω(requested[0].Actions[1].Action.(models.RunAction).Args).Should(Equal([]string{"-c", "./run"}))
+					})
+
+					It("should count the instances that no replacement could be built for", func() {
+						Ω(auctioneer.DrainExecutor("first-rep")).ShouldNot(HaveOccurred())
+
+						Eventually(func() []string { return auctioneer.Status().DrainingExecutors }).Should(BeEmpty())
+						Ω(logger.TestSink.Buffer).Should(gbytes.Say("failed-to-build-replacement"))
+						Ω(auctioneer.Status().Drains).Should(Equal([]ExecutorDrain{
+							{ExecutorID: "first-rep", Instances: 2, Moved: 1, Failed: 1},
+						}))
+					})
+
+					Context("when the auctioneer has seen a start auction for the process", func() {
+						BeforeEach(func() {
+							startAuction.InstanceGuid = "original"
+							startAuction.MemoryMB = 256
+							startAuction.Actions = []models.ExecutorAction{{Action: models.RunAction{Path: "the-desirers-recipe"}}}
+							startAuction.PreferredLabels = map[string]string{"ssd": "true"}
+							bbs.LRPStartAuctionChan <- startAuction
+							Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+						})
+
+						It("should copy how it was run, but ask for what is desired", func() {
+							Ω(auctioneer.DrainExecutor("first-rep")).ShouldNot(HaveOccurred())
+
+							Eventually(bbs.GetRequestedLRPStartAuctions).Should(HaveLen(1))
+
+							requested := bbs.GetRequestedLRPStartAuctions()[0]
+							Ω(requested.Actions).Should(Equal(startAuction.Actions))
+							Ω(requested.PreferredLabels).Should(Equal(startAuction.PreferredLabels))
+							Ω(requested.MemoryMB).Should(Equal(512))
+						})
+					})
+
+					Context("when the uncordoned executor's drain is over", func() {
+						It("should forget how it went", func() {
+							Ω(auctioneer.DrainExecutor("first-rep")).ShouldNot(HaveOccurred())
+							Eventually(func() []string { return auctioneer.Status().DrainingExecutors }).Should(BeEmpty())
+
+							Ω(auctioneer.Uncordon("first-rep")).ShouldNot(HaveOccurred())
+							Ω(auctioneer.Status().Drains).Should(BeEmpty())
+						})
+					})
+
+					Context("when a replacement doesn't run", func() {
+						BeforeEach(func() {
+							replacementsRun = false
+
+							config.ExecutorDrainReplacementTimeout = 50 * time.Millisecond
+							auctioneer.Reconfigure(config)
+						})
+
+						It("should leave the original running", func() {
+							Ω(auctioneer.DrainExecutor("first-rep")).ShouldNot(HaveOccurred())
+
+							Eventually(logger.TestSink.Buffer).Should(gbytes.Say("replacement-not-running"))
+							Eventually(func() []string { return auctioneer.Status().DrainingExecutors }).Should(BeEmpty())
+							Ω(bbs.GetRequestedLRPStopAuctions()).Should(BeEmpty())
+						})
+					})
+				})
+			})
+		})
+	})
+
+	Describe("rebalancing", func() {
+		var signals chan os.Signal
+		var ready chan struct{}
+		var errors chan error
+		var pool *simulation.RepPool
+		var replacementsRun bool
+
+		BeforeEach(func() {
+			replacementsRun = true
+
+			pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+				"first-rep":  {MemoryMB: 1000, DiskMB: 1000, Containers: 10},
+				"second-rep": {MemoryMB: 1000, DiskMB: 1000, Containers: 10},
+				"third-rep":  {MemoryMB: 1000, DiskMB: 1000, Containers: 10},
+			})
+			pool.SetSimulatedInstances("first-rep", []auctiontypes.SimulatedInstance{
+				{ProcessGuid: "my-guid", InstanceGuid: "a", Index: 0, MemoryMB: 400, DiskMB: 400},
+				{ProcessGuid: "my-guid", InstanceGuid: "b", Index: 1, MemoryMB: 400, DiskMB: 400},
+			})
+
+			bbs.Lock()
+			bbs.ActualLRPs = []models.ActualLRP{
+				{ProcessGuid: "my-guid", InstanceGuid: "a", ExecutorID: "first-rep", Index: 0, State: models.ActualLRPStateRunning},
+				{ProcessGuid: "my-guid", InstanceGuid: "b", ExecutorID: "first-rep", Index: 1, State: models.ActualLRPStateRunning},
+			}
+			bbs.WhenRequestingLRPStartAuctions = func(auction models.LRPStartAuction) error {
+				if replacementsRun {
+					bbs.ActualLRPs = append(bbs.ActualLRPs, models.ActualLRP{
+						ProcessGuid:  auction.ProcessGuid,
+						InstanceGuid: auction.InstanceGuid,
+						ExecutorID:   "third-rep",
+						Index:        auction.Index,
+						State:        models.ActualLRPStateRunning,
+					})
+				}
+				return nil
+			}
+			bbs.DesiredLRPs = []models.DesiredLRP{
+				{ProcessGuid: "my-guid", Source: "http://example.com/droplet", Stack: "lucid64", MemoryMB: 400, DiskMB: 400},
+			}
+			bbs.Unlock()
+
+			config.RepPool = pool
+			config.RebalanceInterval = 20 * time.Millisecond
+			config.RebalanceReplacementTimeout = time.Second
+		})
+
+		JustBeforeEach(func() {
+			runner = &fake_runner.FakeAuctionRunner{}
+			auctioneer = New(bbs, runner, config, logger)
+			signals = make(chan os.Signal)
+			ready = make(chan struct{})
+			errors = make(chan error)
+
+			go func() {
+				errors <- auctioneer.Run(signals, ready)
+			}()
+
+			bbs.LockChannel <- true
+			Eventually(ready).Should(BeClosed())
+		})
+
+		AfterEach(func() {
+			signals <- syscall.SIGTERM
+			close(<-bbs.ReleaseLockChannel)
+			Eventually(errors).Should(Receive())
+		})
+
+		It("should replace an instance on the most loaded rep, then stop the original", func() {
+			Eventually(bbs.GetRequestedStopLRPInstances).ShouldNot(BeEmpty())
+			Ω(bbs.GetRequestedStopLRPInstances()[0]).Should(Equal(models.StopLRPInstance{
+				ProcessGuid:  "my-guid",
+				InstanceGuid: "a",
+				Index:        0,
+			}))
+
+			requested := bbs.GetRequestedLRPStartAuctions()
+			Ω(requested[0].ProcessGuid).Should(Equal("my-guid"))
+			Ω(requested[0].Index).Should(Equal(0))
+			Ω(requested[0].InstanceGuid).ShouldNot(Equal("a"))
+			Ω(requested[0].MemoryMB).Should(Equal(400))
+			Ω(requested[0].ExcludedExecutorIDs).Should(Equal([]string{"first-rep"}))
+
+			Eventually(logger.TestSink.Buffer).Should(gbytes.Say("rebalancer.rebalance.move.moved"))
+		})
+
+		Context("when a replacement runs on the original's executor anyway", func() {
+			BeforeEach(func() {
+				bbs.Lock()
+				bbs.WhenRequestingLRPStartAuctions = func(auction models.LRPStartAuction) error {
+					bbs.ActualLRPs = append(bbs.ActualLRPs, models.ActualLRP{
+						ProcessGuid:  auction.ProcessGuid,
+						InstanceGuid: auction.InstanceGuid,
+						ExecutorID:   "first-rep",
+						Index:        auction.Index,
+						State:        models.ActualLRPStateRunning,
+					})
+					return nil
+				}
+				bbs.Unlock()
+			})
+
+			It("should not stop the original", func() {
+				Eventually(logger.TestSink.Buffer).Should(gbytes.Say("replacement-on-same-executor"))
+				Ω(bbs.GetRequestedStopLRPInstances()).Should(BeEmpty())
+			})
+		})
+
+		Context("when a replacement doesn't run", func() {
+			BeforeEach(func() {
+				replacementsRun = false
+				config.RebalanceMaxMoves = 2
+			})
+
+			It("should move no more of the process's instances than it may have unavailable", func() {
+				Eventually(bbs.GetRequestedLRPStartAuctions).Should(HaveLen(1))
+				Consistently(bbs.GetRequestedLRPStartAuctions).Should(HaveLen(1))
+
+				Ω(auctioneer.Status().RebalancingInstances).Should(Equal(1))
+				Ω(bbs.GetRequestedStopLRPInstances()).Should(BeEmpty())
+			})
+		})
+
+		Context("when the reps' load is within the threshold", func() {
+			BeforeEach(func() {
+				config.RebalanceThreshold = 0.9
+			})
+
+			It("should move nothing", func() {
+				Consistently(bbs.GetRequestedLRPStartAuctions).Should(BeEmpty())
+			})
+		})
+
+		Context("in a dry run", func() {
+			BeforeEach(func() {
+				config.RebalanceDryRun = true
+			})
+
+			It("should only log the moves it would make", func() {
+				Eventually(logger.TestSink.Buffer).Should(gbytes.Say("proposed-move"))
+				Ω(bbs.GetRequestedLRPStartAuctions()).Should(BeEmpty())
+				Ω(bbs.GetRequestedStopLRPInstances()).Should(BeEmpty())
+			})
+		})
+	})
+
+	Describe("the start auction lifecycle", func() {
+		BeforeEach(func() {
+			runner = &fake_runner.FakeAuctionRunner{}
+		})
+
+		JustBeforeEach(func() {
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func(done Done) {
+			//send a shut down signal
+			process.Signal(syscall.SIGTERM)
+			//which releases the lock, which we need to acknowledge by closing the channel sent to the release lock channel
+			close(<-bbs.ReleaseLockChannel)
+			//which (eventually) causes the process to exit
+			Eventually(process.Wait()).Should(Receive())
+			//and should stop the auction
+			Ω(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+
+			close(done)
+		})
+
+		Context("when a rep's breaker is open", func() {
+			var repBreakers *breaker.Breakers
+
+			BeforeEach(func() {
+				repBreakers = breaker.New(breaker.Config{MinRequests: 1, OpenFor: time.Minute}, logger)
+				repBreakers.Record("first-rep", breaker.TimedOut)
+				config.RepBreakers = repBreakers
+			})
+
+			It("should leave the rep out of start auctions", func() {
+				bbs.LRPStartAuctionChan <- startAuction
+				Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+				Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("third-rep"))
+			})
+
+			It("should leave the rep out of stop auctions", func() {
+				bbs.LRPStopAuctionChan <- stopAuction
+				Eventually(runner.RunLRPStopAuctionCallCount).Should(Equal(1))
+				Ω(runner.RunLRPStopAuctionArgsForCall(0).RepGuids).Should(ConsistOf("second-rep", "third-rep"))
+			})
+
+			Context("when every rep that could run the instance is unresponsive", func() {
+				BeforeEach(func() {
+					repBreakers.Record("third-rep", breaker.TimedOut)
+				})
+
+				It("should fail the start auction without running it", func() {
+					bbs.LRPStartAuctionChan <- startAuction
+					Eventually(logger.TestSink.Buffer).Should(gbytes.Say("all-executors-unresponsive"))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when the rep is due a probe but lacks the labels an auction requires", func() {
+				BeforeEach(func() {
+					repBreakers = breaker.New(breaker.Config{MinRequests: 1, OpenFor: 200 * time.Millisecond}, logger)
+					repBreakers.Record("first-rep", breaker.TimedOut)
+					config.RepBreakers = repBreakers
+
+					thirdExecutor.Labels = map[string]string{"gpu-class": "a100"}
+
+					bbs.Lock()
+					bbs.Executors = []models.ExecutorPresence{firstExecutor, secondExecutor, thirdExecutor}
+					bbs.Unlock()
+
+					startAuction.RequiredLabels = map[string]string{"gpu-class": "a100"}
+
+					time.Sleep(250 * time.Millisecond)
+				})
+
+				It("should keep the probe for an auction the rep can take part in", func() {
+					bbs.LRPStartAuctionChan <- startAuction
+					Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(1))
+					Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("third-rep"))
+
+					states := repBreakers.States()
+					Ω(states).Should(HaveLen(1))
+					Ω(states[0].State).Should(Equal(breaker.Open))
+
+					unlabelled := startAuction
+					unlabelled.ProcessGuid = "other-guid"
+					unlabelled.RequiredLabels = nil
+					bbs.LRPStartAuctionChan <- unlabelled
+					Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(2))
+					Ω(runner.RunLRPStartAuctionArgsForCall(1).RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+					Ω(repBreakers.States()[0].State).Should(Equal(breaker.HalfOpen))
+				})
+			})
+		})
+
+		Context("when a pending auction request arrives over ETCD", func() {
+			JustBeforeEach(func(done Done) {
+				bbs.LRPStartAuctionChan <- startAuction
+				close(done)
+			})
+
+			It("should attempt to claim the auction", func() {
+				Eventually(bbs.GetClaimedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
+			})
+
+			Context("when the claim succeeds", func() {
+				It("should run the auction with reps of the proper stack", func() {
+					Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+
+					request := runner.RunLRPStartAuctionArgsForCall(0)
+					Ω(request.LRPStartAuction).Should(Equal(startAuction))
+					Ω(request.RepGuids).Should(HaveLen(2))
+					Ω(request.RepGuids).Should(ContainElement(firstExecutor.ExecutorID))
+					Ω(request.RepGuids).Should(ContainElement(thirdExecutor.ExecutorID))
+					Ω(request.RepGuids).ShouldNot(ContainElement(secondExecutor.ExecutorID))
+					Ω(request.Rules.Algorithm).Should(Equal("reserve_n_best"))
+					Ω(request.Rules.MaxBiddingPoolFraction).Should(Equal(0.2))
+					Ω(request.Rules.MaxRounds).Should(Equal(MAX_AUCTION_ROUNDS_FOR_TEST))
+					Ω(request.RepZones).Should(BeEmpty())
+				})
+
+				Context("when the executors are in zones", func() {
+					BeforeEach(func() {
+						firstExecutor.Zone = "z1"
+						thirdExecutor.Zone = "z2"
+
+						bbs.Lock()
+						bbs.Executors = []models.ExecutorPresence{
+							firstExecutor,
+							secondExecutor,
+							thirdExecutor,
+							{ExecutorID: "fourth-rep", Stack: "lucid64", Zone: "z1"},
+						}
+						bbs.ActualLRPs = []models.ActualLRP{
+							{ProcessGuid: "my-guid", InstanceGuid: "a", Index: 0, ExecutorID: "first-rep"},
+							{ProcessGuid: "my-guid", InstanceGuid: "b", Index: 1, ExecutorID: "fourth-rep"},
+							{ProcessGuid: "my-guid", InstanceGuid: "c", Index: 2, ExecutorID: "third-rep"},
+							{ProcessGuid: "other-guid", InstanceGuid: "d", Index: 0, ExecutorID: "third-rep"},
+						}
+						bbs.Unlock()
+
+						runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{
+							Winner:           "third-rep",
+							Zone:             "z2",
+							InstancesPerZone: map[string]int{"z1": 2, "z2": 2},
+						}, nil)
+					})
+
+					It("should run the auction with the zones of the reps and the instances of the process in each", func() {
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+
+						request := runner.RunLRPStartAuctionArgsForCall(0)
+						Ω(request.RepZones).Should(Equal(map[string]string{
+							"first-rep":  "z1",
+							"third-rep":  "z2",
+							"fourth-rep": "z1",
+						}))
+						Ω(request.InstancesPerZone).Should(Equal(map[string]int{"z1": 2, "z2": 1}))
+					})
+
+					It("should log where the instance landed and how the process is spread", func() {
+						Eventually(logger.TestSink.Buffer).Should(gbytes.Say(`succeeded.*"instances-per-zone":\{"z1":2,"z2":2\}.*"zone":"z2"`))
+					})
+				})
+
+				Context("when the auction requires and prefers executor labels", func() {
+					BeforeEach(func() {
+						firstExecutor.Labels = map[string]string{"isolation-segment": "prod", "ssd": "true"}
+						thirdExecutor.Labels = map[string]string{"isolation-segment": "prod"}
+
+						bbs.Lock()
+						bbs.Executors = []models.ExecutorPresence{
+							firstExecutor,
+							secondExecutor,
+							thirdExecutor,
+							{ExecutorID: "fourth-rep", Stack: "lucid64", Labels: map[string]string{"isolation-segment": "dev", "ssd": "true"}},
+						}
+						bbs.Unlock()
+
+						startAuction.RequiredLabels = map[string]string{"isolation-segment": "prod"}
+						startAuction.PreferredLabels = map[string]string{"ssd": "true"}
+					})
+
+					It("should run the auction with the preferred executors among those with the required labels", func() {
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+						Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("first-rep"))
+					})
+
+					Context("when the preferred executors have no room", func() {
+						BeforeEach(func() {
+							runner.RunLRPStartAuctionStub = func(request algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+								if len(request.RepGuids) == 1 {
+									return algorithms.StartAuctionResult{}, auctiontypes.InsufficientResources
+								}
+								return algorithms.StartAuctionResult{Winner: "third-rep"}, nil
+							}
+						})
+
+						It("should run the auction again with every executor that has the required labels", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(2))
+							Ω(runner.RunLRPStartAuctionArgsForCall(1).RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+							Ω(logger.TestSink.Buffer).Should(gbytes.Say("no-room-on-preferred-executors"))
+						})
+					})
+
+					Context("when no executor has the preferred labels", func() {
+						BeforeEach(func() {
+							startAuction.PreferredLabels = map[string]string{"gpu-class": "a100"}
+						})
+
+						It("should run the auction with every executor that has the required labels", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+							Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+						})
+					})
+				})
+
+				Context("with anti-affinity", func() {
+					BeforeEach(func() {
+						bbs.Lock()
+						bbs.ActualLRPs = []models.ActualLRP{
+							{ProcessGuid: "my-guid", InstanceGuid: "a", Index: 0, ExecutorID: "first-rep"},
+							{ProcessGuid: "other-guid", InstanceGuid: "b", Index: 0, ExecutorID: "third-rep"},
+						}
+						bbs.Unlock()
+					})
+
+					Context("when it is soft", func() {
+						BeforeEach(func() {
+							config.AntiAffinity = "soft"
+						})
+
+						It("should run the auction with every rep, and the instances of the process on each", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+
+							request := runner.RunLRPStartAuctionArgsForCall(0)
+							Ω(request.AntiAffinity).Should(Equal("soft"))
+							Ω(request.RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+							Ω(request.InstancesPerRep).Should(Equal(map[string]int{"first-rep": 1}))
+						})
+					})
+
+					Context("when it is hard", func() {
+						BeforeEach(func() {
+							config.AntiAffinity = "hard"
+						})
+
+						It("should run the auction without the reps that host the process", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+
+							request := runner.RunLRPStartAuctionArgsForCall(0)
+							Ω(request.AntiAffinity).Should(Equal("hard"))
+							Ω(request.RepGuids).Should(ConsistOf("third-rep"))
+						})
+
+						Context("when every rep hosts the process", func() {
+							BeforeEach(func() {
+								bbs.Lock()
+								bbs.ActualLRPs = append(bbs.ActualLRPs, models.ActualLRP{ProcessGuid: "my-guid", InstanceGuid: "c", Index: 1, ExecutorID: "third-rep"})
+								bbs.Unlock()
+							})
+
+							It("should not run the auction, and say why", func() {
+								Eventually(logger.TestSink.Buffer).Should(gbytes.Say("no-executors-without-instances-of-process"))
+								Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+								Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+							})
+						})
+					})
+
+					Context("when the start auction chooses its own", func() {
+						BeforeEach(func() {
+							config.AntiAffinity = "hard"
+							startAuction.AntiAffinity = "none"
+						})
+
+						It("should run the auction with that", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+
+							request := runner.RunLRPStartAuctionArgsForCall(0)
+							Ω(request.AntiAffinity).Should(Equal("none"))
+							Ω(request.RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+						})
+					})
+
+					Context("when the start auction asks for an unknown mode", func() {
+						BeforeEach(func() {
+							startAuction.AntiAffinity = "strict"
+						})
+
+						It("should not run the auction, and say why", func() {
+							Eventually(logger.TestSink.Buffer).Should(gbytes.Say("invalid-anti-affinity"))
+							Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+						})
+					})
+				})
+
+				Context("when other stacks are compatible with the desired stack", func() {
+					BeforeEach(func() {
+						config.Stacks = StackCompatibility{
+							Aliases: map[string]string{"lucid": "lucid64"},
+							Compatible: map[string][][]string{
+								"lucid64": {{"cflinuxfs2"}, {".Net"}},
+							},
+						}
+
+						bbs.Lock()
+						bbs.Executors = []models.ExecutorPresence{
+							firstExecutor,
+							secondExecutor,
+							{ExecutorID: "third-rep", Stack: "lucid"},
+							{ExecutorID: "fourth-rep", Stack: "cflinuxfs2"},
+						}
+						bbs.Unlock()
+
+						startAuction.Stack = "lucid"
+					})
+
+					It("should run the auction with the reps of the desired stack, and its aliases, first", func() {
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+						Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("first-rep", "third-rep"))
+					})
+
+					Context("when they have no room", func() {
+						BeforeEach(func() {
+							runner.RunLRPStartAuctionStub = func(request algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+								for _, repGuid := range request.RepGuids {
+									if repGuid == "second-rep" {
+										return algorithms.StartAuctionResult{Winner: repGuid}, nil
+									}
+								}
+								return algorithms.StartAuctionResult{}, auctiontypes.InsufficientResources
+							}
+						})
+
+						It("should move on through the compatible stacks in order", func() {
+							Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(3))
+							Ω(runner.RunLRPStartAuctionArgsForCall(1).RepGuids).Should(ConsistOf("fourth-rep"))
+							Ω(runner.RunLRPStartAuctionArgsForCall(2).RepGuids).Should(ConsistOf("second-rep"))
+						})
+
+						It("should log the stack the instance landed on", func() {
+							Eventually(logger.TestSink.Buffer).Should(gbytes.Say(`succeeded.*"stack":".Net"`))
+						})
+					})
+				})
+
+				Context("when the auction excludes executors", func() {
+					BeforeEach(func() {
+						startAuction.ExcludedExecutorIDs = []string{"first-rep"}
+					})
+
+					It("should run the auction without them", func() {
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+						Ω(runner.RunLRPStartAuctionArgsForCall(0).RepGuids).Should(ConsistOf("third-rep"))
+					})
+				})
+
+				Context("when the stack has its own rules", func() {
+					var lucidRules algorithms.StartAuctionRules
+
+					BeforeEach(func() {
+						lucidRules = algorithms.StartAuctionRules{
+							Algorithm:              "pick_best",
+							MaxRounds:              3,
+							MaxBiddingPoolFraction: 0.5,
+							MinBiddingPool:         1,
+						}
+
+						config.StackStartAuctionRules = map[string]algorithms.StartAuctionRules{
+							"lucid64": lucidRules,
+						}
+					})
+
+					It("should run the auction with those rules", func() {
+						Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+						Ω(runner.RunLRPStartAuctionArgsForCall(0).Rules).Should(Equal(lucidRules))
+					})
+				})
+
+				Context("when the auction succeeds", func() {
+					It("should resolve the auction in etcd", func() {
+						Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					})
+				})
+
+				Context("when the auction fails", func() {
+					BeforeEach(func() {
+						runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{}, errors.New("the auction failed"))
+					})
+
+					It("should log that the auction failed and nontheless resolve the auction", func() {
+						Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+
+						Ω(logger.TestSink.Buffer).Should(gbytes.Say("auction-failed"))
+					})
+				})
+			})
+
+			Context("when the claim fails", func() {
+				BeforeEach(func() {
+					bbs.Lock()
+					bbs.ClaimLRPStartAuctionError = errors.New("already claimed")
+					bbs.Unlock()
+				})
+
+				It("should not run the auction", func() {
+					Consistently(runner.RunLRPStartAuctionCallCount).Should(BeZero())
+				})
+			})
+		})
+
+		Describe("Sad cases", func() {
+			Context("when there are no reps that match the desired stack", func() {
+				JustBeforeEach(func(done Done) {
+					startAuction = models.LRPStartAuction{
+						ProcessGuid: "my-guid",
+						Stack:       "monkey-bunnies",
+					}
+					bbs.LRPStartAuctionChan <- startAuction
+
+					Eventually(bbs.GetClaimedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
+					close(done)
+				})
+
+				It("should not run the auction", func() {
+					Consistently(runner.RunLRPStartAuctionCallCount).Should(BeZero())
+				})
+
+				It("should nonetheless resolve the auction in etcd", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+				})
+			})
+
+			Context("when no rep of the desired stack has the required labels", func() {
+				JustBeforeEach(func(done Done) {
+					startAuction.RequiredLabels = map[string]string{"gpu-class": "a100"}
+					bbs.LRPStartAuctionChan <- startAuction
+
+					Eventually(bbs.GetClaimedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
+					close(done)
+				})
+
+				It("should not run the auction", func() {
+					Consistently(runner.RunLRPStartAuctionCallCount).Should(BeZero())
+				})
+
+				It("should log that no executor matched the labels", func() {
+					Eventually(logger.TestSink.Buffer).Should(gbytes.Say("no-executors-match-required-labels"))
+					Ω(logger.TestSink.Buffer).ShouldNot(gbytes.Say("no-available-executors"))
+				})
+
+				It("should nonetheless resolve the auction in etcd", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+				})
+			})
+		})
+
+		Describe("retrying failed start auctions", func() {
+			BeforeEach(func() {
+				config.StartAuctionRetry = RetryPolicy{
+					MaxAttempts: 3,
+					MinBackoff:  50 * time.Millisecond,
+					MaxBackoff:  100 * time.Millisecond,
+					Deadline:    time.Minute,
+				}
+
+				runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{}, auctiontypes.InsufficientResources)
+			})
+
+			JustBeforeEach(func(done Done) {
+				bbs.LRPStartAuctionChan <- startAuction
+				close(done)
+			})
+
+			It("should requeue the auction, counting the attempt, instead of resolving it", func() {
+				Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+
+				requeued := bbs.GetRequeuedLRPStartAuctions()[0]
+				Ω(requeued.ProcessGuid).Should(Equal(startAuction.ProcessGuid))
+				Ω(requeued.Attempts).Should(Equal(1))
+				Ω(requeued.FirstAttemptAt).ShouldNot(BeZero())
+				Ω(requeued.RetryAt).Should(BeNumerically(">=", requeued.FirstAttemptAt+int64(50*time.Millisecond)))
+
+				Consistently(bbs.GetResolvedLRPStartAuction).Should(Equal(models.LRPStartAuction{}))
+				Ω(logger.TestSink.Buffer).Should(gbytes.Say("requeued"))
+			})
+
+			It("should record why the auction failed, and that it will be retried", func() {
+				Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+
+				failure := bbs.GetFailedLRPStartAuctions()[0]
+				Ω(failure.ProcessGuid).Should(Equal(startAuction.ProcessGuid))
+				Ω(failure.Stack).Should(Equal("lucid64"))
+				Ω(failure.Reason).Should(Equal(models.FailedLRPStartAuctionReasonInsufficientResources))
+				Ω(failure.Error).Should(Equal(auctiontypes.InsufficientResources.Error()))
+				Ω(failure.Attempts).Should(Equal(1))
+				Ω(failure.GaveUp).Should(BeFalse())
+				Ω(failure.FailedAt).ShouldNot(BeZero())
+
+				bbs.Lock()
+				Ω(bbs.FailedLRPStartAuctionTTL).Should(Equal(DefaultFailedStartAuctionTTL))
+				bbs.Unlock()
+			})
+
+			Context("when recording the failure outlasts the backoff", func() {
+				BeforeEach(func() {
+					bbs.WhenRecordingFailedLRPStartAuction = func(models.FailedLRPStartAuction) {
+						time.Sleep(300 * time.Millisecond)
+					}
+				})
+
+				It("should still run the requeued auction once it is due", func() {
+					Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+
+					//the requeued auction comes back over the watch while its
+					//failure is still being recorded
+					bbs.Lock()
+					bbs.WhenRecordingFailedLRPStartAuction = nil
+					bbs.Unlock()
+					bbs.LRPStartAuctionChan <- bbs.GetRequeuedLRPStartAuctions()[0]
+
+					Consistently(runner.RunLRPStartAuctionCallCount, 0.1).Should(Equal(1))
+					Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(2))
+				})
+			})
+
+			It("should hold a requeued auction until it is due", func() {
+				Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+
+				requeued := bbs.GetRequeuedLRPStartAuctions()[0]
+				requeued.RetryAt = time.Now().Add(500 * time.Millisecond).UnixNano()
+				bbs.LRPStartAuctionChan <- requeued
+
+				Consistently(runner.RunLRPStartAuctionCallCount, 0.3).Should(Equal(1))
+				Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(2))
+				Ω(runner.RunLRPStartAuctionArgsForCall(1).LRPStartAuction).Should(Equal(requeued))
+			})
+
+			Context("when the auction has used its last attempt", func() {
+				BeforeEach(func() {
+					startAuction.Attempts = 2
+					startAuction.FirstAttemptAt = time.Now().UnixNano()
+				})
+
+				It("should give up, resolve the auction and record why", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					Ω(bbs.GetRequeuedLRPStartAuctions()).Should(BeEmpty())
+
+					failed := bbs.GetFailedLRPStartAuctions()
+					Ω(failed).Should(HaveLen(1))
+					Ω(failed[0].ProcessGuid).Should(Equal(startAuction.ProcessGuid))
+					Ω(failed[0].Attempts).Should(Equal(3))
+					Ω(failed[0].GaveUp).Should(BeTrue())
+					Ω(failed[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonInsufficientResources))
+
+					Ω(logger.TestSink.Buffer).Should(gbytes.Say("gave-up"))
+				})
+			})
+
+			Context("when the auction is past its deadline", func() {
+				BeforeEach(func() {
+					startAuction.Attempts = 1
+					startAuction.FirstAttemptAt = time.Now().Add(-2 * time.Minute).UnixNano()
+				})
+
+				It("should give up", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					Ω(bbs.GetRequeuedLRPStartAuctions()).Should(BeEmpty())
+					Ω(bbs.GetFailedLRPStartAuctions()[0].GaveUp).Should(BeTrue())
+				})
+			})
+
+			Context("when there are no executors for the stack", func() {
+				BeforeEach(func() {
+					startAuction.Stack = "monkey-bunnies"
+				})
+
+				It("should requeue the auction", func() {
+					Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+
+				It("should record that there were no executors", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonNoExecutors))
+				})
+			})
+
+			Context("when the auction excludes every executor for the stack", func() {
+				BeforeEach(func() {
+					startAuction.ExcludedExecutorIDs = []string{"first-rep", "third-rep"}
+				})
+
+				It("should record that they were excluded", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonAllExecutorsExcluded))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when every executor for the stack is cordoned", func() {
+				BeforeEach(func() {
+					bbs.Lock()
+					bbs.CordonedExecutors = map[string]models.CordonedExecutor{
+						"first-rep": {ExecutorID: "first-rep"},
+						"third-rep": {ExecutorID: "third-rep"},
+					}
+					bbs.Unlock()
+				})
+
+				It("should record that they were cordoned", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonAllExecutorsCordoned))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when every executor for the stack is unresponsive", func() {
+				BeforeEach(func() {
+					repBreakers := breaker.New(breaker.Config{MinRequests: 1, OpenFor: time.Minute}, logger)
+					repBreakers.Record("first-rep", breaker.TimedOut)
+					repBreakers.Record("third-rep", breaker.TimedOut)
+					config.RepBreakers = repBreakers
+				})
+
+				It("should record that they were unresponsive", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonAllExecutorsUnresponsive))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when no executor for the stack has the required labels", func() {
+				BeforeEach(func() {
+					startAuction.RequiredLabels = map[string]string{"gpu-class": "a100"}
+				})
+
+				It("should record that none had the labels", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonNoExecutorsWithLabels))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when hard anti-affinity excludes every executor for the stack", func() {
+				BeforeEach(func() {
+					config.AntiAffinity = "hard"
+
+					bbs.Lock()
+					bbs.ActualLRPs = []models.ActualLRP{
+						{ProcessGuid: "my-guid", InstanceGuid: "a", Index: 0, ExecutorID: "first-rep"},
+						{ProcessGuid: "my-guid", InstanceGuid: "b", Index: 1, ExecutorID: "third-rep"},
+					}
+					bbs.Unlock()
+				})
+
+				It("should record that anti-affinity excluded them", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonAntiAffinityExcludesAll))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+			})
+
+			Context("when no rep answers in time", func() {
+				BeforeEach(func() {
+					runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{NumRounds: 2, NumCommunications: 6}, algorithms.AllBiddersTimedOut)
+				})
+
+				It("should record that the bidders timed out, with the rounds and communications it took", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+
+					failure := bbs.GetFailedLRPStartAuctions()[0]
+					Ω(failure.Reason).Should(Equal(models.FailedLRPStartAuctionReasonAllBiddersTimedOut))
+					Ω(failure.NumRounds).Should(Equal(2))
+					Ω(failure.NumCommunications).Should(Equal(6))
+				})
+			})
+
+			Context("when a retried auction succeeds", func() {
+				BeforeEach(func() {
+					startAuction.Attempts = 1
+					runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{Winner: "first-rep"}, nil)
+				})
+
+				It("should remove the record of its failure", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					Ω(bbs.GetRemovedFailedLRPStartAuctions()).Should(Equal([]models.LRPStartAuction{startAuction}))
+				})
+			})
+
+			Context("when a first attempt succeeds", func() {
+				BeforeEach(func() {
+					runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{Winner: "first-rep"}, nil)
+				})
+
+				It("should remove any record of an earlier failure at its index", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					Ω(bbs.GetRemovedFailedLRPStartAuctions()).Should(Equal([]models.LRPStartAuction{startAuction}))
+				})
+
+				Context("when there is no record to remove", func() {
+					BeforeEach(func() {
+						bbs.Lock()
+						bbs.RemoveFailedLRPStartAuctionError = storeadapter.ErrorKeyNotFound
+						bbs.Unlock()
+					})
+
+					It("should resolve the auction without logging an error", func() {
+						Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+						Ω(logger.TestSink.Buffer).ShouldNot(gbytes.Say("failed-to-remove-failure"))
+					})
+				})
+			})
+
+			Context("when the process's instances can't be fetched under hard anti-affinity", func() {
+				BeforeEach(func() {
+					startAuction.AntiAffinity = "hard"
+
+					bbs.Lock()
+					bbs.ActualLRPsErr = errors.New("oops")
+					bbs.Unlock()
+				})
+
+				It("should requeue the auction without running it", func() {
+					Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+					Ω(runner.RunLRPStartAuctionCallCount()).Should(BeZero())
+				})
+
+				It("should record that the instances couldn't be fetched", func() {
+					Eventually(bbs.GetFailedLRPStartAuctions).Should(HaveLen(1))
+
+					failure := bbs.GetFailedLRPStartAuctions()[0]
+					Ω(failure.Reason).Should(Equal(models.FailedLRPStartAuctionReasonFailedToGetActualLRPs))
+					Ω(failure.Error).Should(Equal("oops"))
+					Ω(failure.GaveUp).Should(BeFalse())
+				})
+			})
+
+			Context("when the process's instances can't be fetched under soft anti-affinity", func() {
+				BeforeEach(func() {
+					startAuction.AntiAffinity = "soft"
+
+					bbs.Lock()
+					bbs.ActualLRPsErr = errors.New("oops")
+					bbs.Unlock()
+				})
+
+				It("should run the auction anyway", func() {
+					Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+				})
+			})
+
+			Context("when the auction can never succeed", func() {
+				BeforeEach(func() {
+					startAuction.AntiAffinity = "sometimes"
+				})
+
+				It("should give up straight away", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					Ω(bbs.GetRequeuedLRPStartAuctions()).Should(BeEmpty())
+
+					failed := bbs.GetFailedLRPStartAuctions()
+					Ω(failed).Should(HaveLen(1))
+					Ω(failed[0].Attempts).Should(Equal(1))
+					Ω(failed[0].GaveUp).Should(BeTrue())
+					Ω(failed[0].Reason).Should(Equal(models.FailedLRPStartAuctionReasonInvalidAntiAffinity))
+				})
+			})
+
+			Context("when requeueing fails", func() {
+				BeforeEach(func() {
+					bbs.Lock()
+					bbs.RequeueLRPStartAuctionError = errors.New("oops")
+					bbs.Unlock()
+				})
+
+				It("should give up on the auction rather than leave it claimed", func() {
+					Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+					Ω(logger.TestSink.Buffer).Should(gbytes.Say("failed-to-requeue"))
+					Ω(bbs.GetFailedLRPStartAuctions()[0].GaveUp).Should(BeTrue())
+				})
+			})
+		})
+
+		Describe("losing the lock while auctions are in flight", func() {
+			var pool *simulation.RepPool
+			var tracker *algorithms.ReservationTracker
+
+			BeforeEach(func() {
+				pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+					"first-rep": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+				})
+				tracker = algorithms.NewReservationTracker(pool)
+				config.Reservations = tracker
+
+				//like the real runner, try to run the instance, however late
+				tracker := tracker
+				runner.RunLRPStartAuctionStub = func(request algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+					tracker.RebidThenTentativelyReserve([]string{"first-rep"}, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+					<-request.Abort
+					tracker.Run("first-rep", request.LRPStartAuction)
+					return algorithms.StartAuctionResult{}, algorithms.AuctionAborted
+				}
+			})
+
+			JustBeforeEach(func(done Done) {
+				bbs.LRPStartAuctionChan <- startAuction
+				Eventually(func() int { return auctioneer.Status().InFlightStartAuctions }).Should(Equal(1))
+
+				bbs.LockChannel <- false
+				close(done)
+			})
+
+			It("should abort the auction and return it to pending", func() {
+				Eventually(bbs.GetRequeuedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
+				Ω(logger.TestSink.Buffer).Should(gbytes.Say("aborted"))
+				Ω(bbs.GetResolvedLRPStartAuction()).Should(Equal(models.LRPStartAuction{}))
+			})
+
+			It("should release the auction's reservations, and not run its instance", func() {
+				Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+
+				Ω(pool.Reservations("first-rep")).Should(BeEmpty())
+				Ω(pool.SimulatedInstances("first-rep")).Should(BeEmpty())
+			})
+
+			Context("when the lock is regained", func() {
+				JustBeforeEach(func(done Done) {
+					Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+
+					bbs.LRPStartAuctionStopChan = make(chan bool)
+					bbs.LRPStopAuctionStopChan = make(chan bool)
+
+					bbs.LockChannel <- true
+					close(done)
+				})
+
+				It("should let instances run again", func() {
+					Eventually(func() bool { return auctioneer.Status().Ready() }).Should(BeTrue())
+
+					tracker.Run("first-rep", startAuction)
+					Ω(pool.SimulatedInstances("first-rep")).Should(HaveLen(1))
+				})
+			})
+		})
+
+		Describe("draining on shutdown", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				release = make(chan struct{})
+				config.DrainTimeout = time.Minute
+
+				//auctions abandoned by one test may still be running in the next
+				release := release
+				runner.RunLRPStartAuctionStub = func(algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+					<-release
+					return algorithms.StartAuctionResult{}, nil
+				}
+			})
+
+			JustBeforeEach(func(done Done) {
+				bbs.LRPStartAuctionChan <- startAuction
+				Eventually(func() int { return auctioneer.Status().InFlightStartAuctions }).Should(Equal(1))
+
+				process.Signal(syscall.SIGTERM)
+				close(done)
+			})
+
+			AfterEach(func() {
+				select {
+				case <-release:
+				default:
+					close(release)
+				}
+			})
+
+			It("should stop watching, but hold the lock until the running auction is over", func() {
+				Eventually(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+				Ω(auctioneer.Status().WatchingStartAuctions).Should(BeFalse())
+
+				Consistently(bbs.ReleaseLockChannel).ShouldNot(Receive())
+				Ω(auctioneer.HasLock()).Should(BeTrue())
+
+				close(release)
+				Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+			})
+
+			Context("when the running auction outlasts the drain timeout", func() {
+				var pool *simulation.RepPool
+				var tracker *algorithms.ReservationTracker
+
+				BeforeEach(func() {
+					pool = simulation.NewRepPool(map[string]auctiontypes.Resources{
+						"first-rep": {MemoryMB: 1024, DiskMB: 1024, Containers: 10},
+					})
+					tracker = algorithms.NewReservationTracker(pool)
+
+					config.DrainTimeout = 100 * time.Millisecond
+					config.Reservations = tracker
+
+					release, tracker := release, tracker
+					runner.RunLRPStartAuctionStub = func(request algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+						tracker.RebidThenTentativelyReserve([]string{"first-rep"}, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(request.LRPStartAuction))
+						<-release
+						tracker.Run("first-rep", request.LRPStartAuction)
+						return algorithms.StartAuctionResult{Winner: "first-rep"}, nil
+					}
+				})
+
+				It("should release the auction's reservations", func() {
+					Eventually(func() []auctiontypes.StartAuctionInfo { return pool.Reservations("first-rep") }).Should(BeEmpty())
+					Ω(logger.TestSink.Buffer).Should(gbytes.Say("drain-timed-out"))
+				})
+
+				It("should return the auction to pending, rather than leave it claimed", func() {
+					Eventually(bbs.GetRequeuedLRPStartAuctions).Should(Equal([]models.LRPStartAuction{startAuction}))
+					Ω(logger.TestSink.Buffer).Should(gbytes.Say("returned-to-pending"))
+				})
+
+				It("should not run the instance once the auction is abandoned", func() {
+					Eventually(bbs.GetRequeuedLRPStartAuctions).Should(HaveLen(1))
+					close(release)
+
+					Consistently(func() []auctiontypes.SimulatedInstance { return pool.SimulatedInstances("first-rep") }).Should(BeEmpty())
+					Ω(bbs.GetResolvedLRPStartAuction()).Should(Equal(models.LRPStartAuction{}))
+				})
+			})
+		})
+	})
+
+	Describe("caching executors", func() {
+		var auctionCount int
+
+		sendStartAuction := func() {
+			auctionCount++
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{
+				ProcessGuid:  "my-guid",
+				InstanceGuid: fmt.Sprintf("instance-%d", auctionCount),
+				Index:        auctionCount,
+				Stack:        "lucid64",
+			}
+			Eventually(runner.RunLRPStartAuctionCallCount).Should(Equal(auctionCount))
+		}
+
+		lastRepGuids := func() []string {
+			return runner.RunLRPStartAuctionArgsForCall(auctionCount - 1).RepGuids
+		}
+
+		BeforeEach(func() {
+			auctionCount = 0
+			runner = &fake_runner.FakeAuctionRunner{}
+			config.ExecutorRelistInterval = time.Hour
+		})
+
+		JustBeforeEach(func() {
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+
+			Eventually(auctioneer.ExecutorCacheStaleness).Should(BeZero())
+		})
+
+		AfterEach(func(done Done) {
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			Eventually(process.Wait()).Should(Receive())
+			Ω(bbs.ExecutorChangeStopChan).Should(BeClosed())
+
+			close(done)
+		})
+
+		It("should run auctions against the cached executors", func() {
+			bbs.Lock()
+			bbs.Executors = []models.ExecutorPresence{firstExecutor}
+			bbs.Unlock()
+
+			sendStartAuction()
+			Ω(lastRepGuids()).Should(ConsistOf("first-rep", "third-rep"))
+		})
+
+		It("should add executors that appear", func() {
+			bbs.ExecutorChangeChan <- models.ExecutorPresenceChange{
+				After: &models.ExecutorPresence{ExecutorID: "fourth-rep", Stack: "lucid64"},
+			}
+
+			Eventually(func() []string {
+				sendStartAuction()
+				return lastRepGuids()
+			}).Should(ConsistOf("first-rep", "third-rep", "fourth-rep"))
+		})
+
+		It("should remove executors that expire", func() {
+			bbs.ExecutorChangeChan <- models.ExecutorPresenceChange{
+				Before: &firstExecutor,
+			}
+
+			Eventually(func() []string {
+				sendStartAuction()
+				return lastRepGuids()
+			}).Should(ConsistOf("third-rep"))
+		})
+
+		It("should move executors whose stack changes", func() {
+			bbs.ExecutorChangeChan <- models.ExecutorPresenceChange{
+				Before: &secondExecutor,
+				After:  &models.ExecutorPresence{ExecutorID: "second-rep", Stack: "lucid64"},
+			}
+
+			Eventually(func() []string {
+				sendStartAuction()
+				return lastRepGuids()
+			}).Should(ConsistOf("first-rep", "second-rep", "third-rep"))
+		})
+
+		Context("with a short relist interval", func() {
+			BeforeEach(func() {
+				config.ExecutorRelistInterval = 50 * time.Millisecond
+			})
+
+			It("should pick up changes the watch missed", func() {
+				bbs.Lock()
+				bbs.Executors = []models.ExecutorPresence{firstExecutor}
+				bbs.Unlock()
+
+				Eventually(func() []string {
+					sendStartAuction()
+					return lastRepGuids()
+				}).Should(ConsistOf("first-rep"))
+			})
+		})
+
+		Context("when the watch fails", func() {
+			JustBeforeEach(func() {
+				bbs.Lock()
+				bbs.Executors = []models.ExecutorPresence{firstExecutor}
+				bbs.Unlock()
+
+				bbs.ExecutorChangeErrorChan <- errors.New("oops")
+			})
+
+			It("should report the cache as stale until it has watched and re-listed again", func() {
+				Eventually(auctioneer.ExecutorCacheStaleness).Should(BeNumerically(">", 0))
+				Eventually(auctioneer.ExecutorCacheStaleness).Should(BeZero())
+
+				sendStartAuction()
+				Ω(lastRepGuids()).Should(ConsistOf("first-rep"))
+			})
+		})
+	})
+
+	Describe("rate limiting many auctions", func() {
+		var startAuction1, startAuction2, startAuction3 models.LRPStartAuction
+
+		BeforeEach(func() {
+			runner = &fake_runner.FakeAuctionRunner{}
+			runner.RunLRPStartAuctionStub = func(auctionRequest algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+				time.Sleep(time.Second)
+				return algorithms.StartAuctionResult{}, nil
+			}
+
+			startAuction1 = models.LRPStartAuction{
+				ProcessGuid: "my-guid-1",
+				Stack:       "lucid64",
+			}
+			startAuction2 = models.LRPStartAuction{
+				ProcessGuid: "my-guid-2",
+				Stack:       "lucid64",
+			}
+			startAuction3 = models.LRPStartAuction{
+				ProcessGuid: "my-guid-3",
+				Stack:       "lucid64",
+			}
+		})
+
+		JustBeforeEach(func() {
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func() {
+			process.Signal(syscall.SIGTERM)
 			close(<-bbs.ReleaseLockChannel)
 			<-process.Wait()
 		})
 
-		It("should only process maxConcurrent auctions at a time", func() {
+		It("should only process maxConcurrent auctions at a time", func() {
+			bbs.LRPStartAuctionChan <- startAuction1
+			bbs.LRPStartAuctionChan <- startAuction2
+			bbs.LRPStartAuctionChan <- startAuction3
+
+			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(2))
+			Consistently(bbs.GetClaimedLRPStartAuctions, 0.5).Should(HaveLen(2))
+
+			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(3))
+		})
+
+		It("should count stop auctions against maxConcurrent", func() {
+			bbs.LRPStartAuctionChan <- startAuction1
+			bbs.LRPStartAuctionChan <- startAuction2
+			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(2))
+
+			bbs.LRPStopAuctionChan <- stopAuction
+			Consistently(bbs.GetClaimedLRPStopAuctions, 0.5).Should(BeEmpty())
+
+			Eventually(bbs.GetClaimedLRPStopAuctions).Should(HaveLen(1))
+		})
+
+		It("should report the number of queued auctions", func() {
+			Ω(auctioneer.QueueDepth()).Should(BeZero())
+
 			bbs.LRPStartAuctionChan <- startAuction1
 			bbs.LRPStartAuctionChan <- startAuction2
 			bbs.LRPStartAuctionChan <- startAuction3
 
-			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(2))
-			Consistently(bbs.GetClaimedLRPStartAuctions, 0.5).Should(HaveLen(2))
+			Eventually(auctioneer.QueueDepth).Should(Equal(1))
+			Eventually(auctioneer.QueueDepth, 3).Should(BeZero())
+		})
 
-			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(3))
+		It("should drop auctions that are already queued", func() {
+			bbs.LRPStartAuctionChan <- startAuction1
+			bbs.LRPStartAuctionChan <- startAuction2
+			bbs.LRPStartAuctionChan <- startAuction3
+			bbs.LRPStartAuctionChan <- startAuction3
+
+			Eventually(bbs.GetClaimedLRPStartAuctions, 3).Should(HaveLen(3))
+			Consistently(bbs.GetClaimedLRPStartAuctions, 1.5).Should(HaveLen(3))
+		})
+
+		Context("when the queue is full", func() {
+			BeforeEach(func() {
+				config.MaxQueuedAuctions = 1
+			})
+
+			JustBeforeEach(func() {
+				bbs.LRPStartAuctionChan <- startAuction1
+				bbs.LRPStartAuctionChan <- startAuction2
+				Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(2))
+
+				bbs.LRPStartAuctionChan <- startAuction3
+			})
+
+			It("should stop consuming the watch until there is room", func() {
+				sent := make(chan struct{})
+				go func() {
+					bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "my-guid-4", Stack: "lucid64"}
+					close(sent)
+				}()
+
+				Consistently(sent, 0.5).ShouldNot(BeClosed())
+				Eventually(sent, 3).Should(BeClosed())
+			})
+
+			It("should still see to the lock", func() {
+				sent := make(chan struct{})
+				go func() {
+					bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "my-guid-4", Stack: "lucid64"}
+					close(sent)
+				}()
+
+				Consistently(sent, 0.2).ShouldNot(BeClosed())
+
+				lost := make(chan struct{})
+				go func() {
+					bbs.LockChannel <- false
+					close(lost)
+				}()
+
+				Eventually(lost, 0.5).Should(BeClosed())
+				Eventually(bbs.LRPStartAuctionStopChan).Should(BeClosed())
+			})
+		})
+	})
+
+	Describe("reconfiguring a running auctioneer", func() {
+		//blockers run until their index is released
+		var releases []chan struct{}
+
+		BeforeEach(func() {
+			releases = []chan struct{}{}
+			for i := 0; i < 4; i++ {
+				releases = append(releases, make(chan struct{}))
+			}
+			releases := releases
+
+			runner = &fake_runner.FakeAuctionRunner{}
+			runner.RunLRPStartAuctionStub = func(auctionRequest algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+				if auctionRequest.LRPStartAuction.ProcessGuid == "blocker" {
+					<-releases[auctionRequest.LRPStartAuction.Index]
+				}
+				return algorithms.StartAuctionResult{}, nil
+			}
+
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func() {
+			for _, release := range releases {
+				select {
+				case <-release:
+				default:
+					close(release)
+				}
+			}
+
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			<-process.Wait()
+		})
+
+		It("should run start auctions with the new rules", func() {
+			newRules := algorithms.StartAuctionRules{
+				Algorithm:              "pick_best",
+				MaxRounds:              3,
+				MaxBiddingPoolFraction: 0.5,
+				MinBiddingPool:         1,
+			}
+
+			config.StartAuctionRules = newRules
+			auctioneer.Reconfigure(config)
+
+			bbs.LRPStartAuctionChan <- startAuction
+
+			Eventually(runner.RunLRPStartAuctionCallCount).ShouldNot(BeZero())
+			Ω(runner.RunLRPStartAuctionArgsForCall(0).Rules).Should(Equal(newRules))
+		})
+
+		It("should run more auctions at once when maxConcurrent is raised", func() {
+			for i := 0; i < 3; i++ {
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Index: i, Stack: "lucid64"}
+			}
+
+			Eventually(func() int { return auctioneer.Status().InFlightStartAuctions }).Should(Equal(2))
+			Consistently(func() int { return auctioneer.Status().InFlightStartAuctions }, 0.2).Should(Equal(2))
+
+			config.MaxConcurrent = 3
+			auctioneer.Reconfigure(config)
+
+			Eventually(func() int { return auctioneer.Status().InFlightStartAuctions }).Should(Equal(3))
+		})
+
+		It("should run fewer auctions at once, as running auctions finish, when maxConcurrent is lowered", func() {
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Index: 0, Stack: "lucid64"}
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Index: 1, Stack: "lucid64"}
+			Eventually(func() int { return auctioneer.Status().InFlightStartAuctions }).Should(Equal(2))
+
+			config.MaxConcurrent = 1
+			auctioneer.Reconfigure(config)
+			Ω(logger.TestSink.Buffer).Should(gbytes.Say("resizing"))
+
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Index: 2, Stack: "lucid64"}
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Index: 3, Stack: "lucid64"}
+			Eventually(auctioneer.QueueDepth).Should(Equal(2))
+
+			close(releases[0])
+			close(releases[1])
+
+			Eventually(auctioneer.QueueDepth).Should(Equal(1))
+			Consistently(func() int { return auctioneer.Status().InFlightStartAuctions }, 0.2).Should(Equal(1))
+			Ω(auctioneer.QueueDepth()).Should(Equal(1))
+		})
+	})
+
+	Describe("sharing workers between start and stop auctions", func() {
+		var lock *sync.Mutex
+		var ran []string
+
+		BeforeEach(func() {
+			lock = &sync.Mutex{}
+			ran = []string{}
+
+			config.MaxConcurrent = 1
+			config.StartAuctionShare = 3
+			config.StopAuctionShare = 1
+
+			runner = &fake_runner.FakeAuctionRunner{}
+			runner.RunLRPStartAuctionStub = func(auctionRequest algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+				if auctionRequest.LRPStartAuction.ProcessGuid == "blocker" {
+					time.Sleep(500 * time.Millisecond)
+				}
+
+				lock.Lock()
+				ran = append(ran, "start")
+				lock.Unlock()
+				return algorithms.StartAuctionResult{}, nil
+			}
+			runner.RunLRPStopAuctionStub = func(auctionRequest algorithms.StopAuctionRequest) (auctiontypes.StopAuctionResult, error) {
+				lock.Lock()
+				ran = append(ran, "stop")
+				lock.Unlock()
+				return auctiontypes.StopAuctionResult{}, nil
+			}
+
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func() {
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			<-process.Wait()
+		})
+
+		It("should interleave them according to their shares", func() {
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Stack: "lucid64"}
+			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(1))
+
+			for i := 0; i < 4; i++ {
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "start-guid", Index: i, Stack: "lucid64"}
+				bbs.LRPStopAuctionChan <- models.LRPStopAuction{ProcessGuid: "stop-guid", Index: i}
+			}
+
+			ranAuctions := func() []string {
+				lock.Lock()
+				defer lock.Unlock()
+				return ran
+			}
+
+			Eventually(ranAuctions, 2).Should(HaveLen(9))
+			Ω(ranAuctions()[1:5]).Should(Equal([]string{"start", "start", "stop", "start"}))
+		})
+	})
+
+	Describe("prioritizing start auctions", func() {
+		var lock *sync.Mutex
+		var ran []string
+
+		BeforeEach(func() {
+			lock = &sync.Mutex{}
+			ran = []string{}
+
+			config.MaxConcurrent = 1
+
+			bbs.Lock()
+			bbs.ActualLRPs = []models.ActualLRP{
+				{ProcessGuid: "healthy-guid", Index: 0, State: models.ActualLRPStateRunning},
+				{ProcessGuid: "crashed-guid", Index: 0, State: models.ActualLRPStateStarting},
+			}
+			bbs.Unlock()
+
+			runner = &fake_runner.FakeAuctionRunner{}
+			runner.RunLRPStartAuctionStub = func(auctionRequest algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+				startAuction := auctionRequest.LRPStartAuction
+				if startAuction.ProcessGuid == "blocker" {
+					time.Sleep(500 * time.Millisecond)
+					return algorithms.StartAuctionResult{}, nil
+				}
+
+				lock.Lock()
+				ran = append(ran, fmt.Sprintf("%s-%d", startAuction.ProcessGuid, startAuction.Index))
+				lock.Unlock()
+				return algorithms.StartAuctionResult{}, nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Stack: "lucid64"}
+			Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(1))
+
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "healthy-guid", Index: 5, Stack: "lucid64"}
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "crashed-guid", Index: 3, Stack: "lucid64"}
+			bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "healthy-guid", Index: 1, Stack: "lucid64"}
+		})
+
+		AfterEach(func() {
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			<-process.Wait()
+		})
+
+		ranAuctions := func() []string {
+			lock.Lock()
+			defer lock.Unlock()
+			return ran
+		}
+
+		It("should run auctions for processes with nothing running first, then by index", func() {
+			Eventually(ranAuctions, 2).Should(Equal([]string{"crashed-guid-3", "healthy-guid-1", "healthy-guid-5"}))
+		})
+
+		Context("with a custom priority", func() {
+			BeforeEach(func() {
+				priority, err := NewStartAuctionPriority("index")
+				Ω(err).ShouldNot(HaveOccurred())
+				config.StartAuctionPriority = priority
+			})
+
+			It("should run auctions in the order it dictates", func() {
+				Eventually(ranAuctions, 2).Should(Equal([]string{"healthy-guid-1", "crashed-guid-3", "healthy-guid-5"}))
+			})
+		})
+	})
+
+	Describe("counting the running instances of processes waiting for a worker", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+
+			bbs.Lock()
+			bbs.WhenGettingActualLRPsByProcessGuid = func(string) {
+				<-release
+			}
+			bbs.Unlock()
+
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func() {
+			close(release)
+
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			<-process.Wait()
+		})
+
+		It("should not hold up the watch while the BBS is asked", func() {
+			sent := make(chan struct{})
+			go func() {
+				bbs.LRPStartAuctionChan <- startAuction
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "other-guid", Stack: "lucid64"}
+				bbs.LRPStartAuctionChan <- startAuction
+				close(sent)
+			}()
+
+			Eventually(sent).Should(BeClosed())
+		})
+	})
+
+	Describe("sharing start auctions fairly between process guids", func() {
+		var lock *sync.Mutex
+		var ran []string
+
+		BeforeEach(func() {
+			lock = &sync.Mutex{}
+			ran = []string{}
+
+			priority, err := NewStartAuctionPriority("age")
+			Ω(err).ShouldNot(HaveOccurred())
+			config.StartAuctionPriority = priority
+
+			runner = &fake_runner.FakeAuctionRunner{}
+		})
+
+		JustBeforeEach(func() {
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func() {
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			<-process.Wait()
+		})
+
+		ranAuctions := func() []string {
+			lock.Lock()
+			defer lock.Unlock()
+			return ran
+		}
+
+		Context("when one process guid has many auctions waiting", func() {
+			BeforeEach(func() {
+				config.MaxConcurrent = 1
+
+				runner.RunLRPStartAuctionStub = func(auctionRequest algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+					startAuction := auctionRequest.LRPStartAuction
+					if startAuction.ProcessGuid == "blocker" {
+						time.Sleep(500 * time.Millisecond)
+						return algorithms.StartAuctionResult{}, nil
+					}
+
+					lock.Lock()
+					ran = append(ran, fmt.Sprintf("%s-%d", startAuction.ProcessGuid, startAuction.Index))
+					lock.Unlock()
+					return algorithms.StartAuctionResult{}, nil
+				}
+			})
+
+			It("should interleave the other process guids' auctions with it", func() {
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "blocker", Stack: "lucid64"}
+				Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(1))
+
+				updatedAt := int64(0)
+				send := func(processGuid string, index int) {
+					updatedAt++
+					bbs.LRPStartAuctionChan <- models.LRPStartAuction{
+						ProcessGuid: processGuid,
+						Index:       index,
+						Stack:       "lucid64",
+						UpdatedAt:   updatedAt,
+					}
+				}
+
+				for i := 0; i < 4; i++ {
+					send("big-guid", i)
+				}
+				send("small-guid", 0)
+				send("small-guid", 1)
+				send("tiny-guid", 0)
+
+				Eventually(ranAuctions, 2).Should(Equal([]string{
+					"big-guid-0", "small-guid-0", "tiny-guid-0",
+					"big-guid-1", "small-guid-1",
+					"big-guid-2",
+					"big-guid-3",
+				}))
+			})
+		})
+
+		Context("with a per-process concurrency cap", func() {
+			BeforeEach(func() {
+				config.MaxConcurrent = 3
+				config.MaxConcurrentPerProcess = 1
+
+				runner.RunLRPStartAuctionStub = func(auctionRequest algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+					time.Sleep(300 * time.Millisecond)
+					return algorithms.StartAuctionResult{}, nil
+				}
+			})
+
+			It("should not run more than the cap for a process guid, leaving workers for others", func() {
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "big-guid", Index: 0, Stack: "lucid64"}
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "big-guid", Index: 1, Stack: "lucid64"}
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "big-guid", Index: 2, Stack: "lucid64"}
+				bbs.LRPStartAuctionChan <- models.LRPStartAuction{ProcessGuid: "small-guid", Index: 0, Stack: "lucid64"}
+
+				Eventually(bbs.GetClaimedLRPStartAuctions).Should(HaveLen(2))
+				Consistently(bbs.GetClaimedLRPStartAuctions, 0.2).Should(HaveLen(2))
+
+				claimedGuids := []string{}
+				for _, claimed := range bbs.GetClaimedLRPStartAuctions() {
+					claimedGuids = append(claimedGuids, claimed.ProcessGuid)
+				}
+				Ω(claimedGuids).Should(ConsistOf("big-guid", "small-guid"))
+
+				Eventually(bbs.GetClaimedLRPStartAuctions, 2).Should(HaveLen(4))
+			})
 		})
 	})
 
 	Describe("the stop auction lifecycle", func() {
 		BeforeEach(func() {
-			runner = &fake_auctionrunner.FakeAuctionRunner{}
-			auctioneer = New(bbs, runner, 2, MAX_AUCTION_ROUNDS_FOR_TEST, time.Second, logger)
+			runner = &fake_runner.FakeAuctionRunner{}
+			auctioneer = New(bbs, runner, config, logger)
 
 			go func() {
 				bbs.LockChannel <- true
@@ -444,4 +2574,101 @@ var _ = Describe("Auctioneer", func() {
 			})
 		})
 	})
+
+	Describe("metrics", func() {
+		var registry *metrics.Registry
+
+		scrape := func() string {
+			buffer := &bytes.Buffer{}
+			registry.WriteTo(buffer)
+			return buffer.String()
+		}
+
+		BeforeEach(func() {
+			registry = metrics.NewRegistry()
+			config.Metrics = registry
+
+			runner = &fake_runner.FakeAuctionRunner{}
+			runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{
+				Winner:            "first-rep",
+				NumRounds:         2,
+				NumCommunications: 7,
+				BiddingDuration:   30 * time.Millisecond,
+			}, nil)
+		})
+
+		JustBeforeEach(func() {
+			auctioneer = New(bbs, runner, config, logger)
+
+			go func() {
+				bbs.LockChannel <- true
+			}()
+
+			process = ifrit.Envoke(auctioneer)
+		})
+
+		AfterEach(func(done Done) {
+			process.Signal(syscall.SIGTERM)
+			close(<-bbs.ReleaseLockChannel)
+			Eventually(process.Wait()).Should(Receive())
+
+			close(done)
+		})
+
+		It("should report holding the lock", func() {
+			Eventually(scrape).Should(ContainSubstring("auctioneer_lock_held 1\n"))
+		})
+
+		It("should count start auctions that succeed, by stack, with their rounds and communications", func() {
+			bbs.LRPStartAuctionChan <- startAuction
+			Eventually(bbs.GetResolvedLRPStartAuction).Should(Equal(startAuction))
+
+			Eventually(scrape).Should(ContainSubstring(`auctioneer_start_auctions_succeeded_total{stack="lucid64"} 1`))
+			Ω(scrape()).Should(ContainSubstring(`auctioneer_start_auctions_started_total{stack="lucid64"} 1`))
+			Ω(scrape()).Should(ContainSubstring(`auctioneer_start_auction_rounds_sum{stack="lucid64"} 2`))
+			Ω(scrape()).Should(ContainSubstring(`auctioneer_auction_communications_sum{kind="start"} 7`))
+			Ω(scrape()).Should(ContainSubstring(`auctioneer_auction_bidding_duration_seconds_sum{kind="start"} 0.03`))
+			Ω(scrape()).Should(ContainSubstring(`auctioneer_auction_queue_wait_seconds_count{kind="start"} 1`))
+		})
+
+		It("should count start auctions that fail, by stack and reason", func() {
+			runner.RunLRPStartAuctionReturns(algorithms.StartAuctionResult{}, auctiontypes.InsufficientResources)
+
+			bbs.LRPStartAuctionChan <- startAuction
+
+			Eventually(scrape).Should(ContainSubstring(`auctioneer_start_auctions_failed_total{stack="lucid64",reason="insufficient-resources"} 1`))
+		})
+
+		It("should count stop auctions", func() {
+			bbs.LRPStopAuctionChan <- stopAuction
+
+			Eventually(scrape).Should(ContainSubstring("auctioneer_stop_auctions_succeeded_total 1\n"))
+			Ω(scrape()).Should(ContainSubstring(`auctioneer_auction_queue_wait_seconds_count{kind="stop"} 1`))
+		})
+
+		Context("while an auction is running", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				config.MaxConcurrent = 4
+
+				release = make(chan struct{})
+				runner.RunLRPStartAuctionStub = func(algorithms.StartAuctionRequest) (algorithms.StartAuctionResult, error) {
+					<-release
+					return algorithms.StartAuctionResult{Winner: "first-rep"}, nil
+				}
+			})
+
+			It("should report it in flight, and the workers it is using", func() {
+				bbs.LRPStartAuctionChan <- startAuction
+
+				Eventually(scrape).Should(ContainSubstring(`auctioneer_auctions_in_flight{kind="start"} 1`))
+				Ω(scrape()).Should(ContainSubstring("auctioneer_worker_utilization 0.25\n"))
+
+				close(release)
+
+				Eventually(scrape).Should(ContainSubstring(`auctioneer_auctions_in_flight{kind="start"} 0`))
+			})
+		})
+	})
 })
@@ -0,0 +1,409 @@
+package auctioneer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	"github.com/cloudfoundry-incubator/auctioneer/breaker"
+)
+
+/*
+
+ConfigFile holds every setting of the auctioneer.  Each setting has the same
+name in the file as the flag that sets it, and the file, when there is one,
+overrides the flags:
+
+	{
+		"maxConcurrent": 40,
+		"natsAuctionTimeout": "2s",
+		"stackStartAuctionRules": {"lucid64": {"algorithm": "pick_best"}},
+		"stacks": {"aliases": {"lucid": "lucid64"}}
+	}
+
+Settings tagged `reload:"restart"` are read once, at start up; the rest can be
+changed while the auctioneer runs by reloading the file.
+
+*/
+
+type ConfigFile struct {
+	EtcdCluster   string `json:"etcdCluster" reload:"restart"`
+	NATSAddresses string `json:"natsAddresses" reload:"restart"`
+	NATSUsername  string `json:"natsUsername" reload:"restart"`
+	NATSPassword  string `json:"natsPassword" reload:"restart" secret:"true"`
+
+	MaxConcurrent           int    `json:"maxConcurrent"`
+	MaxQueuedAuctions       int    `json:"maxQueuedAuctions"`
+	StartAuctionShare       int    `json:"startAuctionShare"`
+	StopAuctionShare        int    `json:"stopAuctionShare"`
+	MaxConcurrentPerProcess int    `json:"maxConcurrentPerProcess"`
+	StartAuctionPriority    string `json:"startAuctionPriority"`
+
+	MaxRounds              int     `json:"maxRounds"`
+	AuctionAlgorithm       string  `json:"auctionAlgorithm"`
+	MaxBiddingPoolFraction float64 `json:"maxBiddingPoolFraction"`
+	MinBiddingPool         int     `json:"minBiddingPool"`
+	NumChoices             int     `json:"numChoices"`
+	Placement              string  `json:"placement"`
+	AntiAffinity           string  `json:"antiAffinity"`
+	StackStartAuctionRules JSON    `json:"stackStartAuctionRules"`
+
+	StartAuctionMaxAttempts     int      `json:"startAuctionMaxAttempts"`
+	StartAuctionRetryMinBackoff Duration `json:"startAuctionRetryMinBackoff"`
+	StartAuctionRetryMaxBackoff Duration `json:"startAuctionRetryMaxBackoff"`
+	StartAuctionRetryDeadline   Duration `json:"startAuctionRetryDeadline"`
+	FailedStartAuctionTTL       Duration `json:"failedStartAuctionTTL"`
+
+	NATSAuctionTimeout Duration `json:"natsAuctionTimeout"`
+	RunAuctionTimeout  Duration `json:"runAuctionTimeout"`
+	DrainTimeout       Duration `json:"drainTimeout"`
+
+	ExecutorDrainInterval           Duration `json:"executorDrainInterval"`
+	ExecutorDrainReplacementTimeout Duration `json:"executorDrainReplacementTimeout"`
+
+	RebalanceInterval           Duration `json:"rebalanceInterval" reload:"restart"`
+	RebalanceThreshold          float64  `json:"rebalanceThreshold"`
+	RebalanceMaxMoves           int      `json:"rebalanceMaxMoves"`
+	RebalanceMaxUnavailable     int      `json:"rebalanceMaxUnavailable"`
+	RebalanceReplacementTimeout Duration `json:"rebalanceReplacementTimeout"`
+	RebalanceDryRun             bool     `json:"rebalanceDryRun"`
+
+	RepBreakerOpenFor     Duration `json:"repBreakerOpenFor" reload:"restart"`
+	RepBreakerWindow      int      `json:"repBreakerWindow" reload:"restart"`
+	RepBreakerMinRequests int      `json:"repBreakerMinRequests" reload:"restart"`
+	RepBreakerFailureRate float64  `json:"repBreakerFailureRate" reload:"restart"`
+
+	ExecutorRelistInterval Duration `json:"executorRelistInterval" reload:"restart"`
+	MetricsAddress         string   `json:"metricsAddress" reload:"restart"`
+	HealthAddress          string   `json:"healthAddress" reload:"restart"`
+	AdminAddress           string   `json:"adminAddress" reload:"restart"`
+	AdminSecret            string   `json:"adminSecret" reload:"restart" secret:"true"`
+	LockInterval           Duration `json:"lockInterval" reload:"restart"`
+
+	// which executors' stacks can run apps asking for which stacks; too
+	// structured for a flag
+	Stacks StackCompatibility `json:"stacks"`
+}
+
+// LoadConfigFile reads the settings in the file over the defaults, and
+// validates the result
+func LoadConfigFile(path string, defaults ConfigFile) (ConfigFile, error) {
+	configFile := defaults
+
+	payload, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	err = checkSettings(payload)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	err = json.Unmarshal(payload, &configFile)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	err = configFile.Validate()
+	if err != nil {
+		return ConfigFile{}, err
+	}
+
+	return configFile, nil
+}
+
+// checkSettings rejects settings that no field of ConfigFile reads, matched
+// without regard to case as the json package does
+func checkSettings(payload []byte) error {
+	settings := map[string]json.RawMessage{}
+	err := json.Unmarshal(payload, &settings)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	fields := reflect.TypeOf(ConfigFile{})
+	for i := 0; i < fields.NumField(); i++ {
+		name := strings.Split(fields.Field(i).Tag.Get("json"), ",")[0]
+		known[strings.ToLower(name)] = true
+	}
+
+	for setting := range settings {
+		if !known[strings.ToLower(setting)] {
+			return fmt.Errorf("unknown setting '%s'", setting)
+		}
+	}
+
+	return nil
+}
+
+func (c ConfigFile) Validate() error {
+	_, err := c.AuctioneerConfig()
+	if err != nil {
+		return err
+	}
+
+	if c.NATSAuctionTimeout <= 0 {
+		return fmt.Errorf("nats auction timeout must be positive, got %s", c.NATSAuctionTimeout)
+	}
+
+	if c.RunAuctionTimeout <= 0 {
+		return fmt.Errorf("run auction timeout must be positive, got %s", c.RunAuctionTimeout)
+	}
+
+	if c.AdminAddress != "" && c.AdminSecret == "" {
+		return errors.New("an admin secret is required to serve the admin endpoints")
+	}
+
+	if c.RepBreakerOpenFor < 0 || c.RepBreakerWindow < 0 || c.RepBreakerMinRequests < 0 {
+		return fmt.Errorf("rep breaker open for, window and min requests can't be negative, got %s, %d and %d", c.RepBreakerOpenFor, c.RepBreakerWindow, c.RepBreakerMinRequests)
+	}
+
+	if c.RepBreakerFailureRate < 0 || c.RepBreakerFailureRate > 1 {
+		return fmt.Errorf("rep breaker failure rate must be between 0 and 1, got %g", c.RepBreakerFailureRate)
+	}
+
+	return nil
+}
+
+// RepBreakerConfig is when the rep breakers open, and for how long; they
+// aren't used if RepBreakerOpenFor is 0
+func (c ConfigFile) RepBreakerConfig() breaker.Config {
+	return breaker.Config{
+		Window:      c.RepBreakerWindow,
+		MinRequests: c.RepBreakerMinRequests,
+		FailureRate: c.RepBreakerFailureRate,
+		OpenFor:     time.Duration(c.RepBreakerOpenFor),
+	}
+}
+
+// AuctioneerConfig validates the settings that the Auctioneer runs with and
+// turns them into its Config.  The Metrics, Reservations, RepPool and
+// RepBreakers are left for the caller.
+func (c ConfigFile) AuctioneerConfig() (Config, error) {
+	if c.MaxConcurrent < 1 {
+		return Config{}, fmt.Errorf("max concurrent must be at least 1, got %d", c.MaxConcurrent)
+	}
+
+	if c.MaxQueuedAuctions < 1 {
+		return Config{}, fmt.Errorf("max queued auctions must be at least 1, got %d", c.MaxQueuedAuctions)
+	}
+
+	if c.StartAuctionShare < 1 || c.StopAuctionShare < 1 {
+		return Config{}, fmt.Errorf("auction shares must be at least 1, got %d (start) and %d (stop)", c.StartAuctionShare, c.StopAuctionShare)
+	}
+
+	priority, err := NewStartAuctionPriority(strings.Split(c.StartAuctionPriority, ",")...)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid start auction priority: %s", err.Error())
+	}
+
+	rules := algorithms.StartAuctionRules{
+		Algorithm:              c.AuctionAlgorithm,
+		MaxRounds:              c.MaxRounds,
+		MaxBiddingPoolFraction: c.MaxBiddingPoolFraction,
+		MinBiddingPool:         c.MinBiddingPool,
+		NumChoices:             c.NumChoices,
+		Placement:              c.Placement,
+	}
+
+	err = ValidateStartAuctionRules(rules)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid start auction rules: %s", err.Error())
+	}
+
+	stackRules, err := ParseStackStartAuctionRules(rules, string(c.StackStartAuctionRules))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid stack start auction rules: %s", err.Error())
+	}
+
+	//packing favours the fullest reps, which are those the rebalancer moves
+	//instances off, so the two would undo each other's work
+	if c.RebalanceInterval > 0 {
+		if rules.Placement == algorithms.PlacementPack {
+			return Config{}, fmt.Errorf("rebalancing can't be combined with pack placement")
+		}
+
+		for stack, stackRule := range stackRules {
+			if stackRule.Placement == algorithms.PlacementPack {
+				return Config{}, fmt.Errorf("rebalancing can't be combined with pack placement, which stack '%s' uses", stack)
+			}
+		}
+	}
+
+	err = ValidateAntiAffinity(c.AntiAffinity)
+	if err != nil {
+		return Config{}, err
+	}
+
+	retryPolicy := RetryPolicy{
+		MaxAttempts: c.StartAuctionMaxAttempts,
+		MinBackoff:  time.Duration(c.StartAuctionRetryMinBackoff),
+		MaxBackoff:  time.Duration(c.StartAuctionRetryMaxBackoff),
+		Deadline:    time.Duration(c.StartAuctionRetryDeadline),
+	}
+
+	err = ValidateRetryPolicy(retryPolicy)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid start auction retry policy: %s", err.Error())
+	}
+
+	if c.ExecutorDrainInterval < 0 || c.ExecutorDrainReplacementTimeout < 0 {
+		return Config{}, fmt.Errorf("executor drain interval and replacement timeout can't be negative, got %s and %s", c.ExecutorDrainInterval, c.ExecutorDrainReplacementTimeout)
+	}
+
+	if c.RebalanceInterval < 0 || c.RebalanceReplacementTimeout < 0 {
+		return Config{}, fmt.Errorf("rebalance interval and replacement timeout can't be negative, got %s and %s", c.RebalanceInterval, c.RebalanceReplacementTimeout)
+	}
+
+	if c.RebalanceThreshold < 0 || c.RebalanceThreshold >= 1 {
+		return Config{}, fmt.Errorf("rebalance threshold must be at least 0 and less than 1, got %g", c.RebalanceThreshold)
+	}
+
+	if c.RebalanceMaxMoves < 0 || c.RebalanceMaxUnavailable < 0 {
+		return Config{}, fmt.Errorf("rebalance max moves and max unavailable can't be negative, got %d and %d", c.RebalanceMaxMoves, c.RebalanceMaxUnavailable)
+	}
+
+	err = c.Stacks.Validate()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid stacks: %s", err.Error())
+	}
+
+	return Config{
+		MaxConcurrent:           c.MaxConcurrent,
+		MaxQueuedAuctions:       c.MaxQueuedAuctions,
+		StartAuctionShare:       c.StartAuctionShare,
+		StopAuctionShare:        c.StopAuctionShare,
+		MaxConcurrentPerProcess: c.MaxConcurrentPerProcess,
+		StartAuctionPriority:    priority,
+		StartAuctionRules:       rules,
+		StackStartAuctionRules:  stackRules,
+		Stacks:                  c.Stacks,
+		AntiAffinity:            c.AntiAffinity,
+		StartAuctionRetry:       retryPolicy,
+		FailedStartAuctionTTL:   time.Duration(c.FailedStartAuctionTTL),
+		ExecutorRelistInterval:  time.Duration(c.ExecutorRelistInterval),
+		DrainTimeout:            time.Duration(c.DrainTimeout),
+		LockInterval:            time.Duration(c.LockInterval),
+
+		ExecutorDrainInterval:           time.Duration(c.ExecutorDrainInterval),
+		ExecutorDrainReplacementTimeout: time.Duration(c.ExecutorDrainReplacementTimeout),
+
+		RebalanceInterval:           time.Duration(c.RebalanceInterval),
+		RebalanceThreshold:          c.RebalanceThreshold,
+		RebalanceMaxMoves:           c.RebalanceMaxMoves,
+		RebalanceMaxUnavailable:     c.RebalanceMaxUnavailable,
+		RebalanceReplacementTimeout: time.Duration(c.RebalanceReplacementTimeout),
+		RebalanceDryRun:             c.RebalanceDryRun,
+	}, nil
+}
+
+// ConfigChange is a setting that differs between two ConfigFiles
+type ConfigChange struct {
+	Setting string      `json:"setting"`
+	From    interface{} `json:"from"`
+	To      interface{} `json:"to"`
+
+	// the change is ignored until the auctioneer is restarted
+	RequiresRestart bool `json:"requires_restart"`
+}
+
+// the value of secret settings is never shown
+const redacted = "[redacted]"
+
+// Reload returns the settings to run with once next is loaded, which are
+// next's apart from the settings that can't change until a restart, and what
+// changed in next
+func (c ConfigFile) Reload(next ConfigFile) (ConfigFile, []ConfigChange) {
+	reloaded := next
+	changes := []ConfigChange{}
+
+	current := reflect.ValueOf(c)
+	nextValue := reflect.ValueOf(next)
+	reloadedValue := reflect.ValueOf(&reloaded).Elem()
+	fields := current.Type()
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		from, to := current.Field(i).Interface(), nextValue.Field(i).Interface()
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+
+		change := ConfigChange{
+			Setting:         strings.Split(field.Tag.Get("json"), ",")[0],
+			From:            from,
+			To:              to,
+			RequiresRestart: field.Tag.Get("reload") == "restart",
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			change.From, change.To = redacted, redacted
+		}
+
+		if change.RequiresRestart {
+			reloadedValue.Field(i).Set(current.Field(i))
+		}
+
+		changes = append(changes, change)
+	}
+
+	return reloaded, changes
+}
+
+// Duration is a time.Duration written in config files as a string, e.g.
+// "1m30s"
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(payload []byte) error {
+	var value string
+	err := json.Unmarshal(payload, &value)
+	if err != nil {
+		return errors.New("durations must be strings, e.g. \"10s\"")
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+	return nil
+}
+
+// JSON is a setting that is itself a JSON document.  A flag can only give it
+// as a string, but a config file may also give it inline.
+type JSON string
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if j == "" {
+		return []byte(`""`), nil
+	}
+
+	return []byte(j), nil
+}
+
+func (j *JSON) UnmarshalJSON(payload []byte) error {
+	var value string
+	if json.Unmarshal(payload, &value) == nil {
+		*j = JSON(value)
+		return nil
+	}
+
+	*j = JSON(payload)
+	return nil
+}
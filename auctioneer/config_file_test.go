@@ -0,0 +1,269 @@
+package auctioneer_test
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	. "github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config file", func() {
+	var defaults ConfigFile
+
+	BeforeEach(func() {
+		defaults = ConfigFile{
+			EtcdCluster:   "http://127.0.0.1:4001",
+			NATSAddresses: "127.0.0.1:4222",
+			NATSUsername:  "nats",
+			NATSPassword:  "nats",
+
+			MaxConcurrent:        20,
+			MaxQueuedAuctions:    1000,
+			StartAuctionShare:    3,
+			StopAuctionShare:     1,
+			StartAuctionPriority: "no-running-instances,index,age",
+
+			MaxRounds:              10,
+			AuctionAlgorithm:       "reserve_n_best",
+			MaxBiddingPoolFraction: 0.2,
+			MinBiddingPool:         5,
+			AntiAffinity:           "none",
+
+			StartAuctionMaxAttempts:     5,
+			StartAuctionRetryMinBackoff: Duration(time.Second),
+			StartAuctionRetryMaxBackoff: Duration(30 * time.Second),
+
+			NATSAuctionTimeout: Duration(time.Second),
+			RunAuctionTimeout:  Duration(10 * time.Second),
+			LockInterval:       Duration(30 * time.Second),
+		}
+	})
+
+	Describe("LoadConfigFile", func() {
+		var path string
+
+		writeConfigFile := func(payload string) {
+			file, err := ioutil.TempFile("", "auctioneer-config")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer file.Close()
+
+			_, err = file.WriteString(payload)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			path = file.Name()
+		}
+
+		AfterEach(func() {
+			os.Remove(path)
+		})
+
+		It("should load the stack compatibility", func() {
+			writeConfigFile(`{"stacks": {"aliases": {"lucid": "lucid64"}, "compatible": {"lucid64": [["cflinuxfs2"]]}}}`)
+
+			configFile, err := LoadConfigFile(path, defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(configFile.Stacks.Tiers("lucid")).Should(Equal([][]string{{"lucid64"}, {"cflinuxfs2"}}))
+		})
+
+		It("should override the defaults with the settings it has, and keep the rest", func() {
+			writeConfigFile(`{"maxConcurrent": 40, "natsAuctionTimeout": "2s", "auctionAlgorithm": "pick_best"}`)
+
+			configFile, err := LoadConfigFile(path, defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(configFile.MaxConcurrent).Should(Equal(40))
+			Ω(configFile.NATSAuctionTimeout).Should(Equal(Duration(2 * time.Second)))
+			Ω(configFile.AuctionAlgorithm).Should(Equal("pick_best"))
+			Ω(configFile.MaxQueuedAuctions).Should(Equal(1000))
+			Ω(configFile.RunAuctionTimeout).Should(Equal(Duration(10 * time.Second)))
+		})
+
+		It("should take the stack start auction rules either inline or as a string", func() {
+			writeConfigFile(`{"stackStartAuctionRules": {"lucid64": {"maxRounds": 3}}}`)
+			inline, err := LoadConfigFile(path, defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			writeConfigFile(`{"stackStartAuctionRules": "{\"lucid64\": {\"maxRounds\": 3}}"}`)
+			quoted, err := LoadConfigFile(path, defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			for _, configFile := range []ConfigFile{inline, quoted} {
+				config, err := configFile.AuctioneerConfig()
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(config.StackStartAuctionRules["lucid64"].MaxRounds).Should(Equal(3))
+				Ω(config.StackStartAuctionRules["lucid64"].Algorithm).Should(Equal("reserve_n_best"))
+			}
+		})
+
+		It("should reject malformed files", func() {
+			writeConfigFile(`{"stacks": `)
+
+			_, err := LoadConfigFile(path, defaults)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should reject unknown settings", func() {
+			writeConfigFile(`{"maxConcurent": 40}`)
+
+			_, err := LoadConfigFile(path, defaults)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should match settings without regard to case, as it reads them", func() {
+			writeConfigFile(`{"MaxConcurrent": 40}`)
+
+			configFile, err := LoadConfigFile(path, defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(configFile.MaxConcurrent).Should(Equal(40))
+		})
+
+		It("should reject durations that aren't strings", func() {
+			writeConfigFile(`{"drainTimeout": 10}`)
+
+			_, err := LoadConfigFile(path, defaults)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should reject invalid settings", func() {
+			for _, payload := range []string{
+				`{"maxConcurrent": 0}`,
+				`{"auctionAlgorithm": "reserve_n_bets"}`,
+				`{"antiAffinity": "sometimes"}`,
+				`{"startAuctionPriority": "size"}`,
+				`{"stackStartAuctionRules": {"lucid64": {"maxRounds": 0}}}`,
+				`{"natsAuctionTimeout": "0s"}`,
+				`{"adminAddress": "127.0.0.1:8090"}`,
+				`{"executorDrainInterval": "-1s"}`,
+				`{"rebalanceThreshold": 1.5}`,
+				`{"rebalanceMaxUnavailable": -1}`,
+				`{"repBreakerFailureRate": 2}`,
+			} {
+				writeConfigFile(payload)
+
+				_, err := LoadConfigFile(path, defaults)
+				Ω(err).Should(HaveOccurred(), payload)
+			}
+		})
+
+		It("should reject invalid stack compatibility", func() {
+			writeConfigFile(`{"stacks": {"aliases": {"a": "b", "b": "c"}}}`)
+
+			_, err := LoadConfigFile(path, defaults)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should serve the admin endpoints only with a secret", func() {
+			writeConfigFile(`{"adminAddress": "127.0.0.1:8090", "adminSecret": "s3cret"}`)
+
+			configFile, err := LoadConfigFile(path, defaults)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(configFile.AdminAddress).Should(Equal("127.0.0.1:8090"))
+			Ω(configFile.AdminSecret).Should(Equal("s3cret"))
+		})
+
+		It("should fail when the file is missing", func() {
+			path = "/does/not/exist"
+
+			_, err := LoadConfigFile(path, defaults)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("AuctioneerConfig", func() {
+		It("should turn the settings into the auctioneer's config", func() {
+			defaults.DrainTimeout = Duration(5 * time.Second)
+
+			config, err := defaults.AuctioneerConfig()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(config.MaxConcurrent).Should(Equal(20))
+			Ω(config.StartAuctionRules).Should(Equal(algorithms.StartAuctionRules{
+				Algorithm:              "reserve_n_best",
+				MaxRounds:              10,
+				MaxBiddingPoolFraction: 0.2,
+				MinBiddingPool:         5,
+			}))
+			Ω(config.StartAuctionRetry.MaxAttempts).Should(Equal(5))
+			Ω(config.DrainTimeout).Should(Equal(5 * time.Second))
+			Ω(config.StartAuctionPriority).ShouldNot(BeNil())
+		})
+
+		Context("when rebalancing", func() {
+			BeforeEach(func() {
+				defaults.RebalanceInterval = Duration(time.Minute)
+				defaults.AuctionAlgorithm = "bin_pack"
+			})
+
+			It("should reject pack placement", func() {
+				defaults.Placement = "pack"
+
+				_, err := defaults.AuctioneerConfig()
+				Ω(err).Should(MatchError("rebalancing can't be combined with pack placement"))
+			})
+
+			It("should reject pack placement for a stack", func() {
+				defaults.StackStartAuctionRules = JSON(`{"lucid64": {"placement": "pack"}}`)
+
+				_, err := defaults.AuctioneerConfig()
+				Ω(err).Should(MatchError("rebalancing can't be combined with pack placement, which stack 'lucid64' uses"))
+			})
+
+			It("should accept spread placement", func() {
+				defaults.Placement = "spread"
+
+				_, err := defaults.AuctioneerConfig()
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Reload", func() {
+		var next ConfigFile
+
+		BeforeEach(func() {
+			next = defaults
+		})
+
+		It("should report nothing when nothing changed", func() {
+			reloaded, changes := defaults.Reload(next)
+			Ω(reloaded).Should(Equal(defaults))
+			Ω(changes).Should(BeEmpty())
+		})
+
+		It("should report what changed", func() {
+			next.MaxConcurrent = 40
+			next.NATSAuctionTimeout = Duration(2 * time.Second)
+
+			reloaded, changes := defaults.Reload(next)
+			Ω(reloaded).Should(Equal(next))
+			Ω(changes).Should(ConsistOf(
+				ConfigChange{Setting: "maxConcurrent", From: 20, To: 40},
+				ConfigChange{Setting: "natsAuctionTimeout", From: Duration(time.Second), To: Duration(2 * time.Second)},
+			))
+		})
+
+		It("should keep the settings that can't change without a restart", func() {
+			next.EtcdCluster = "http://10.0.0.1:4001"
+			next.LockInterval = Duration(time.Minute)
+
+			reloaded, changes := defaults.Reload(next)
+			Ω(reloaded).Should(Equal(defaults))
+			Ω(changes).Should(ConsistOf(
+				ConfigChange{Setting: "etcdCluster", From: "http://127.0.0.1:4001", To: "http://10.0.0.1:4001", RequiresRestart: true},
+				ConfigChange{Setting: "lockInterval", From: Duration(30 * time.Second), To: Duration(time.Minute), RequiresRestart: true},
+			))
+		})
+
+		It("should not reveal secrets", func() {
+			next.NATSPassword = "hunter2"
+
+			_, changes := defaults.Reload(next)
+			Ω(changes).Should(HaveLen(1))
+			Ω(changes[0].From).ShouldNot(Equal("nats"))
+			Ω(changes[0].To).ShouldNot(Equal("hunter2"))
+		})
+	})
+})
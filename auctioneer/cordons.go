@@ -0,0 +1,196 @@
+package auctioneer
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+// ErrExecutorNotCordoned is returned when asked to drain an executor that
+// start auctions could still place the instances back on
+var ErrExecutorNotCordoned = errors.New("the executor is not cordoned")
+
+/*
+
+cordons keeps the cordoned executors in memory so that auctions needn't each
+list them from etcd.  They are re-read from etcd every time the lock is
+renewed, and changed along with etcd by the auctioneer that holds the lock.
+
+Start auctions leave cordoned executors out, and stop auctions keep the
+instance they leave running off them where they can.
+
+*/
+
+type cordons struct {
+	lock      *sync.RWMutex
+	executors map[string]models.CordonedExecutor
+}
+
+func newCordons() *cordons {
+	return &cordons{
+		lock:      &sync.RWMutex{},
+		executors: map[string]models.CordonedExecutor{},
+	}
+}
+
+func (c *cordons) set(cordoned []models.CordonedExecutor) {
+	executors := map[string]models.CordonedExecutor{}
+	for _, cordon := range cordoned {
+		executors[cordon.ExecutorID] = cordon
+	}
+
+	c.lock.Lock()
+	c.executors = executors
+	c.lock.Unlock()
+}
+
+func (c *cordons) add(cordon models.CordonedExecutor) {
+	c.lock.Lock()
+	c.executors[cordon.ExecutorID] = cordon
+	c.lock.Unlock()
+}
+
+func (c *cordons) remove(executorID string) {
+	c.lock.Lock()
+	delete(c.executors, executorID)
+	c.lock.Unlock()
+}
+
+func (c *cordons) has(executorID string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	_, ok := c.executors[executorID]
+	return ok
+}
+
+// list is ordered by executor
+func (c *cordons) list() []models.CordonedExecutor {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	cordoned := []models.CordonedExecutor{}
+	for _, cordon := range c.executors {
+		cordoned = append(cordoned, cordon)
+	}
+
+	sort.Sort(byExecutorID(cordoned))
+
+	return cordoned
+}
+
+func (c *cordons) guids() []string {
+	cordoned := c.list()
+
+	guids := make([]string, 0, len(cordoned))
+	for _, cordon := range cordoned {
+		guids = append(guids, cordon.ExecutorID)
+	}
+
+	return guids
+}
+
+// exclude returns the executors that aren't cordoned
+func (c *cordons) exclude(executors []models.ExecutorPresence) []models.ExecutorPresence {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if len(c.executors) == 0 {
+		return executors
+	}
+
+	uncordoned := []models.ExecutorPresence{}
+	for _, executor := range executors {
+		if _, ok := c.executors[executor.ExecutorID]; !ok {
+			uncordoned = append(uncordoned, executor)
+		}
+	}
+
+	return uncordoned
+}
+
+type byExecutorID []models.CordonedExecutor
+
+func (s byExecutorID) Len() int           { return len(s) }
+func (s byExecutorID) Less(i, j int) bool { return s[i].ExecutorID < s[j].ExecutorID }
+func (s byExecutorID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Cordoned returns the executors that new instances are kept off
+func (a *Auctioneer) Cordoned() []models.CordonedExecutor {
+	return a.cordons.list()
+}
+
+// Cordon keeps new instances off the executor.  Instances already on it keep
+// running until it is drained.
+func (a *Auctioneer) Cordon(executorID string) error {
+	if !a.HasLock() {
+		return ErrLockNotHeld
+	}
+
+	cordon := models.CordonedExecutor{
+		ExecutorID: executorID,
+		CordonedAt: time.Now().UnixNano(),
+	}
+
+	err := a.bbs.CordonExecutor(cordon)
+	if err != nil {
+		a.logger.Error("failed-to-cordon", err, lager.Data{"executor-id": executorID})
+		return err
+	}
+
+	a.cordons.add(cordon)
+	a.logger.Info("cordoned", lager.Data{"executor-id": executorID})
+
+	return nil
+}
+
+// Uncordon lets new instances onto the executor again, and stops draining it
+func (a *Auctioneer) Uncordon(executorID string) error {
+	if !a.HasLock() {
+		return ErrLockNotHeld
+	}
+
+	err := a.bbs.UncordonExecutor(executorID)
+	if err != nil {
+		a.logger.Error("failed-to-uncordon", err, lager.Data{"executor-id": executorID})
+		return err
+	}
+
+	a.cordons.remove(executorID)
+	a.executorDrainer.cancel(executorID)
+	a.logger.Info("uncordoned", lager.Data{"executor-id": executorID})
+
+	return nil
+}
+
+// DrainExecutor moves the instances on a cordoned executor elsewhere, in the
+// background.  Draining an executor that is already being drained does
+// nothing.
+func (a *Auctioneer) DrainExecutor(executorID string) error {
+	if !a.HasLock() {
+		return ErrLockNotHeld
+	}
+
+	if !a.cordons.has(executorID) {
+		return ErrExecutorNotCordoned
+	}
+
+	a.executorDrainer.drain(executorID)
+
+	return nil
+}
+
+// loadCordons picks up the cordons left by whoever held the lock before
+func (a *Auctioneer) loadCordons() {
+	cordoned, err := a.bbs.GetCordonedExecutors()
+	if err != nil {
+		a.logger.Error("failed-to-get-cordoned-executors", err)
+		return
+	}
+
+	a.cordons.set(cordoned)
+}
@@ -0,0 +1,269 @@
+package auctioneer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	Bbs "github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	DefaultExecutorDrainInterval           = 5 * time.Second
+	DefaultExecutorDrainReplacementTimeout = 2 * time.Minute
+)
+
+/*
+
+executorDrainer moves the instances off cordoned executors, one at a time.
+Each instance is replaced before it is stopped: once its replacement is
+running, a stop auction is requested for the index, which stops the original
+on the cordoned executor.  Instances whose replacement isn't running within
+the replacement timeout are skipped.  Instances for which no replacement can
+be built, since their desired LRP can't be got, have failed.  How each
+executor's drain is going, or went, is kept until it is uncordoned.
+
+Instances are moved no more often than every drain interval, however many
+executors are draining.  Drains are abandoned when the lock is lost.
+
+*/
+
+// ExecutorDrain is how the drain of a cordoned executor is going, or went
+type ExecutorDrain struct {
+	ExecutorID string `json:"executor_id"`
+	Draining   bool   `json:"draining"`
+	Instances  int    `json:"instances"`
+	Moved      int    `json:"moved"`
+	Skipped    int    `json:"skipped"`
+	Failed     int    `json:"failed"`
+}
+
+type executorDrainer struct {
+	bbs      Bbs.AuctioneerBBS
+	replacer *replacer
+	settings func() *settings
+	logger   lager.Logger
+
+	lock     *sync.Mutex
+	drains   map[string]chan struct{}
+	reports  map[string]*ExecutorDrain
+	nextMove time.Time
+}
+
+func newExecutorDrainer(bbs Bbs.AuctioneerBBS, replacer *replacer, settings func() *settings, logger lager.Logger) *executorDrainer {
+	return &executorDrainer{
+		bbs:      bbs,
+		replacer: replacer,
+		settings: settings,
+		logger:   logger.Session("executor-drainer"),
+
+		lock:    &sync.Mutex{},
+		drains:  map[string]chan struct{}{},
+		reports: map[string]*ExecutorDrain{},
+	}
+}
+
+func (d *executorDrainer) drain(executorID string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, ok := d.drains[executorID]; ok {
+		return
+	}
+
+	cancel := make(chan struct{})
+	d.drains[executorID] = cancel
+	d.reports[executorID] = &ExecutorDrain{ExecutorID: executorID, Draining: true}
+
+	go d.run(executorID, cancel)
+}
+
+func (d *executorDrainer) cancel(executorID string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	cancel, ok := d.drains[executorID]
+	if ok {
+		close(cancel)
+		delete(d.drains, executorID)
+	}
+
+	delete(d.reports, executorID)
+}
+
+// stop abandons every drain
+func (d *executorDrainer) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for executorID, cancel := range d.drains {
+		close(cancel)
+		delete(d.drains, executorID)
+		d.reports[executorID].Draining = false
+	}
+}
+
+// draining returns the executors being drained, in order
+func (d *executorDrainer) draining() []string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	executorIDs := []string{}
+	for executorID := range d.drains {
+		executorIDs = append(executorIDs, executorID)
+	}
+
+	sort.Strings(executorIDs)
+
+	return executorIDs
+}
+
+// reported returns how each drain is going, or went, in order of executor
+func (d *executorDrainer) reported() []ExecutorDrain {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	executorIDs := []string{}
+	for executorID := range d.reports {
+		executorIDs = append(executorIDs, executorID)
+	}
+
+	sort.Strings(executorIDs)
+
+	reports := make([]ExecutorDrain, 0, len(executorIDs))
+	for _, executorID := range executorIDs {
+		reports = append(reports, *d.reports[executorID])
+	}
+
+	return reports
+}
+
+// report updates the drain's report, unless another drain has replaced it
+func (d *executorDrainer) report(executorID string, cancel chan struct{}, update func(*ExecutorDrain)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.drains[executorID] == cancel {
+		update(d.reports[executorID])
+	}
+}
+
+func (d *executorDrainer) finished(executorID string, cancel chan struct{}) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.drains[executorID] == cancel {
+		delete(d.drains, executorID)
+		d.reports[executorID].Draining = false
+	}
+}
+
+func (d *executorDrainer) run(executorID string, cancel chan struct{}) {
+	defer d.finished(executorID, cancel)
+
+	logger := d.logger.Session("drain", lager.Data{"executor-id": executorID})
+
+	actualLRPs, err := d.bbs.GetAllActualLRPs()
+	if err != nil {
+		logger.Error("failed-to-get-actual-lrps", err)
+		return
+	}
+
+	instances := []models.ActualLRP{}
+	for _, actualLRP := range actualLRPs {
+		if actualLRP.ExecutorID == executorID {
+			instances = append(instances, actualLRP)
+		}
+	}
+
+	logger.Info("draining", lager.Data{"instances": len(instances)})
+	d.report(executorID, cancel, func(report *ExecutorDrain) {
+		report.Instances = len(instances)
+	})
+
+	moved, skipped, failed := 0, 0, 0
+	for _, instance := range instances {
+		if !d.waitToMove(cancel) {
+			logger.Info("cancelled", lager.Data{"moved": moved, "skipped": skipped, "failed": failed})
+			return
+		}
+
+		switch d.move(logger, instance, cancel) {
+		case drainMoved:
+			moved++
+		case drainSkipped:
+			skipped++
+		case drainFailed:
+			failed++
+		}
+
+		d.report(executorID, cancel, func(report *ExecutorDrain) {
+			report.Moved, report.Skipped, report.Failed = moved, skipped, failed
+		})
+	}
+
+	logger.Info("drained", lager.Data{"moved": moved, "skipped": skipped, "failed": failed})
+}
+
+// waitToMove returns false if the drain is cancelled while it waits its turn
+func (d *executorDrainer) waitToMove(cancel <-chan struct{}) bool {
+	d.lock.Lock()
+	now := time.Now()
+	moveAt := d.nextMove
+	if moveAt.Before(now) {
+		moveAt = now
+	}
+	d.nextMove = moveAt.Add(d.settings().executorDrainInterval)
+	d.lock.Unlock()
+
+	select {
+	case <-time.After(moveAt.Sub(now)):
+		return true
+	case <-cancel:
+		return false
+	}
+}
+
+type drainOutcome int
+
+const (
+	drainMoved drainOutcome = iota
+	drainSkipped
+	drainFailed
+)
+
+func (d *executorDrainer) move(logger lager.Logger, instance models.ActualLRP, cancel <-chan struct{}) drainOutcome {
+	logger = logger.Session("move", lager.Data{
+		"process-guid":  instance.ProcessGuid,
+		"instance-guid": instance.InstanceGuid,
+		"index":         instance.Index,
+	})
+
+	settings := d.settings()
+
+	replacement, err := d.replacer.startAuctionFor(instance)
+	if err != nil {
+		logger.Error("failed-to-build-replacement", err)
+		return drainFailed
+	}
+
+	_, ok := d.replacer.replace(logger, replacement, settings.executorDrainReplacementTimeout, settings.executorDrainInterval, cancel)
+	if !ok {
+		return drainSkipped
+	}
+
+	err = d.bbs.RequestLRPStopAuction(models.LRPStopAuction{
+		ProcessGuid: instance.ProcessGuid,
+		Index:       instance.Index,
+	})
+	if err != nil {
+		logger.Error("failed-to-request-stop-auction", err)
+		return drainSkipped
+	}
+
+	logger.Info("moved")
+
+	return drainMoved
+}
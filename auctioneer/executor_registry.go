@@ -0,0 +1,255 @@
+package auctioneer
+
+import (
+	"sync"
+	"time"
+
+	Bbs "github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const defaultExecutorRelistInterval = 30 * time.Second
+
+/*
+
+executorRegistry keeps the executors in memory so that auctions needn't each
+list them from etcd.  It watches executor presences come and go, and re-lists
+them all every relistInterval in case the watch missed anything.
+
+Until the first list succeeds, auctions list the executors from etcd
+themselves.
+
+The registry is stale while it may be missing changes: from when the watch
+goes away until it is re-established and the executors have been re-listed.
+
+*/
+
+type executorRegistry struct {
+	bbs            Bbs.AuctioneerBBS
+	relistInterval time.Duration
+	logger         lager.Logger
+
+	lock       *sync.RWMutex
+	executors  map[string]models.ExecutorPresence
+	byStack    map[string]map[string]models.ExecutorPresence
+	synced     bool
+	watching   bool
+	staleSince time.Time
+
+	stopping chan struct{}
+	stopped  chan struct{}
+}
+
+func newExecutorRegistry(bbs Bbs.AuctioneerBBS, relistInterval time.Duration, logger lager.Logger) *executorRegistry {
+	if relistInterval <= 0 {
+		relistInterval = defaultExecutorRelistInterval
+	}
+
+	return &executorRegistry{
+		bbs:            bbs,
+		relistInterval: relistInterval,
+		logger:         logger.Session("executor-registry"),
+
+		lock:       &sync.RWMutex{},
+		executors:  map[string]models.ExecutorPresence{},
+		byStack:    map[string]map[string]models.ExecutorPresence{},
+		staleSince: time.Now(),
+
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (r *executorRegistry) start() {
+	go r.run()
+}
+
+func (r *executorRegistry) stop() {
+	close(r.stopping)
+	<-r.stopped
+}
+
+// all returns every executor
+func (r *executorRegistry) all() ([]models.ExecutorPresence, error) {
+	r.lock.RLock()
+	if !r.synced {
+		r.lock.RUnlock()
+		return r.bbs.GetAllExecutors()
+	}
+	defer r.lock.RUnlock()
+
+	executors := []models.ExecutorPresence{}
+	for _, executor := range r.executors {
+		executors = append(executors, executor)
+	}
+
+	return executors, nil
+}
+
+// forStacks returns the executors that advertise any of the stacks
+func (r *executorRegistry) forStacks(stacks []string) ([]models.ExecutorPresence, error) {
+	r.lock.RLock()
+	if !r.synced {
+		r.lock.RUnlock()
+
+		executors, err := r.bbs.GetAllExecutors()
+		if err != nil {
+			return nil, err
+		}
+
+		return StackCompatibility{}.executorsForStacks(executors, stacks), nil
+	}
+	defer r.lock.RUnlock()
+
+	executors := []models.ExecutorPresence{}
+	for _, stack := range stacks {
+		for _, executor := range r.byStack[stack] {
+			executors = append(executors, executor)
+		}
+	}
+
+	return executors, nil
+}
+
+func (r *executorRegistry) staleness() time.Duration {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if r.watching && r.synced {
+		return 0
+	}
+
+	return time.Since(r.staleSince)
+}
+
+func (r *executorRegistry) run() {
+	defer close(r.stopped)
+
+	changes, cancelWatch, errs := r.watch()
+	r.relist()
+
+	relistTicker := time.NewTicker(r.relistInterval)
+	defer relistTicker.Stop()
+
+	var retry <-chan time.Time
+	retryInterval := watchRetryMinInterval
+
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				r.logger.Info("watch-closed")
+				changes, cancelWatch, errs = nil, nil, nil
+				r.lostWatch()
+				retry = time.After(retryInterval)
+				retryInterval = nextWatchRetryInterval(retryInterval)
+				continue
+			}
+
+			retryInterval = watchRetryMinInterval
+			r.apply(change)
+
+		case err := <-errs:
+			r.logger.Error("watch-failed", err, lager.Data{"retry-in": retryInterval.String()})
+			changes, cancelWatch, errs = nil, nil, nil
+			r.lostWatch()
+			retry = time.After(retryInterval)
+			retryInterval = nextWatchRetryInterval(retryInterval)
+
+		case <-retry:
+			retry = nil
+			changes, cancelWatch, errs = r.watch()
+			r.relist()
+
+		case <-relistTicker.C:
+			r.relist()
+
+		case <-r.stopping:
+			if cancelWatch != nil {
+				close(cancelWatch)
+			}
+			return
+		}
+	}
+}
+
+func (r *executorRegistry) watch() (<-chan models.ExecutorPresenceChange, chan<- bool, <-chan error) {
+	changes, cancelWatch, errs := r.bbs.WatchForExecutorChanges()
+
+	r.lock.Lock()
+	r.watching = true
+	r.lock.Unlock()
+
+	r.logger.Info("watching")
+
+	return changes, cancelWatch, errs
+}
+
+func (r *executorRegistry) lostWatch() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.watching && r.synced {
+		r.staleSince = time.Now()
+	}
+	r.watching = false
+}
+
+func (r *executorRegistry) relist() {
+	executors, err := r.bbs.GetAllExecutors()
+	if err != nil {
+		r.logger.Error("failed-to-list-executors", err)
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.executors = map[string]models.ExecutorPresence{}
+	r.byStack = map[string]map[string]models.ExecutorPresence{}
+	for _, executor := range executors {
+		r.add(executor)
+	}
+
+	r.synced = true
+	if !r.watching {
+		r.staleSince = time.Now()
+	}
+}
+
+func (r *executorRegistry) apply(change models.ExecutorPresenceChange) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if change.Before != nil {
+		r.remove(change.Before.ExecutorID)
+	}
+
+	if change.After != nil {
+		r.remove(change.After.ExecutorID)
+		r.add(*change.After)
+	}
+}
+
+func (r *executorRegistry) add(executor models.ExecutorPresence) {
+	r.executors[executor.ExecutorID] = executor
+
+	if r.byStack[executor.Stack] == nil {
+		r.byStack[executor.Stack] = map[string]models.ExecutorPresence{}
+	}
+	r.byStack[executor.Stack][executor.ExecutorID] = executor
+}
+
+func (r *executorRegistry) remove(executorID string) {
+	executor, ok := r.executors[executorID]
+	if !ok {
+		return
+	}
+
+	delete(r.executors, executorID)
+	delete(r.byStack[executor.Stack], executorID)
+	if len(r.byStack[executor.Stack]) == 0 {
+		delete(r.byStack, executor.Stack)
+	}
+}
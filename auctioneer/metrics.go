@@ -0,0 +1,93 @@
+package auctioneer
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/auctioneer/metrics"
+)
+
+// kinds of auction, as labelled in the metrics
+const (
+	startAuctionKind = "start"
+	stopAuctionKind  = "stop"
+)
+
+// reasons a stop auction fails, as labelled in the metrics
+const (
+	stopFailureFailedToGetExecutors = "failed-to-get-executors"
+	stopFailureNoExecutors          = "no-executors"
+	stopFailureAuctionError         = "auction-error"
+)
+
+var roundBuckets = []float64{1, 2, 3, 5, 10, 20, 40}
+var communicationBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+type auctioneerMetrics struct {
+	startsStarted   *metrics.Counter
+	startsSucceeded *metrics.Counter
+	startsFailed    *metrics.Counter
+
+	stopsStarted   *metrics.Counter
+	stopsSucceeded *metrics.Counter
+	stopsFailed    *metrics.Counter
+
+	rounds          *metrics.Histogram
+	communications  *metrics.Histogram
+	biddingDuration *metrics.Histogram
+	queueWait       *metrics.Histogram
+
+	inFlight *metrics.Gauge
+}
+
+func newAuctioneerMetrics(registry *metrics.Registry, a *Auctioneer) *auctioneerMetrics {
+	m := &auctioneerMetrics{
+		startsStarted:   registry.NewCounter("auctioneer_start_auctions_started_total", "Start auctions claimed and run.", "stack"),
+		startsSucceeded: registry.NewCounter("auctioneer_start_auctions_succeeded_total", "Start auctions that placed their instance.", "stack"),
+		startsFailed:    registry.NewCounter("auctioneer_start_auctions_failed_total", "Start auction attempts that failed, whether or not they are retried.", "stack", "reason"),
+
+		stopsStarted:   registry.NewCounter("auctioneer_stop_auctions_started_total", "Stop auctions claimed and run."),
+		stopsSucceeded: registry.NewCounter("auctioneer_stop_auctions_succeeded_total", "Stop auctions that stopped the extra instances."),
+		stopsFailed:    registry.NewCounter("auctioneer_stop_auctions_failed_total", "Stop auctions that failed.", "reason"),
+
+		rounds:          registry.NewHistogram("auctioneer_start_auction_rounds", "Rounds of bidding per start auction.", roundBuckets, "stack"),
+		communications:  registry.NewHistogram("auctioneer_auction_communications", "Messages exchanged with reps per auction.", communicationBuckets, "kind"),
+		biddingDuration: registry.NewHistogram("auctioneer_auction_bidding_duration_seconds", "Time spent bidding per auction.", metrics.DefaultDurationBuckets, "kind"),
+		queueWait:       registry.NewHistogram("auctioneer_auction_queue_wait_seconds", "Time auctions wait for a worker.", metrics.DefaultDurationBuckets, "kind"),
+
+		inFlight: registry.NewGauge("auctioneer_auctions_in_flight", "Auctions being run.", "kind"),
+	}
+
+	m.inFlight.Set(0, startAuctionKind)
+	m.inFlight.Set(0, stopAuctionKind)
+
+	registry.NewGaugeFunc("auctioneer_worker_utilization", "Fraction of the maxConcurrent workers running auctions.", func() float64 {
+		maxConcurrent := a.scheduler.size()
+		if maxConcurrent == 0 {
+			return 0
+		}
+
+		return (m.inFlight.Value(startAuctionKind) + m.inFlight.Value(stopAuctionKind)) / float64(maxConcurrent)
+	})
+
+	registry.NewGaugeFunc("auctioneer_queued_auctions", "Auctions waiting for a worker.", func() float64 {
+		return float64(a.QueueDepth())
+	})
+
+	registry.NewGaugeFunc("auctioneer_lock_held", "1 if this auctioneer holds the auctioneer lock, 0 otherwise.", func() float64 {
+		if a.HasLock() {
+			return 1
+		}
+
+		return 0
+	})
+
+	registry.NewGaugeFunc("auctioneer_executor_cache_staleness_seconds", "How long the cached executors may have been missing changes.", func() float64 {
+		return a.ExecutorCacheStaleness().Seconds()
+	})
+
+	return m
+}
+
+func (m *auctioneerMetrics) waited(kind string, wait time.Duration) {
+	m.queueWait.Observe(wait.Seconds(), kind)
+}
@@ -0,0 +1,86 @@
+package auctioneer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+// QueuedStartAuction is what a StartAuctionPriority gets to see about a start
+// auction waiting for a worker
+type QueuedStartAuction struct {
+	LRPStartAuction models.LRPStartAuction
+
+	// running actual LRPs for the auction's process guid, counted by the
+	// scheduler before it next chooses between the process and others
+	RunningInstances int
+}
+
+// StartAuctionPriority orders the start auctions waiting for a worker
+type StartAuctionPriority interface {
+	// Less reports whether a should be auctioned before b
+	Less(a, b QueuedStartAuction) bool
+}
+
+type priorityCriterion func(a, b QueuedStartAuction) int
+
+var priorityCriteria = map[string]priorityCriterion{
+	// processes with nothing running (new or crashed apps) go first
+	"no-running-instances": func(a, b QueuedStartAuction) int {
+		aNone, bNone := a.RunningInstances == 0, b.RunningInstances == 0
+		switch {
+		case aNone && !bNone:
+			return -1
+		case bNone && !aNone:
+			return 1
+		}
+		return 0
+	},
+
+	// lower indices go first
+	"index": func(a, b QueuedStartAuction) int {
+		return a.LRPStartAuction.Index - b.LRPStartAuction.Index
+	},
+
+	// auctions that have been waiting longer go first
+	"age": func(a, b QueuedStartAuction) int {
+		switch {
+		case a.LRPStartAuction.UpdatedAt < b.LRPStartAuction.UpdatedAt:
+			return -1
+		case a.LRPStartAuction.UpdatedAt > b.LRPStartAuction.UpdatedAt:
+			return 1
+		}
+		return 0
+	},
+}
+
+var DefaultStartAuctionPriorityCriteria = []string{"no-running-instances", "index", "age"}
+
+type criteriaPriority []priorityCriterion
+
+// NewStartAuctionPriority builds a StartAuctionPriority that compares
+// auctions by each of the named criteria in turn.  Known criteria are
+// "no-running-instances", "index" and "age".
+func NewStartAuctionPriority(criteria ...string) (StartAuctionPriority, error) {
+	priority := criteriaPriority{}
+	for _, name := range criteria {
+		criterion, ok := priorityCriteria[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown start auction priority criterion '%s'", name)
+		}
+		priority = append(priority, criterion)
+	}
+
+	return priority, nil
+}
+
+func (p criteriaPriority) Less(a, b QueuedStartAuction) bool {
+	for _, criterion := range p {
+		if c := criterion(a, b); c != 0 {
+			return c < 0
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package auctioneer_test
+
+import (
+	. "github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StartAuctionPriority", func() {
+	queued := func(processGuid string, index int, updatedAt int64, running int) QueuedStartAuction {
+		return QueuedStartAuction{
+			LRPStartAuction: models.LRPStartAuction{
+				ProcessGuid: processGuid,
+				Index:       index,
+				UpdatedAt:   updatedAt,
+			},
+			RunningInstances: running,
+		}
+	}
+
+	Describe("the default criteria", func() {
+		var priority StartAuctionPriority
+
+		BeforeEach(func() {
+			var err error
+			priority, err = NewStartAuctionPriority(DefaultStartAuctionPriorityCriteria...)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("should put processes with no running instances first", func() {
+			crashed := queued("crashed", 49, 100, 0)
+			healthy := queued("healthy", 0, 0, 49)
+
+			Ω(priority.Less(crashed, healthy)).Should(BeTrue())
+			Ω(priority.Less(healthy, crashed)).Should(BeFalse())
+		})
+
+		It("should then put lower indices first", func() {
+			low := queued("app", 1, 100, 3)
+			high := queued("app", 50, 0, 3)
+
+			Ω(priority.Less(low, high)).Should(BeTrue())
+			Ω(priority.Less(high, low)).Should(BeFalse())
+		})
+
+		It("should then put older auctions first", func() {
+			older := queued("app", 1, 100, 3)
+			newer := queued("other-app", 1, 200, 3)
+
+			Ω(priority.Less(older, newer)).Should(BeTrue())
+			Ω(priority.Less(newer, older)).Should(BeFalse())
+		})
+
+		It("should not order otherwise identical auctions", func() {
+			a := queued("app", 1, 100, 3)
+			b := queued("other-app", 1, 100, 3)
+
+			Ω(priority.Less(a, b)).Should(BeFalse())
+			Ω(priority.Less(b, a)).Should(BeFalse())
+		})
+	})
+
+	Describe("custom criteria", func() {
+		It("should apply the criteria in the order given", func() {
+			priority, err := NewStartAuctionPriority("age", "index")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			older := queued("app", 50, 100, 3)
+			newer := queued("app", 1, 200, 0)
+
+			Ω(priority.Less(older, newer)).Should(BeTrue())
+		})
+
+		It("should error on unknown criteria", func() {
+			_, err := NewStartAuctionPriority("index", "shoe-size")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
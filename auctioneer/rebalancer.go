@@ -0,0 +1,347 @@
+package auctioneer
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	Bbs "github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	DefaultRebalanceThreshold          = 0.2
+	DefaultRebalanceMaxMoves           = 1
+	DefaultRebalanceMaxUnavailable     = 1
+	DefaultRebalanceReplacementTimeout = 2 * time.Minute
+)
+
+// the reps' load is sampled by asking them to bid on an instance that needs
+// nothing but a container
+var rebalanceProbe = auctiontypes.StartAuctionInfo{
+	ProcessGuid:  "auctioneer-rebalance-probe",
+	InstanceGuid: "auctioneer-rebalance-probe",
+}
+
+/*
+
+rebalancer moves instances from the most loaded executor to less loaded ones,
+while this auctioneer holds the lock.
+
+Every rebalance interval it samples the load of the uncordoned executors'
+reps, by their bids for a probe.  Reps that refuse the probe, which they do
+when they have no room left, are taken to be full.  If the most loaded rep's
+load is more than the threshold above the least loaded rep's, up to the max
+moves of its running instances are moved, each by starting a replacement and
+then stopping the original.  Where the replacement goes is up to its start
+auction, which favours the less loaded reps.
+
+Each process has a budget of max unavailable instances: those being moved,
+and those that aren't running (replacements included), count against it, and
+no instance is moved while the budget is spent.  Instances that can't be
+replaced are left where they are.
+
+In a dry run the moves are only logged.
+
+Nothing is moved while start auctions are paused, and moves in flight are
+abandoned when the lock is lost.
+
+*/
+
+type rebalancer struct {
+	repPool   auctiontypes.RepPoolClient
+	bbs       Bbs.AuctioneerBBS
+	executors *executorRegistry
+	cordons   *cordons
+	replacer  *replacer
+	active    func() bool
+	settings  func() *settings
+	interval  time.Duration
+	logger    lager.Logger
+
+	lock   *sync.Mutex
+	moving map[string]string //process guids by instance guid
+	cancel chan struct{}
+
+	stopping chan struct{}
+	stopped  chan struct{}
+}
+
+func newRebalancer(
+	repPool auctiontypes.RepPoolClient,
+	bbs Bbs.AuctioneerBBS,
+	executors *executorRegistry,
+	cordons *cordons,
+	replacer *replacer,
+	active func() bool,
+	settings func() *settings,
+	interval time.Duration,
+	logger lager.Logger,
+) *rebalancer {
+	return &rebalancer{
+		repPool:   repPool,
+		bbs:       bbs,
+		executors: executors,
+		cordons:   cordons,
+		replacer:  replacer,
+		active:    active,
+		settings:  settings,
+		interval:  interval,
+		logger:    logger.Session("rebalancer"),
+
+		lock:   &sync.Mutex{},
+		moving: map[string]string{},
+		cancel: make(chan struct{}),
+
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// rebalancing is off without a rep pool to sample or an interval to do it at
+func (r *rebalancer) enabled() bool {
+	return r.repPool != nil && r.interval > 0
+}
+
+func (r *rebalancer) start() {
+	if !r.enabled() {
+		return
+	}
+
+	go r.run()
+}
+
+func (r *rebalancer) stop() {
+	if !r.enabled() {
+		return
+	}
+
+	close(r.stopping)
+	<-r.stopped
+
+	r.abandon()
+}
+
+// abandon cancels the moves in flight
+func (r *rebalancer) abandon() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	close(r.cancel)
+	r.cancel = make(chan struct{})
+}
+
+// inFlight returns the number of instances being moved
+func (r *rebalancer) inFlight() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return len(r.moving)
+}
+
+func (r *rebalancer) run() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if r.active() {
+				r.rebalance()
+			}
+
+		case <-r.stopping:
+			return
+		}
+	}
+}
+
+type repLoad struct {
+	repGuid string
+	load    float64
+}
+
+type byLoad []repLoad
+
+func (s byLoad) Len() int           { return len(s) }
+func (s byLoad) Less(i, j int) bool { return s[i].load < s[j].load }
+func (s byLoad) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+type byProcessAndIndex []models.ActualLRP
+
+func (s byProcessAndIndex) Len() int      { return len(s) }
+func (s byProcessAndIndex) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byProcessAndIndex) Less(i, j int) bool {
+	if s[i].ProcessGuid != s[j].ProcessGuid {
+		return s[i].ProcessGuid < s[j].ProcessGuid
+	}
+
+	return s[i].Index < s[j].Index
+}
+
+func (r *rebalancer) rebalance() {
+	settings := r.settings()
+
+	loads, err := r.sample()
+	if err != nil {
+		r.logger.Error("failed-to-get-executors", err)
+		return
+	}
+
+	if len(loads) < 2 {
+		return
+	}
+
+	coldest, hottest := loads[0], loads[len(loads)-1]
+	if hottest.load-coldest.load <= settings.rebalanceThreshold {
+		return
+	}
+
+	logger := r.logger.Session("rebalance", lager.Data{
+		"from":      hottest.repGuid,
+		"from-load": hottest.load,
+		"min-load":  coldest.load,
+	})
+
+	actualLRPs, err := r.bbs.GetAllActualLRPs()
+	if err != nil {
+		logger.Error("failed-to-get-actual-lrps", err)
+		return
+	}
+
+	//instances count against their process's budget while they aren't running
+	unavailable := map[string]int{}
+	candidates := []models.ActualLRP{}
+	for _, actualLRP := range actualLRPs {
+		if actualLRP.State != models.ActualLRPStateRunning {
+			unavailable[actualLRP.ProcessGuid]++
+			continue
+		}
+
+		if actualLRP.ExecutorID == hottest.repGuid {
+			candidates = append(candidates, actualLRP)
+		}
+	}
+
+	sort.Sort(byProcessAndIndex(candidates))
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, processGuid := range r.moving {
+		unavailable[processGuid]++
+	}
+
+	moves := 0
+	for _, instance := range candidates {
+		if moves >= settings.rebalanceMaxMoves {
+			break
+		}
+
+		if _, ok := r.moving[instance.InstanceGuid]; ok {
+			continue
+		}
+
+		if unavailable[instance.ProcessGuid] >= settings.rebalanceMaxUnavailable {
+			continue
+		}
+
+		unavailable[instance.ProcessGuid]++
+		moves++
+
+		if settings.rebalanceDryRun {
+			logger.Info("proposed-move", lager.Data{
+				"process-guid":  instance.ProcessGuid,
+				"instance-guid": instance.InstanceGuid,
+				"index":         instance.Index,
+			})
+			continue
+		}
+
+		r.moving[instance.InstanceGuid] = instance.ProcessGuid
+		go r.move(logger, instance, settings, r.cancel)
+	}
+}
+
+// sample returns the load of every uncordoned executor's rep, least loaded
+// first
+func (r *rebalancer) sample() ([]repLoad, error) {
+	executors, err := r.executors.all()
+	if err != nil {
+		return nil, err
+	}
+
+	executors = r.cordons.exclude(executors)
+	if len(executors) < 2 {
+		return nil, nil
+	}
+
+	repGuids := make([]string, 0, len(executors))
+	for _, executor := range executors {
+		repGuids = append(repGuids, executor.ExecutorID)
+	}
+
+	loads := []repLoad{}
+	for _, bid := range r.repPool.BidForStartAuction(repGuids, rebalanceProbe) {
+		load := bid.Bid
+		if bid.Error != "" {
+			load = 1
+		}
+
+		loads = append(loads, repLoad{repGuid: bid.Rep, load: load})
+	}
+
+	sort.Sort(byLoad(loads))
+
+	return loads, nil
+}
+
+func (r *rebalancer) move(logger lager.Logger, instance models.ActualLRP, settings *settings, cancel <-chan struct{}) {
+	defer r.moved(instance.InstanceGuid)
+
+	logger = logger.Session("move", lager.Data{
+		"process-guid":  instance.ProcessGuid,
+		"instance-guid": instance.InstanceGuid,
+		"index":         instance.Index,
+	})
+
+	startAuction, err := r.replacer.startAuctionFor(instance)
+	if err != nil {
+		logger.Error("failed-to-build-replacement", err)
+		return
+	}
+
+	replacement, ok := r.replacer.replace(logger, startAuction, settings.rebalanceReplacementTimeout, r.interval, cancel)
+	if !ok {
+		return
+	}
+
+	//the original's executor is left out of the replacement's auction, but
+	//in case the replacement got there anyway, the original is kept
+	if replacement.ExecutorID == instance.ExecutorID {
+		logger.Error("replacement-on-same-executor", nil, lager.Data{"executor-id": instance.ExecutorID})
+		return
+	}
+
+	err = r.bbs.RequestStopLRPInstance(models.StopLRPInstance{
+		ProcessGuid:  instance.ProcessGuid,
+		InstanceGuid: instance.InstanceGuid,
+		Index:        instance.Index,
+	})
+	if err != nil {
+		logger.Error("failed-to-request-stop-instance", err)
+		return
+	}
+
+	logger.Info("moved", lager.Data{"to": replacement.ExecutorID})
+}
+
+func (r *rebalancer) moved(instanceGuid string) {
+	r.lock.Lock()
+	delete(r.moving, instanceGuid)
+	r.lock.Unlock()
+}
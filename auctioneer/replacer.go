@@ -0,0 +1,157 @@
+package auctioneer
+
+import (
+	"sync"
+	"time"
+
+	Bbs "github.com/cloudfoundry-incubator/runtime-schema/bbs"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/nu7hatch/gouuid"
+	"github.com/pivotal-golang/lager"
+)
+
+/*
+
+replacer starts a replacement for a running instance, for those that move
+instances from one executor to another by starting the replacement and then
+stopping the original.
+
+The replacement's start auction is built from the process's desired LRP, so
+that it asks for the stack and resources the process is desired with now.
+The desired LRP doesn't say how its desirer runs instances, though, so the
+actions, ports, labels and anti-affinity are copied from the last start
+auction this auctioneer saw for the process.  Without one, as after a restart,
+the replacement is run from the desired LRP's source and start command.
+
+*/
+
+type replacer struct {
+	bbs Bbs.AuctioneerBBS
+
+	lock      *sync.Mutex
+	templates map[string]models.LRPStartAuction
+}
+
+func newReplacer(bbs Bbs.AuctioneerBBS) *replacer {
+	return &replacer{
+		bbs: bbs,
+
+		lock:      &sync.Mutex{},
+		templates: map[string]models.LRPStartAuction{},
+	}
+}
+
+// remember keeps the start auction to copy what the desired LRP doesn't say
+// from when replacing the process's instances
+func (r *replacer) remember(startAuction models.LRPStartAuction) {
+	r.lock.Lock()
+	r.templates[startAuction.ProcessGuid] = startAuction
+	r.lock.Unlock()
+}
+
+// startAuctionFor builds the start auction for a replacement of the instance,
+// at its index and on any executor but the instance's; it fails if the
+// process's desired LRP can't be got
+func (r *replacer) startAuctionFor(instance models.ActualLRP) (models.LRPStartAuction, error) {
+	desired, err := r.bbs.GetDesiredLRPByProcessGuid(instance.ProcessGuid)
+	if err != nil {
+		return models.LRPStartAuction{}, err
+	}
+
+	guid, err := uuid.NewV4()
+	if err != nil {
+		return models.LRPStartAuction{}, err
+	}
+
+	index := instance.Index
+	replacement := models.LRPStartAuction{
+		ProcessGuid:  desired.ProcessGuid,
+		InstanceGuid: guid.String(),
+		Stack:        desired.Stack,
+		DiskMB:       desired.DiskMB,
+		MemoryMB:     desired.MemoryMB,
+		Log: models.LogConfig{
+			Guid:       desired.LogGuid,
+			SourceName: "App",
+			Index:      &index,
+		},
+		Index:               index,
+		ExcludedExecutorIDs: []string{instance.ExecutorID},
+	}
+
+	r.lock.Lock()
+	template, ok := r.templates[instance.ProcessGuid]
+	r.lock.Unlock()
+
+	if !ok {
+		replacement.Actions = desiredLRPActions(desired)
+		return replacement, nil
+	}
+
+	replacement.Actions = template.Actions
+	replacement.Ports = template.Ports
+	replacement.RequiredLabels = template.RequiredLabels
+	replacement.PreferredLabels = template.PreferredLabels
+	replacement.AntiAffinity = template.AntiAffinity
+	if template.Log.SourceName != "" {
+		replacement.Log.SourceName = template.Log.SourceName
+	}
+
+	return replacement, nil
+}
+
+// desiredLRPActions runs the desired LRP's start command in its source
+func desiredLRPActions(desired models.DesiredLRP) []models.ExecutorAction {
+	run := models.RunAction{
+		Path: "/bin/bash",
+		Args: []string{"-c", desired.StartCommand},
+		Env:  desired.Environment,
+	}
+
+	if desired.FileDescriptors != 0 {
+		nofile := desired.FileDescriptors
+		run.ResourceLimits.Nofile = &nofile
+	}
+
+	return []models.ExecutorAction{
+		{Action: models.DownloadAction{From: desired.Source, To: "/app", Extract: true}},
+		{Action: run},
+	}
+}
+
+// replace requests the replacement's start auction, and waits, checking
+// every pollInterval, for it to run.  It returns the running replacement, or
+// false if there is none by the timeout or the wait is cancelled.
+func (r *replacer) replace(logger lager.Logger, replacement models.LRPStartAuction, timeout time.Duration, pollInterval time.Duration, cancel <-chan struct{}) (models.ActualLRP, bool) {
+	err := r.bbs.RequestLRPStartAuction(replacement)
+	if err != nil {
+		logger.Error("failed-to-request-start-auction", err)
+		return models.ActualLRP{}, false
+	}
+
+	logger.Info("requested-replacement", lager.Data{"replacement-instance-guid": replacement.InstanceGuid})
+
+	timedOut := time.After(timeout)
+	for {
+		actualLRPs, err := r.bbs.GetActualLRPsByProcessGuid(replacement.ProcessGuid)
+		if err != nil {
+			logger.Error("failed-to-get-actual-lrps", err)
+		}
+
+		for _, actualLRP := range actualLRPs {
+			if actualLRP.InstanceGuid == replacement.InstanceGuid && actualLRP.State == models.ActualLRPStateRunning {
+				return actualLRP, true
+			}
+		}
+
+		select {
+		case <-time.After(pollInterval):
+		case <-timedOut:
+			logger.Info("replacement-not-running", lager.Data{"timeout": timeout.String()})
+			return models.ActualLRP{}, false
+		case <-cancel:
+			logger.Info("cancelled")
+			return models.ActualLRP{}, false
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package auctioneer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+// RetryPolicy says how start auctions that fail are put back up for auction.
+// The zero value gives up after the first attempt.
+type RetryPolicy struct {
+	// attempts in all, including the first
+	MaxAttempts int
+
+	// wait before the second attempt, doubling after each further failure up
+	// to MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// how long after its first attempt an auction may still be retried; 0
+	// means no deadline
+	Deadline time.Duration
+}
+
+func ValidateRetryPolicy(policy RetryPolicy) error {
+	if policy.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative, got %d", policy.MaxAttempts)
+	}
+
+	if policy.Deadline < 0 {
+		return fmt.Errorf("retry deadline must not be negative, got %s", policy.Deadline)
+	}
+
+	if policy.MaxAttempts <= 1 {
+		return nil
+	}
+
+	if policy.MinBackoff <= 0 {
+		return fmt.Errorf("min retry backoff must be positive, got %s", policy.MinBackoff)
+	}
+
+	if policy.MaxBackoff < policy.MinBackoff {
+		return fmt.Errorf("max retry backoff must be at least the min (%s), got %s", policy.MinBackoff, policy.MaxBackoff)
+	}
+
+	return nil
+}
+
+// nextAttempt is when an auction that has just failed (and had its Attempts
+// bumped) should run again, or false if it has run out of attempts or time
+func (policy RetryPolicy) nextAttempt(startAuction models.LRPStartAuction, now time.Time) (time.Time, bool) {
+	if startAuction.Attempts >= policy.MaxAttempts {
+		return time.Time{}, false
+	}
+
+	next := now.Add(policy.backoff(startAuction.Attempts))
+	if policy.Deadline > 0 && next.Sub(time.Unix(0, startAuction.FirstAttemptAt)) > policy.Deadline {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+func (policy RetryPolicy) backoff(failures int) time.Duration {
+	backoff := policy.MinBackoff
+	for i := 1; i < failures && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > policy.MaxBackoff && policy.MaxBackoff >= policy.MinBackoff {
+		return policy.MaxBackoff
+	}
+
+	return backoff
+}
+
+type startAuctionFailure struct {
+	reason string
+	err    error
+
+	numRounds         int
+	numCommunications int
+
+	// permanent failures would fail the same way if retried
+	permanent bool
+}
+
+// heldStartAuctions are pending in the BBS but not due to run yet; each is
+// submitted once its timer fires
+type heldStartAuctions struct {
+	lock   sync.Mutex
+	timers map[auctionKey]*time.Timer
+}
+
+func newHeldStartAuctions() *heldStartAuctions {
+	return &heldStartAuctions{
+		timers: map[auctionKey]*time.Timer{},
+	}
+}
+
+// hold returns false if the auction is already held
+func (h *heldStartAuctions) hold(startAuction models.LRPStartAuction, delay time.Duration, submit func(models.LRPStartAuction)) bool {
+	key := auctionKey{startAuction.ProcessGuid, startAuction.Index}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, held := h.timers[key]; held {
+		return false
+	}
+
+	h.timers[key] = time.AfterFunc(delay, func() {
+		h.lock.Lock()
+		delete(h.timers, key)
+		h.lock.Unlock()
+
+		submit(startAuction)
+	})
+
+	return true
+}
+
+// releaseAll forgets every held auction; they remain pending in the BBS for
+// whoever holds the lock next
+func (h *heldStartAuctions) releaseAll() int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	released := len(h.timers)
+	for key, timer := range h.timers {
+		timer.Stop()
+		delete(h.timers, key)
+	}
+
+	return released
+}
@@ -0,0 +1,51 @@
+package auctioneer_test
+
+import (
+	"time"
+
+	. "github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateRetryPolicy", func() {
+	var policy RetryPolicy
+
+	BeforeEach(func() {
+		policy = RetryPolicy{
+			MaxAttempts: 5,
+			MinBackoff:  time.Second,
+			MaxBackoff:  30 * time.Second,
+			Deadline:    5 * time.Minute,
+		}
+	})
+
+	It("should accept a sensible policy", func() {
+		Ω(ValidateRetryPolicy(policy)).ShouldNot(HaveOccurred())
+	})
+
+	It("should accept the zero policy, which never retries", func() {
+		Ω(ValidateRetryPolicy(RetryPolicy{})).ShouldNot(HaveOccurred())
+	})
+
+	It("should reject negative attempts", func() {
+		policy.MaxAttempts = -1
+		Ω(ValidateRetryPolicy(policy)).Should(HaveOccurred())
+	})
+
+	It("should reject a negative deadline", func() {
+		policy.Deadline = -time.Second
+		Ω(ValidateRetryPolicy(policy)).Should(HaveOccurred())
+	})
+
+	It("should reject retrying without backing off", func() {
+		policy.MinBackoff = 0
+		Ω(ValidateRetryPolicy(policy)).Should(HaveOccurred())
+	})
+
+	It("should reject a max backoff shorter than the min", func() {
+		policy.MaxBackoff = 500 * time.Millisecond
+		Ω(ValidateRetryPolicy(policy)).Should(HaveOccurred())
+	})
+})
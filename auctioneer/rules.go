@@ -0,0 +1,92 @@
+package auctioneer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
+)
+
+// ValidateStartAuctionRules catches rules that would otherwise only fail
+// once an auction is run with them
+func ValidateStartAuctionRules(rules algorithms.StartAuctionRules) error {
+	algorithm, err := algorithms.Lookup(rules.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	switch rules.Placement {
+	case "", algorithms.PlacementSpread:
+	case algorithms.PlacementPack:
+		if !algorithms.HonoursPlacement(algorithm) {
+			return fmt.Errorf("auction algorithm '%s' can't pack", rules.Algorithm)
+		}
+	default:
+		return fmt.Errorf("unknown placement '%s'", rules.Placement)
+	}
+
+	if rules.MaxRounds < 1 {
+		return fmt.Errorf("max rounds must be at least 1, got %d", rules.MaxRounds)
+	}
+
+	if rules.MaxBiddingPoolFraction <= 0 || rules.MaxBiddingPoolFraction > 1 {
+		return fmt.Errorf("max bidding pool fraction must be in (0, 1], got %g", rules.MaxBiddingPoolFraction)
+	}
+
+	if rules.MinBiddingPool < 1 {
+		return fmt.Errorf("min bidding pool must be at least 1, got %d", rules.MinBiddingPool)
+	}
+
+	if rules.NumChoices < 0 {
+		return fmt.Errorf("num choices must not be negative, got %d", rules.NumChoices)
+	}
+
+	return nil
+}
+
+// ValidateAntiAffinity accepts "" as well as the named modes, since start
+// auctions that don't set a mode fall back to the auctioneer's
+func ValidateAntiAffinity(antiAffinity string) error {
+	switch antiAffinity {
+	case "", algorithms.AntiAffinityNone, algorithms.AntiAffinitySoft, algorithms.AntiAffinityHard:
+		return nil
+	default:
+		return fmt.Errorf("unknown anti-affinity '%s'", antiAffinity)
+	}
+}
+
+// ParseStackStartAuctionRules reads per-stack overrides of the default rules
+// from JSON, e.g.
+//
+//	{"lucid64": {"algorithm": "pick_best", "maxRounds": 10}}
+//
+// Fields that are not given for a stack keep their default values.
+func ParseStackStartAuctionRules(defaults algorithms.StartAuctionRules, payload string) (map[string]algorithms.StartAuctionRules, error) {
+	stackRules := map[string]algorithms.StartAuctionRules{}
+	if payload == "" {
+		return stackRules, nil
+	}
+
+	overrides := map[string]json.RawMessage{}
+	err := json.Unmarshal([]byte(payload), &overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	for stack, override := range overrides {
+		rules := defaults
+		err := json.Unmarshal(override, &rules)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rules for stack '%s': %s", stack, err.Error())
+		}
+
+		err = ValidateStartAuctionRules(rules)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rules for stack '%s': %s", stack, err.Error())
+		}
+
+		stackRules[stack] = rules
+	}
+
+	return stackRules, nil
+}
@@ -0,0 +1,131 @@
+package auctioneer_test
+
+import (
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
+	. "github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Start auction rules", func() {
+	var rules algorithms.StartAuctionRules
+
+	BeforeEach(func() {
+		rules = algorithms.StartAuctionRules{
+			Algorithm:              "reserve_n_best",
+			MaxRounds:              10,
+			MaxBiddingPoolFraction: 0.2,
+			MinBiddingPool:         5,
+		}
+	})
+
+	Describe("ValidateStartAuctionRules", func() {
+		It("should accept every known algorithm", func() {
+			for _, algorithm := range algorithms.Names() {
+				rules.Algorithm = algorithm
+				Ω(ValidateStartAuctionRules(rules)).ShouldNot(HaveOccurred())
+			}
+		})
+
+		It("should reject unknown algorithms", func() {
+			rules.Algorithm = "reserve_n_bets"
+			Ω(ValidateStartAuctionRules(rules)).Should(Equal(algorithms.UnknownAlgorithmError{Algorithm: "reserve_n_bets"}))
+		})
+
+		It("should accept packing with algorithms that can pack", func() {
+			rules.Algorithm = "bin_pack"
+			rules.Placement = algorithms.PlacementPack
+			Ω(ValidateStartAuctionRules(rules)).ShouldNot(HaveOccurred())
+		})
+
+		It("should reject packing with algorithms that only spread", func() {
+			rules.Placement = algorithms.PlacementPack
+			Ω(ValidateStartAuctionRules(rules)).Should(MatchError("auction algorithm 'reserve_n_best' can't pack"))
+		})
+
+		It("should reject unknown placements", func() {
+			rules.Placement = "scatter"
+			Ω(ValidateStartAuctionRules(rules)).Should(MatchError("unknown placement 'scatter'"))
+		})
+
+		It("should reject negative num choices", func() {
+			rules.NumChoices = -1
+			Ω(ValidateStartAuctionRules(rules)).Should(HaveOccurred())
+		})
+
+		It("should reject non-positive max rounds", func() {
+			rules.MaxRounds = 0
+			Ω(ValidateStartAuctionRules(rules)).Should(HaveOccurred())
+		})
+
+		It("should reject bidding pool fractions outside (0, 1]", func() {
+			rules.MaxBiddingPoolFraction = 0
+			Ω(ValidateStartAuctionRules(rules)).Should(HaveOccurred())
+
+			rules.MaxBiddingPoolFraction = 1.5
+			Ω(ValidateStartAuctionRules(rules)).Should(HaveOccurred())
+
+			rules.MaxBiddingPoolFraction = 1
+			Ω(ValidateStartAuctionRules(rules)).ShouldNot(HaveOccurred())
+		})
+
+		It("should reject non-positive minimum bidding pools", func() {
+			rules.MinBiddingPool = 0
+			Ω(ValidateStartAuctionRules(rules)).Should(HaveOccurred())
+		})
+	})
+
+	Describe("ValidateAntiAffinity", func() {
+		It("should accept the known modes, and no mode", func() {
+			for _, mode := range []string{"", "none", "soft", "hard"} {
+				Ω(ValidateAntiAffinity(mode)).ShouldNot(HaveOccurred())
+			}
+		})
+
+		It("should reject unknown modes", func() {
+			Ω(ValidateAntiAffinity("strict")).Should(MatchError("unknown anti-affinity 'strict'"))
+		})
+	})
+
+	Describe("ParseStackStartAuctionRules", func() {
+		It("should return no overrides for an empty payload", func() {
+			stackRules, err := ParseStackStartAuctionRules(rules, "")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(stackRules).Should(BeEmpty())
+		})
+
+		It("should layer each stack's overrides on top of the defaults", func() {
+			stackRules, err := ParseStackStartAuctionRules(rules, `{
+				"lucid64": {"algorithm": "pick_best", "maxRounds": 3},
+				".Net": {"minBiddingPool": 1}
+			}`)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(stackRules).Should(Equal(map[string]algorithms.StartAuctionRules{
+				"lucid64": {
+					Algorithm:              "pick_best",
+					MaxRounds:              3,
+					MaxBiddingPoolFraction: 0.2,
+					MinBiddingPool:         5,
+				},
+				".Net": {
+					Algorithm:              "reserve_n_best",
+					MaxRounds:              10,
+					MaxBiddingPoolFraction: 0.2,
+					MinBiddingPool:         1,
+				},
+			}))
+		})
+
+		It("should error on malformed JSON", func() {
+			_, err := ParseStackStartAuctionRules(rules, `{"lucid64": `)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should error on invalid rules for a stack", func() {
+			_, err := ParseStackStartAuctionRules(rules, `{"lucid64": {"algorithm": "magic"}}`)
+			Ω(err).Should(MatchError("invalid rules for stack 'lucid64': unknown auction algorithm 'magic'"))
+		})
+	})
+})
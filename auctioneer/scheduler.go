@@ -0,0 +1,448 @@
+package auctioneer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+	"github.com/pivotal-golang/lager"
+)
+
+// auctions live in the BBS at /<root>/<process-guid>/<index>, so that pair
+// identifies an auction regardless of how we heard about it
+type auctionKey struct {
+	processGuid string
+	index       int
+}
+
+/*
+
+The scheduler holds a bounded queue of start and stop auctions and runs them
+on a pool of workers.
+
+	- Submitting never blocks, but whoever consumes the watches stops reading them while the queue is full, see room
+	- An auction that is already queued or running is dropped, but a newer attempt of a running start auction is queued once it is done, see resubmitted
+	- When both kinds of auction are waiting, workers pick between them in proportion to their shares
+	- Start auctions are shared fairly between process guids, see startAuctionQueue
+	- The pool can be resized while it runs; surplus workers stop once their auction is over
+
+*/
+
+type scheduler struct {
+	numWorkers int
+	maxQueued  int
+	startShare int
+	stopShare  int
+
+	runStart         func(models.LRPStartAuction)
+	runStop          func(models.LRPStopAuction)
+	runningInstances func(processGuid string) int
+	waited           func(kind string, wait time.Duration)
+
+	logger lager.Logger
+
+	workers *sync.WaitGroup
+
+	lock       *sync.Mutex
+	started    bool
+	running    int
+	cond       *sync.Cond
+	startQueue *startAuctionQueue
+	stopQueue  []models.LRPStopAuction
+	starts     map[auctionKey]bool
+	stops      map[auctionKey]bool
+
+	//the start auction each worker is running, and newer attempts at it
+	//submitted meanwhile, such as one requeued by its own worker, which are
+	//queued once it is done
+	runningStarts map[auctionKey]models.LRPStartAuction
+	resubmitted   map[auctionKey]models.LRPStartAuction
+
+	queuedAt    map[string]map[auctionKey]time.Time
+	startCredit int
+	stopCredit  int
+	stopped     bool
+
+	//closed, and cleared, once a full queue has room
+	roomChan chan struct{}
+}
+
+func newScheduler(
+	numWorkers int,
+	maxQueued int,
+	startShare int,
+	stopShare int,
+	maxPerProcess int,
+	priority StartAuctionPriority,
+	runStart func(models.LRPStartAuction),
+	runStop func(models.LRPStopAuction),
+	runningInstances func(processGuid string) int,
+	waited func(kind string, wait time.Duration),
+	logger lager.Logger,
+) *scheduler {
+	lock := &sync.Mutex{}
+
+	return &scheduler{
+		numWorkers:       numWorkers,
+		maxQueued:        maxQueued,
+		startShare:       startShare,
+		stopShare:        stopShare,
+		runStart:         runStart,
+		runStop:          runStop,
+		runningInstances: runningInstances,
+		waited:           waited,
+		logger:           logger.Session("scheduler"),
+		workers:          &sync.WaitGroup{},
+		lock:             lock,
+		cond:             sync.NewCond(lock),
+		startQueue:       newStartAuctionQueue(priority, maxPerProcess),
+		starts:           map[auctionKey]bool{},
+		stops:            map[auctionKey]bool{},
+		runningStarts:    map[auctionKey]models.LRPStartAuction{},
+		resubmitted:      map[auctionKey]models.LRPStartAuction{},
+		queuedAt: map[string]map[auctionKey]time.Time{
+			startAuctionKind: {},
+			stopAuctionKind:  {},
+		},
+	}
+}
+
+func (s *scheduler) start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.started = true
+	s.addWorkers(s.numWorkers)
+}
+
+// must be called with the lock held
+func (s *scheduler) addWorkers(n int) {
+	s.running += n
+	s.workers.Add(n)
+	for i := 0; i < n; i++ {
+		go s.work()
+	}
+}
+
+// reconfigure applies new limits to a scheduler that may be running.  Queued
+// start auctions are reordered by the new priority.
+func (s *scheduler) reconfigure(numWorkers int, maxQueued int, startShare int, stopShare int, maxPerProcess int, priority StartAuctionPriority) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if numWorkers != s.numWorkers {
+		s.logger.Info("resizing", lager.Data{"from": s.numWorkers, "to": numWorkers})
+	}
+
+	s.numWorkers = numWorkers
+	s.maxQueued = maxQueued
+	s.startShare, s.stopShare = startShare, stopShare
+	s.startCredit, s.stopCredit = 0, 0
+	s.startQueue.reconfigure(priority, maxPerProcess)
+
+	if s.started && !s.stopped && s.running < s.numWorkers {
+		s.addWorkers(s.numWorkers - s.running)
+	}
+
+	//surplus workers, and whoever is waiting for room, need to notice
+	s.cond.Broadcast()
+	s.signalRoom()
+}
+
+// size is the number of workers the scheduler runs auctions on
+func (s *scheduler) size() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.numWorkers
+}
+
+// stop lets running auctions finish but drops anything still queued; those
+// auctions remain pending in the BBS
+func (s *scheduler) stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stopped = true
+	s.dropQueued()
+}
+
+// drop is stop without stopping: running auctions finish, queued auctions
+// are dropped, and the scheduler takes whatever is submitted next
+func (s *scheduler) drop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dropQueued()
+}
+
+// dropStarts is drop for start auctions alone
+func (s *scheduler) dropStarts() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dropQueuedStarts()
+	s.cond.Broadcast()
+}
+
+// dropStops is drop for stop auctions alone
+func (s *scheduler) dropStops() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dropQueuedStops()
+	s.cond.Broadcast()
+}
+
+// must be called with the lock held
+func (s *scheduler) dropQueued() {
+	s.dropQueuedStarts()
+	s.dropQueuedStops()
+	s.cond.Broadcast()
+}
+
+// running auctions stay in starts and stops until they are done; must be
+// called with the lock held
+func (s *scheduler) dropQueuedStarts() {
+	if s.startQueue.Len() > 0 {
+		s.logger.Info("dropping-queued-start-auctions", lager.Data{"start-auctions": s.startQueue.Len()})
+	}
+
+	for key := range s.queuedAt[startAuctionKind] {
+		delete(s.starts, key)
+	}
+
+	s.startQueue.clear()
+	s.queuedAt[startAuctionKind] = map[auctionKey]time.Time{}
+	s.resubmitted = map[auctionKey]models.LRPStartAuction{}
+	s.signalRoom()
+}
+
+// must be called with the lock held
+func (s *scheduler) dropQueuedStops() {
+	if len(s.stopQueue) > 0 {
+		s.logger.Info("dropping-queued-stop-auctions", lager.Data{"stop-auctions": len(s.stopQueue)})
+	}
+
+	for key := range s.queuedAt[stopAuctionKind] {
+		delete(s.stops, key)
+	}
+
+	s.stopQueue = nil
+	s.queuedAt[stopAuctionKind] = map[auctionKey]time.Time{}
+	s.signalRoom()
+}
+
+// finished is closed once every worker has returned, which after stop is
+// once the auctions they were running are over
+func (s *scheduler) finished() <-chan struct{} {
+	finished := make(chan struct{})
+
+	go func() {
+		s.workers.Wait()
+		close(finished)
+	}()
+
+	return finished
+}
+
+func (s *scheduler) depth() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.startQueue.Len() + len(s.stopQueue)
+}
+
+// submitStart returns false if the auction is already queued, or running and
+// not a newer attempt
+func (s *scheduler) submitStart(startAuction models.LRPStartAuction) bool {
+	key := auctionKey{startAuction.ProcessGuid, startAuction.Index}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopped {
+		return false
+	}
+
+	if s.starts[key] {
+		running, ok := s.runningStarts[key]
+		if !ok || startAuction.Attempts <= running.Attempts {
+			return false
+		}
+
+		s.resubmitted[key] = startAuction
+		return true
+	}
+
+	s.queueStart(key, startAuction)
+
+	return true
+}
+
+// must be called with the lock held
+func (s *scheduler) queueStart(key auctionKey, startAuction models.LRPStartAuction) {
+	s.starts[key] = true
+	s.queuedAt[startAuctionKind][key] = time.Now()
+	s.startQueue.push(startAuction)
+	s.cond.Broadcast()
+}
+
+func (s *scheduler) submitStop(stopAuction models.LRPStopAuction) bool {
+	key := auctionKey{stopAuction.ProcessGuid, stopAuction.Index}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stopped || s.stops[key] {
+		return false
+	}
+
+	s.stops[key] = true
+	s.queuedAt[stopAuctionKind][key] = time.Now()
+	s.stopQueue = append(s.stopQueue, stopAuction)
+	s.cond.Broadcast()
+
+	return true
+}
+
+// room returns nil if the queue has room, and otherwise a channel that is
+// closed once it does.  Submitting to a full queue still queues the auction,
+// so a list of pending auctions can take the queue past its maximum.
+func (s *scheduler) room() <-chan struct{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.full() {
+		return nil
+	}
+
+	if s.roomChan == nil {
+		s.logger.Info("queue-full", lager.Data{"max-queued": s.maxQueued})
+		s.roomChan = make(chan struct{})
+	}
+
+	return s.roomChan
+}
+
+// must be called with the lock held
+func (s *scheduler) full() bool {
+	return !s.stopped && s.startQueue.Len()+len(s.stopQueue) >= s.maxQueued
+}
+
+// must be called with the lock held
+func (s *scheduler) signalRoom() {
+	if s.roomChan != nil && !s.full() {
+		close(s.roomChan)
+		s.roomChan = nil
+	}
+}
+
+func (s *scheduler) work() {
+	defer s.workers.Done()
+
+	for {
+		s.lock.Lock()
+		for !s.stopped && s.running <= s.numWorkers && !s.startQueue.ready() && len(s.stopQueue) == 0 {
+			s.cond.Wait()
+		}
+
+		if s.stopped || s.running > s.numWorkers {
+			s.running--
+			s.lock.Unlock()
+			return
+		}
+
+		//counting means asking the BBS, which is done without the lock, and
+		//then choosing afresh
+		if uncounted := s.startQueue.uncounted(); len(uncounted) > 0 {
+			s.lock.Unlock()
+			s.count(uncounted)
+			continue
+		}
+
+		if s.takeStopNext() {
+			stopAuction := s.stopQueue[0]
+			s.stopQueue = s.stopQueue[1:]
+			wait := s.dequeued(stopAuctionKind, auctionKey{stopAuction.ProcessGuid, stopAuction.Index})
+			s.cond.Broadcast()
+			s.signalRoom()
+			s.lock.Unlock()
+
+			s.waited(stopAuctionKind, wait)
+
+			s.runStop(stopAuction)
+
+			s.lock.Lock()
+			delete(s.stops, auctionKey{stopAuction.ProcessGuid, stopAuction.Index})
+			s.lock.Unlock()
+		} else {
+			startAuction := s.startQueue.pop()
+			key := auctionKey{startAuction.ProcessGuid, startAuction.Index}
+			wait := s.dequeued(startAuctionKind, key)
+			s.runningStarts[key] = startAuction
+			s.cond.Broadcast()
+			s.signalRoom()
+			s.lock.Unlock()
+
+			s.waited(startAuctionKind, wait)
+
+			s.runStart(startAuction)
+
+			s.lock.Lock()
+			s.startQueue.done(startAuction)
+			delete(s.starts, key)
+			delete(s.runningStarts, key)
+			if resubmitted, ok := s.resubmitted[key]; ok {
+				delete(s.resubmitted, key)
+				if !s.stopped {
+					s.queueStart(key, resubmitted)
+				}
+			}
+			s.cond.Broadcast()
+			s.lock.Unlock()
+		}
+	}
+}
+
+func (s *scheduler) count(processGuids []string) {
+	for _, processGuid := range processGuids {
+		runningInstances := s.runningInstances(processGuid)
+
+		s.lock.Lock()
+		s.startQueue.count(processGuid, runningInstances)
+		s.lock.Unlock()
+	}
+}
+
+// smooth weighted round robin between the two queues; must be called with
+// the lock held and at least one auction ready to run
+func (s *scheduler) takeStopNext() bool {
+	if len(s.stopQueue) == 0 {
+		return false
+	}
+
+	if !s.startQueue.ready() {
+		return true
+	}
+
+	s.startCredit += s.startShare
+	s.stopCredit += s.stopShare
+
+	if s.stopCredit > s.startCredit {
+		s.stopCredit -= s.startShare + s.stopShare
+		return true
+	}
+
+	s.startCredit -= s.startShare + s.stopShare
+	return false
+}
+
+// dequeued is how long an auction waited for a worker; must be called with
+// the lock held
+func (s *scheduler) dequeued(kind string, key auctionKey) time.Duration {
+	queuedAt := s.queuedAt[kind][key]
+	delete(s.queuedAt[kind], key)
+
+	return time.Since(queuedAt)
+}
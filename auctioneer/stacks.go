@@ -0,0 +1,118 @@
+package auctioneer
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+/*
+
+StackCompatibility lets an app that asks for one stack run on executors that
+advertise another, e.g. while moving from lucid64 to a newer rootfs:
+
+	{
+		"aliases": {"lucid": "lucid64"},
+		"compatible": {"lucid64": [["cflinuxfs2"], ["trusty64", "trusty64-beta"]]}
+	}
+
+Aliases name the same stack, and apply both to what start auctions ask for and
+to what executors advertise.  A stack's compatible stacks come in tiers, in
+order of preference, after the stack itself; the auction only moves on to a
+tier when the ones before it have no room.
+
+*/
+
+type StackCompatibility struct {
+	Aliases    map[string]string     `json:"aliases"`
+	Compatible map[string][][]string `json:"compatible"`
+}
+
+func (c StackCompatibility) Validate() error {
+	for alias, stack := range c.Aliases {
+		if _, chained := c.Aliases[stack]; chained {
+			return fmt.Errorf("stack alias '%s' refers to another alias, '%s'", alias, stack)
+		}
+	}
+
+	for stack, tiers := range c.Compatible {
+		if _, aliased := c.Aliases[stack]; aliased {
+			return fmt.Errorf("compatible stacks are given for alias '%s'", stack)
+		}
+
+		seen := map[string]bool{stack: true}
+		for _, tier := range tiers {
+			for _, compatibleStack := range tier {
+				compatibleStack = c.Resolve(compatibleStack)
+				if seen[compatibleStack] {
+					return fmt.Errorf("stack '%s' appears more than once among the stacks compatible with '%s'", compatibleStack, stack)
+				}
+				seen[compatibleStack] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// Resolve returns the stack that the given stack is an alias for, or the
+// stack itself
+func (c StackCompatibility) Resolve(stack string) string {
+	if resolved, ok := c.Aliases[stack]; ok {
+		return resolved
+	}
+
+	return stack
+}
+
+// Tiers returns the stacks that can run apps asking for the given stack, in
+// tiers in order of preference, starting with the stack itself
+func (c StackCompatibility) Tiers(stack string) [][]string {
+	stack = c.Resolve(stack)
+
+	tiers := [][]string{{stack}}
+	for _, tier := range c.Compatible[stack] {
+		resolvedTier := []string{}
+		for _, compatibleStack := range tier {
+			resolvedTier = append(resolvedTier, c.Resolve(compatibleStack))
+		}
+		tiers = append(tiers, resolvedTier)
+	}
+
+	return tiers
+}
+
+// advertisedAs returns the stacks, and their aliases
+func (c StackCompatibility) advertisedAs(stacks []string) []string {
+	advertised := append([]string{}, stacks...)
+
+	wanted := map[string]bool{}
+	for _, stack := range stacks {
+		wanted[stack] = true
+	}
+
+	for alias, stack := range c.Aliases {
+		if wanted[stack] {
+			advertised = append(advertised, alias)
+		}
+	}
+
+	return advertised
+}
+
+func (c StackCompatibility) executorsForStacks(executors []models.ExecutorPresence, stacks []string) []models.ExecutorPresence {
+	wanted := map[string]bool{}
+	for _, stack := range stacks {
+		wanted[stack] = true
+	}
+
+	filteredExecutors := []models.ExecutorPresence{}
+
+	for _, executor := range executors {
+		if wanted[c.Resolve(executor.Stack)] {
+			filteredExecutors = append(filteredExecutors, executor)
+		}
+	}
+
+	return filteredExecutors
+}
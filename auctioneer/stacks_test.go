@@ -0,0 +1,67 @@
+package auctioneer_test
+
+import (
+	. "github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stack compatibility", func() {
+	var stacks StackCompatibility
+
+	BeforeEach(func() {
+		stacks = StackCompatibility{
+			Aliases: map[string]string{
+				"lucid":  "lucid64",
+				"cflfs2": "cflinuxfs2",
+			},
+			Compatible: map[string][][]string{
+				"lucid64": {{"cflfs2"}, {"trusty64", "trusty64-beta"}},
+			},
+		}
+	})
+
+	It("should resolve aliases", func() {
+		Ω(stacks.Resolve("lucid")).Should(Equal("lucid64"))
+		Ω(stacks.Resolve("lucid64")).Should(Equal("lucid64"))
+	})
+
+	It("should give the compatible stacks in tiers, after the stack itself", func() {
+		Ω(stacks.Tiers("lucid")).Should(Equal([][]string{
+			{"lucid64"},
+			{"cflinuxfs2"},
+			{"trusty64", "trusty64-beta"},
+		}))
+	})
+
+	It("should give just the stack when nothing is compatible with it", func() {
+		Ω(stacks.Tiers(".Net")).Should(Equal([][]string{{".Net"}}))
+	})
+
+	Describe("Validate", func() {
+		It("should accept sensible compatibility", func() {
+			Ω(stacks.Validate()).ShouldNot(HaveOccurred())
+		})
+
+		It("should reject aliases of aliases", func() {
+			stacks.Aliases["lucid-old"] = "lucid"
+			Ω(stacks.Validate()).Should(HaveOccurred())
+		})
+
+		It("should reject compatible stacks given for an alias", func() {
+			stacks.Compatible["lucid"] = [][]string{{"trusty64"}}
+			Ω(stacks.Validate()).Should(HaveOccurred())
+		})
+
+		It("should reject a stack that appears in more than one tier", func() {
+			stacks.Compatible["lucid64"] = [][]string{{"cflinuxfs2"}, {"cflfs2"}}
+			Ω(stacks.Validate()).Should(HaveOccurred())
+		})
+
+		It("should reject a stack that is compatible with itself", func() {
+			stacks.Compatible["lucid64"] = [][]string{{"lucid"}}
+			Ω(stacks.Validate()).Should(HaveOccurred())
+		})
+	})
+})
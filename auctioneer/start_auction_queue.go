@@ -0,0 +1,238 @@
+package auctioneer
+
+import (
+	"container/heap"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+/*
+
+Start auctions are queued per process guid and handed out in rounds (deficit
+round robin with every auction costing the same):
+
+	- Each process guid with auctions waiting gets one auction run per round
+	- Within a round, process guids are served in the order the StartAuctionPriority gives their next auction
+	- Within a process guid, auctions are taken in priority order, then first come first served
+	- A process guid with maxPerProcess auctions running is skipped until one finishes (0 means no limit)
+	- A process guid's running instances are counted before it is next in the running to be served, see uncounted
+
+So one app scaling to hundreds of instances only ever gets one turn in
+between each of the other apps waiting to be placed.
+
+The queue is not safe for concurrent use; the scheduler guards it.
+
+*/
+
+type startAuctionQueue struct {
+	priority      StartAuctionPriority
+	maxPerProcess int
+
+	flows  map[string]*startAuctionFlow
+	length int
+	round  uint64
+	seq    uint64
+}
+
+type startAuctionFlow struct {
+	auctions   *startAuctionHeap
+	running    int
+	lastServed uint64
+
+	//the process's running instances, as last counted
+	runningInstances int
+	counted          bool
+	counting         bool
+}
+
+func newStartAuctionQueue(priority StartAuctionPriority, maxPerProcess int) *startAuctionQueue {
+	return &startAuctionQueue{
+		priority:      priority,
+		maxPerProcess: maxPerProcess,
+		flows:         map[string]*startAuctionFlow{},
+		round:         1,
+	}
+}
+
+func (q *startAuctionQueue) Len() int {
+	return q.length
+}
+
+func (q *startAuctionQueue) push(auction models.LRPStartAuction) {
+	flow, ok := q.flows[auction.ProcessGuid]
+	if !ok {
+		flow = &startAuctionFlow{auctions: &startAuctionHeap{priority: q.priority}}
+		q.flows[auction.ProcessGuid] = flow
+	}
+
+	queued := QueuedStartAuction{
+		LRPStartAuction:  auction,
+		RunningInstances: flow.runningInstances,
+	}
+
+	q.seq++
+	heap.Push(flow.auctions, prioritizedStartAuction{queued, q.seq})
+	q.length++
+}
+
+// uncounted returns the process guids, among those that could be served
+// next, whose running instances haven't been counted since they were last
+// served.  They are taken to be being counted until count is called.
+func (q *startAuctionQueue) uncounted() []string {
+	processGuids := []string{}
+	for processGuid, flow := range q.flows {
+		if q.eligible(flow) && !flow.counted && !flow.counting {
+			flow.counting = true
+			processGuids = append(processGuids, processGuid)
+		}
+	}
+
+	return processGuids
+}
+
+// count records the process's running instances, reordering its waiting
+// auctions by them
+func (q *startAuctionQueue) count(processGuid string, runningInstances int) {
+	flow, ok := q.flows[processGuid]
+	if !ok {
+		return
+	}
+
+	flow.runningInstances = runningInstances
+	flow.counted, flow.counting = true, false
+
+	for i := range flow.auctions.auctions {
+		flow.auctions.auctions[i].RunningInstances = runningInstances
+	}
+	heap.Init(flow.auctions)
+}
+
+// ready reports whether pop has anything to return
+func (q *startAuctionQueue) ready() bool {
+	for _, flow := range q.flows {
+		if q.eligible(flow) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (q *startAuctionQueue) pop() models.LRPStartAuction {
+	next := q.next()
+	if next == nil {
+		q.round++
+		next = q.next()
+	}
+
+	next.lastServed = q.round
+	next.running++
+	next.counted = false
+	q.length--
+
+	return heap.Pop(next.auctions).(prioritizedStartAuction).LRPStartAuction
+}
+
+// done must be called once a popped auction has finished running
+func (q *startAuctionQueue) done(auction models.LRPStartAuction) {
+	flow, ok := q.flows[auction.ProcessGuid]
+	if !ok {
+		return
+	}
+
+	flow.running--
+	if flow.running == 0 && flow.auctions.Len() == 0 {
+		delete(q.flows, auction.ProcessGuid)
+	}
+}
+
+func (q *startAuctionQueue) clear() {
+	for processGuid, flow := range q.flows {
+		flow.auctions.auctions = nil
+		if flow.running == 0 {
+			delete(q.flows, processGuid)
+		}
+	}
+
+	q.length = 0
+}
+
+// reconfigure reorders the waiting auctions by the new priority
+func (q *startAuctionQueue) reconfigure(priority StartAuctionPriority, maxPerProcess int) {
+	q.priority = priority
+	q.maxPerProcess = maxPerProcess
+
+	for _, flow := range q.flows {
+		flow.auctions.priority = priority
+		heap.Init(flow.auctions)
+	}
+}
+
+// the eligible flow, not yet served this round, with the highest priority
+// auction at its head
+func (q *startAuctionQueue) next() *startAuctionFlow {
+	var best *startAuctionFlow
+	for _, flow := range q.flows {
+		if !q.eligible(flow) || flow.lastServed >= q.round {
+			continue
+		}
+
+		if best == nil || best.auctions.before(flow.auctions.auctions[0], best.auctions.auctions[0]) {
+			best = flow
+		}
+	}
+
+	return best
+}
+
+func (q *startAuctionQueue) eligible(flow *startAuctionFlow) bool {
+	if flow.auctions.Len() == 0 {
+		return false
+	}
+
+	return q.maxPerProcess <= 0 || flow.running < q.maxPerProcess
+}
+
+type prioritizedStartAuction struct {
+	QueuedStartAuction
+	seq uint64
+}
+
+// the start auctions for one process guid, ordered by priority and then by
+// arrival
+type startAuctionHeap struct {
+	priority StartAuctionPriority
+	auctions []prioritizedStartAuction
+}
+
+func (h *startAuctionHeap) before(a, b prioritizedStartAuction) bool {
+	if h.priority.Less(a.QueuedStartAuction, b.QueuedStartAuction) {
+		return true
+	}
+	if h.priority.Less(b.QueuedStartAuction, a.QueuedStartAuction) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
+func (h *startAuctionHeap) Len() int {
+	return len(h.auctions)
+}
+
+func (h *startAuctionHeap) Less(i, j int) bool {
+	return h.before(h.auctions[i], h.auctions[j])
+}
+
+func (h *startAuctionHeap) Swap(i, j int) {
+	h.auctions[i], h.auctions[j] = h.auctions[j], h.auctions[i]
+}
+
+func (h *startAuctionHeap) Push(x interface{}) {
+	h.auctions = append(h.auctions, x.(prioritizedStartAuction))
+}
+
+func (h *startAuctionHeap) Pop() interface{} {
+	last := h.auctions[len(h.auctions)-1]
+	h.auctions = h.auctions[:len(h.auctions)-1]
+	return last
+}
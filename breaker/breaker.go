@@ -0,0 +1,279 @@
+/*
+Package breaker keeps a circuit breaker for each rep, so that auctions can
+leave out the reps that aren't answering instead of waiting out their
+timeouts round after round.
+
+A rep's breaker is closed while its requests go through.  It opens once at
+least MinRequests of its last Window requests have been made and the fraction
+of them that failed or timed out reaches FailureRate.  An open breaker keeps
+the rep out of auctions for OpenFor, and then half-opens: the rep is let into
+auctions until one of them asks it to bid, which is the probe, and the
+breaker closes if that request succeeds or opens again if it fails.  Being let
+into an auction doesn't use the probe up, since an auction needn't ask every
+rep it is given; a probe that is asked but never answered is given up after
+OpenFor.
+
+The breakers serve their reps' state as JSON:
+
+	breakers := breaker.New(breaker.Config{OpenFor: time.Minute}, logger)
+	client.SetRequestObserver(func(repGuid string, err error) {
+		breakers.Record(repGuid, outcomeOf(err))
+	})
+	runner.SetBidObserver(func(repGuids []string) {
+		for _, repGuid := range repGuids {
+			breakers.Asked(repGuid)
+		}
+	})
+
+	http.Handle("/breakers", breakers)
+*/
+package breaker
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	DefaultWindow      = 20
+	DefaultMinRequests = 5
+	DefaultFailureRate = 0.5
+	DefaultOpenFor     = 30 * time.Second
+)
+
+// Outcome is how a request to a rep went
+type Outcome int
+
+const (
+	Succeeded Outcome = iota
+	Failed
+	TimedOut
+)
+
+type State string
+
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half-open"
+)
+
+// Config says when breakers open, and for how long; settings left zero take
+// their defaults
+type Config struct {
+	Window      int
+	MinRequests int
+	FailureRate float64
+	OpenFor     time.Duration
+}
+
+// RepState is what a rep's breaker reports about it.  The counts are of the
+// requests in its window.
+type RepState struct {
+	RepGuid     string    `json:"rep_guid"`
+	State       State     `json:"state"`
+	Requests    int       `json:"requests"`
+	Failures    int       `json:"failures"`
+	Timeouts    int       `json:"timeouts"`
+	FailureRate float64   `json:"failure_rate"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+type Breakers struct {
+	config Config
+	logger lager.Logger
+
+	lock sync.Mutex
+	reps map[string]*rep
+}
+
+type rep struct {
+	state     State
+	outcomes  []Outcome //oldest first
+	changedAt time.Time
+
+	//when a half-open breaker's rep was asked to bid, zero until it is
+	probedAt time.Time
+}
+
+func New(config Config, logger lager.Logger) *Breakers {
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+
+	if config.MinRequests <= 0 {
+		config.MinRequests = DefaultMinRequests
+	}
+
+	if config.FailureRate <= 0 {
+		config.FailureRate = DefaultFailureRate
+	}
+
+	if config.OpenFor <= 0 {
+		config.OpenFor = DefaultOpenFor
+	}
+
+	return &Breakers{
+		config: config,
+		logger: logger.Session("rep-breakers"),
+		reps:   map[string]*rep{},
+	}
+}
+
+// Record counts a request to the rep, opening or closing its breaker if
+// that's called for
+func (b *Breakers) Record(repGuid string, outcome Outcome) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	r, ok := b.reps[repGuid]
+	if !ok {
+		r = &rep{state: Closed, changedAt: time.Now()}
+		b.reps[repGuid] = r
+	}
+
+	r.outcomes = append(r.outcomes, outcome)
+	if len(r.outcomes) > b.config.Window {
+		r.outcomes = r.outcomes[len(r.outcomes)-b.config.Window:]
+	}
+
+	switch r.state {
+	case Closed:
+		state := r.report(repGuid)
+		if state.Requests >= b.config.MinRequests && state.FailureRate >= b.config.FailureRate {
+			b.change(repGuid, r, Open)
+		}
+
+	case HalfOpen:
+		if outcome == Succeeded {
+			b.change(repGuid, r, Closed)
+		} else {
+			b.change(repGuid, r, Open)
+		}
+
+	//requests made before the breaker opened may still be coming back
+	case Open:
+	}
+}
+
+// Allow returns whether the rep may be asked to take part in an auction
+func (b *Breakers) Allow(repGuid string) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	r, ok := b.reps[repGuid]
+	if !ok {
+		return true
+	}
+
+	now := time.Now()
+
+	switch r.state {
+	case Open:
+		if now.Sub(r.changedAt) < b.config.OpenFor {
+			return false
+		}
+
+		b.change(repGuid, r, HalfOpen)
+		return true
+
+	case HalfOpen:
+		return r.probedAt.IsZero() || now.Sub(r.probedAt) >= b.config.OpenFor
+	}
+
+	return true
+}
+
+// Asked notes that the rep was asked to bid, which is the probe a half-open
+// breaker waits on before letting the rep into any more auctions
+func (b *Breakers) Asked(repGuid string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	r, ok := b.reps[repGuid]
+	if !ok || r.state != HalfOpen {
+		return
+	}
+
+	now := time.Now()
+	if r.probedAt.IsZero() || now.Sub(r.probedAt) >= b.config.OpenFor {
+		r.probedAt = now
+	}
+}
+
+// States returns every rep's breaker, in order of rep
+func (b *Breakers) States() []RepState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	states := make([]RepState, 0, len(b.reps))
+	for repGuid, r := range b.reps {
+		states = append(states, r.report(repGuid))
+	}
+
+	sort.Sort(byRepGuid(states))
+
+	return states
+}
+
+func (b *Breakers) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b.States())
+}
+
+// a breaker that closes starts counting afresh, so that the failures that
+// opened it don't open it again
+func (b *Breakers) change(repGuid string, r *rep, state State) {
+	report := r.report(repGuid)
+
+	r.state = state
+	r.changedAt = time.Now()
+	r.probedAt = time.Time{}
+
+	if state == Closed {
+		r.outcomes = nil
+	}
+
+	b.logger.Info(string(state), lager.Data{
+		"rep-guid":     repGuid,
+		"requests":     report.Requests,
+		"failures":     report.Failures,
+		"timeouts":     report.Timeouts,
+		"failure-rate": report.FailureRate,
+	})
+}
+
+func (r *rep) report(repGuid string) RepState {
+	state := RepState{
+		RepGuid:   repGuid,
+		State:     r.state,
+		Requests:  len(r.outcomes),
+		ChangedAt: r.changedAt,
+	}
+
+	for _, outcome := range r.outcomes {
+		switch outcome {
+		case Failed:
+			state.Failures++
+		case TimedOut:
+			state.Timeouts++
+		}
+	}
+
+	if state.Requests > 0 {
+		state.FailureRate = float64(state.Failures+state.Timeouts) / float64(state.Requests)
+	}
+
+	return state
+}
+
+type byRepGuid []RepState
+
+func (s byRepGuid) Len() int           { return len(s) }
+func (s byRepGuid) Less(i, j int) bool { return s[i].RepGuid < s[j].RepGuid }
+func (s byRepGuid) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,150 @@
+package breaker_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/cloudfoundry-incubator/auctioneer/breaker"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Breakers", func() {
+	var breakers *Breakers
+	var logger *lagertest.TestLogger
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		breakers = New(Config{
+			Window:      4,
+			MinRequests: 2,
+			FailureRate: 0.5,
+			OpenFor:     50 * time.Millisecond,
+		}, logger)
+	})
+
+	record := func(repGuid string, outcomes ...Outcome) {
+		for _, outcome := range outcomes {
+			breakers.Record(repGuid, outcome)
+		}
+	}
+
+	It("should allow reps it has heard nothing about", func() {
+		Ω(breakers.Allow("rep-a")).Should(BeTrue())
+		Ω(breakers.States()).Should(BeEmpty())
+	})
+
+	It("should not open on fewer than the minimum requests", func() {
+		record("rep-a", TimedOut)
+
+		Ω(breakers.Allow("rep-a")).Should(BeTrue())
+		Ω(breakers.States()[0].State).Should(Equal(Closed))
+	})
+
+	It("should stay closed while the failure rate is below the threshold", func() {
+		record("rep-a", Succeeded, Succeeded, Succeeded, Failed)
+
+		Ω(breakers.Allow("rep-a")).Should(BeTrue())
+	})
+
+	It("should only count the requests in its window", func() {
+		record("rep-a", Failed, Succeeded, Succeeded, Succeeded, Succeeded)
+
+		state := breakers.States()[0]
+		Ω(state.Requests).Should(Equal(4))
+		Ω(state.Failures).Should(BeZero())
+	})
+
+	Context("when a rep fails too often", func() {
+		BeforeEach(func() {
+			record("rep-a", Succeeded, TimedOut, Failed)
+		})
+
+		It("should keep it out of auctions, and report why", func() {
+			Ω(breakers.Allow("rep-a")).Should(BeFalse())
+			Ω(breakers.Allow("rep-b")).Should(BeTrue())
+
+			state := breakers.States()[0]
+			Ω(state.State).Should(Equal(Open))
+			Ω(state.Timeouts).Should(Equal(1))
+			Ω(state.Failures).Should(Equal(1))
+			Ω(state.FailureRate).Should(BeNumerically("~", 2.0/3.0))
+
+			Ω(logger.TestSink.Buffer).Should(gbytes.Say("rep-breakers.open"))
+		})
+
+		Context("once it has been open for long enough", func() {
+			BeforeEach(func() {
+				time.Sleep(60 * time.Millisecond)
+			})
+
+			It("should let it into auctions until one asks it to bid, as a probe", func() {
+				Ω(breakers.Allow("rep-a")).Should(BeTrue())
+				Ω(breakers.Allow("rep-a")).Should(BeTrue())
+				Ω(breakers.States()[0].State).Should(Equal(HalfOpen))
+
+				breakers.Asked("rep-a")
+				Ω(breakers.Allow("rep-a")).Should(BeFalse())
+			})
+
+			It("should close if the probe succeeds", func() {
+				breakers.Allow("rep-a")
+				breakers.Asked("rep-a")
+				record("rep-a", Succeeded)
+
+				Ω(breakers.Allow("rep-a")).Should(BeTrue())
+				Ω(breakers.States()[0].State).Should(Equal(Closed))
+				Ω(breakers.States()[0].Requests).Should(BeZero())
+			})
+
+			It("should open again if the probe fails", func() {
+				breakers.Allow("rep-a")
+				breakers.Asked("rep-a")
+				record("rep-a", TimedOut)
+
+				Ω(breakers.Allow("rep-a")).Should(BeFalse())
+				Ω(breakers.States()[0].State).Should(Equal(Open))
+			})
+
+			It("should let another auction probe if the probe isn't answered", func() {
+				breakers.Allow("rep-a")
+				breakers.Asked("rep-a")
+
+				Eventually(func() bool { return breakers.Allow("rep-a") }).Should(BeTrue())
+			})
+		})
+
+		It("should ignore the rep being asked while its breaker is open", func() {
+			breakers.Asked("rep-a")
+			time.Sleep(60 * time.Millisecond)
+
+			Ω(breakers.Allow("rep-a")).Should(BeTrue())
+			Ω(breakers.Allow("rep-a")).Should(BeTrue())
+		})
+	})
+
+	It("should serve the reps' breakers as JSON", func() {
+		record("rep-b", Succeeded)
+		record("rep-a", Failed, Failed)
+
+		recorder := httptest.NewRecorder()
+		breakers.ServeHTTP(recorder, &http.Request{Method: "GET"})
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.HeaderMap.Get("Content-Type")).Should(Equal("application/json"))
+
+		var states []RepState
+		err := json.Unmarshal(recorder.Body.Bytes(), &states)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(states).Should(HaveLen(2))
+		Ω(states[0].RepGuid).Should(Equal("rep-a"))
+		Ω(states[0].State).Should(Equal(Open))
+		Ω(states[1].RepGuid).Should(Equal("rep-b"))
+		Ω(states[1].State).Should(Equal(Closed))
+	})
+})
@@ -0,0 +1,141 @@
+/*
+Package health serves an auctioneer's health over HTTP:
+
+	GET /healthz   200 if the process is up and its dependencies answer, 503 otherwise
+	GET /ready     200 if it holds the lock and is watching for auctions (or has
+	               been paused), 503 otherwise
+	GET /status    200 with what the auctioneer reports about itself
+
+Each responds with a JSON body.
+*/
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+)
+
+// DefaultCheckTimeout is how long a Check may take before it counts as failed
+const DefaultCheckTimeout = 2 * time.Second
+
+// Check returns nil if a dependency can be reached
+type Check func() error
+
+type StatusReporter interface {
+	Status() auctioneer.Status
+}
+
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e TimeoutError) Error() string {
+	return "timed out after " + e.Timeout.String()
+}
+
+type handler struct {
+	reporter StatusReporter
+	checks   map[string]Check
+	timeout  time.Duration
+	mux      *http.ServeMux
+}
+
+func NewHandler(reporter StatusReporter, checks map[string]Check, timeout time.Duration) http.Handler {
+	h := &handler{
+		reporter: reporter,
+		checks:   checks,
+		timeout:  timeout,
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("/healthz", h.healthz)
+	h.mux.HandleFunc("/ready", h.ready)
+	h.mux.HandleFunc("/status", h.status)
+
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// the result of each check, "ok" or why it failed
+func (h *handler) healthz(w http.ResponseWriter, r *http.Request) {
+	results := map[string]string{}
+	healthy := true
+
+	lock := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			err := runWithTimeout(check, h.timeout)
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			if err != nil {
+				healthy = false
+				results[name] = err.Error()
+			} else {
+				results[name] = "ok"
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if !healthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, results)
+}
+
+func (h *handler) ready(w http.ResponseWriter, r *http.Request) {
+	status := h.reporter.Status()
+
+	code := http.StatusOK
+	if !status.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, map[string]bool{
+		"ready":                   status.Ready(),
+		"have_lock":               status.HaveLock,
+		"watching_start_auctions": status.WatchingStartAuctions,
+		"watching_stop_auctions":  status.WatchingStopAuctions,
+		"start_auctions_paused":   status.StartAuctionsPaused,
+		"stop_auctions_paused":    status.StopAuctionsPaused,
+	})
+}
+
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.reporter.Status())
+}
+
+func runWithTimeout(check Check, timeout time.Duration) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- check()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(timeout):
+		return TimeoutError{timeout}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,171 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+	. "github.com/cloudfoundry-incubator/auctioneer/health"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeReporter struct {
+	status auctioneer.Status
+}
+
+func (r *fakeReporter) Status() auctioneer.Status {
+	return r.status
+}
+
+var _ = Describe("Health", func() {
+	var reporter *fakeReporter
+	var checks map[string]Check
+	var handler http.Handler
+
+	get := func(path string) (int, map[string]interface{}) {
+		request, err := http.NewRequest("GET", path, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		Ω(recorder.HeaderMap.Get("Content-Type")).Should(Equal("application/json"))
+
+		body := map[string]interface{}{}
+		err = json.Unmarshal(recorder.Body.Bytes(), &body)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		return recorder.Code, body
+	}
+
+	BeforeEach(func() {
+		reporter = &fakeReporter{}
+		checks = map[string]Check{
+			"etcd": func() error { return nil },
+			"nats": func() error { return nil },
+		}
+	})
+
+	JustBeforeEach(func() {
+		handler = NewHandler(reporter, checks, 100*time.Millisecond)
+	})
+
+	Describe("/healthz", func() {
+		It("should be OK when every check passes", func() {
+			code, body := get("/healthz")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body).Should(Equal(map[string]interface{}{"etcd": "ok", "nats": "ok"}))
+		})
+
+		Context("when a check fails", func() {
+			BeforeEach(func() {
+				checks["nats"] = func() error { return errors.New("no pong") }
+			})
+
+			It("should be unavailable, and say why", func() {
+				code, body := get("/healthz")
+				Ω(code).Should(Equal(http.StatusServiceUnavailable))
+				Ω(body).Should(Equal(map[string]interface{}{"etcd": "ok", "nats": "no pong"}))
+			})
+		})
+
+		Context("when a check hangs", func() {
+			BeforeEach(func() {
+				checks["etcd"] = func() error {
+					time.Sleep(time.Second)
+					return nil
+				}
+			})
+
+			It("should give up on it", func() {
+				code, body := get("/healthz")
+				Ω(code).Should(Equal(http.StatusServiceUnavailable))
+				Ω(body["etcd"]).Should(Equal(TimeoutError{100 * time.Millisecond}.Error()))
+			})
+		})
+	})
+
+	Describe("/ready", func() {
+		Context("when the auctioneer holds the lock and is watching", func() {
+			BeforeEach(func() {
+				reporter.status = auctioneer.Status{
+					HaveLock:              true,
+					WatchingStartAuctions: true,
+					WatchingStopAuctions:  true,
+				}
+			})
+
+			It("should be OK", func() {
+				code, body := get("/ready")
+				Ω(code).Should(Equal(http.StatusOK))
+				Ω(body["ready"]).Should(BeTrue())
+			})
+		})
+
+		Context("when the auctioneer is on standby", func() {
+			It("should be unavailable", func() {
+				code, body := get("/ready")
+				Ω(code).Should(Equal(http.StatusServiceUnavailable))
+				Ω(body["ready"]).Should(BeFalse())
+				Ω(body["have_lock"]).Should(BeFalse())
+			})
+		})
+
+		Context("when the auctioneer has been paused", func() {
+			BeforeEach(func() {
+				reporter.status = auctioneer.Status{
+					HaveLock:             true,
+					WatchingStopAuctions: true,
+					StartAuctionsPaused:  true,
+				}
+			})
+
+			It("should be OK, and say so", func() {
+				code, body := get("/ready")
+				Ω(code).Should(Equal(http.StatusOK))
+				Ω(body["start_auctions_paused"]).Should(BeTrue())
+				Ω(body["stop_auctions_paused"]).Should(BeFalse())
+			})
+		})
+
+		Context("when a watch is down", func() {
+			BeforeEach(func() {
+				reporter.status = auctioneer.Status{
+					HaveLock:              true,
+					WatchingStartAuctions: true,
+				}
+			})
+
+			It("should be unavailable", func() {
+				code, body := get("/ready")
+				Ω(code).Should(Equal(http.StatusServiceUnavailable))
+				Ω(body["watching_stop_auctions"]).Should(BeFalse())
+			})
+		})
+	})
+
+	Describe("/status", func() {
+		BeforeEach(func() {
+			reporter.status = auctioneer.Status{
+				AuctioneerID:          "some-guid",
+				HaveLock:              true,
+				InFlightStartAuctions: 3,
+				LastAuctionAt:         time.Date(2014, 7, 1, 12, 0, 0, 0, time.UTC),
+			}
+		})
+
+		It("should report the auctioneer's status", func() {
+			code, body := get("/status")
+			Ω(code).Should(Equal(http.StatusOK))
+			Ω(body["auctioneer_id"]).Should(Equal("some-guid"))
+			Ω(body["have_lock"]).Should(BeTrue())
+			Ω(body["in_flight_start_auctions"]).Should(BeNumerically("==", 3))
+			Ω(body["last_auction_at"]).Should(Equal("2014-07-01T12:00:00Z"))
+		})
+	})
+})
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cloudfoundry-incubator/cf-lager"
@@ -12,12 +16,22 @@ import (
 
 	"github.com/cloudfoundry-incubator/auction/auctionrunner"
 	"github.com/cloudfoundry-incubator/auction/communication/nats/auction_nats_client"
+	"github.com/cloudfoundry-incubator/auction/communication/nats/nats_muxer"
+	"github.com/cloudfoundry-incubator/auctioneer/admin"
+	"github.com/cloudfoundry-incubator/auctioneer/algorithms"
 	"github.com/cloudfoundry-incubator/auctioneer/auctioneer"
+	"github.com/cloudfoundry-incubator/auctioneer/breaker"
+	"github.com/cloudfoundry-incubator/auctioneer/health"
+	"github.com/cloudfoundry-incubator/auctioneer/metrics"
+	"github.com/cloudfoundry-incubator/runtime-schema/bbs/shared"
 	"github.com/cloudfoundry/gunk/timeprovider"
+	"github.com/cloudfoundry/storeadapter"
 	"github.com/cloudfoundry/storeadapter/etcdstoreadapter"
 	"github.com/cloudfoundry/storeadapter/workerpool"
 	"github.com/cloudfoundry/yagnats"
 	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+	"github.com/tedsuo/ifrit/http_server"
 	"github.com/tedsuo/ifrit/sigmon"
 )
 
@@ -51,12 +65,120 @@ var maxConcurrent = flag.Int(
 	"Maximum number of concurrent auctions",
 )
 
+var maxQueuedAuctions = flag.Int(
+	"maxQueuedAuctions",
+	1000,
+	"Maximum number of auctions waiting for a worker before the auctioneer stops consuming watch events",
+)
+
+var startAuctionShare = flag.Int(
+	"startAuctionShare",
+	3,
+	"Relative share of the workers given to start auctions when stop auctions are also waiting",
+)
+
+var stopAuctionShare = flag.Int(
+	"stopAuctionShare",
+	1,
+	"Relative share of the workers given to stop auctions when start auctions are also waiting",
+)
+
+var maxConcurrentPerProcess = flag.Int(
+	"maxConcurrentPerProcess",
+	0,
+	"Maximum number of concurrent start auctions for a single process guid (0 for no limit)",
+)
+
+var startAuctionPriority = flag.String(
+	"startAuctionPriority",
+	strings.Join(auctioneer.DefaultStartAuctionPriorityCriteria, ","),
+	"comma-separated criteria used, in turn, to order waiting start auctions (no-running-instances, index, age)",
+)
+
 var maxRounds = flag.Int(
 	"maxRounds",
 	auctionrunner.DefaultStartAuctionRules.MaxRounds,
 	"Maximum number of rounds to run before declaring failure",
 )
 
+var auctionAlgorithm = flag.String(
+	"auctionAlgorithm",
+	auctionrunner.DefaultStartAuctionRules.Algorithm,
+	"Algorithm used to run start auctions ("+strings.Join(algorithms.Names(), ", ")+")",
+)
+
+var maxBiddingPoolFraction = flag.Float64(
+	"maxBiddingPoolFraction",
+	auctionrunner.DefaultStartAuctionRules.MaxBiddingPoolFraction,
+	"Fraction of the reps of a stack asked to bid in each round of a start auction",
+)
+
+var minBiddingPool = flag.Int(
+	"minBiddingPool",
+	auctionrunner.DefaultStartAuctionRules.MinBiddingPool,
+	"Minimum number of reps asked to bid in each round of a start auction",
+)
+
+var numChoices = flag.Int(
+	"numChoices",
+	algorithms.DefaultNumChoices,
+	"Number of reps asked to bid in each round of a power_of_d_choices start auction",
+)
+
+var placement = flag.String(
+	"placement",
+	algorithms.PlacementSpread,
+	"How start auctions place instances: spread (onto the emptiest reps) or pack (onto the fullest reps that fit; bin_pack and power_of_d_choices only)",
+)
+
+var antiAffinity = flag.String(
+	"antiAffinity",
+	algorithms.AntiAffinityNone,
+	"Whether start auctions keep instances of a process off reps that already host it: none, soft (prefer other reps) or hard (only other reps); start auctions may override this",
+)
+
+var stackStartAuctionRules = flag.String(
+	"stackStartAuctionRules",
+	"",
+	"JSON object of per-stack overrides of the start auction rules, e.g. {\"lucid64\":{\"algorithm\":\"pick_best\"}}",
+)
+
+var configFile = flag.String(
+	"configFile",
+	"",
+	"Path to a JSON file of settings, named as the flags are, that take precedence over the flags and also give stack compatibility; it is reloaded on SIGHUP",
+)
+
+var startAuctionMaxAttempts = flag.Int(
+	"startAuctionMaxAttempts",
+	5,
+	"Number of times a start auction is attempted before it is given up on (1 to never retry)",
+)
+
+var startAuctionRetryMinBackoff = flag.Duration(
+	"startAuctionRetryMinBackoff",
+	time.Second,
+	"How long a failed start auction waits before its first retry; doubles with each further failure",
+)
+
+var startAuctionRetryMaxBackoff = flag.Duration(
+	"startAuctionRetryMaxBackoff",
+	30*time.Second,
+	"Longest a failed start auction waits before being retried",
+)
+
+var startAuctionRetryDeadline = flag.Duration(
+	"startAuctionRetryDeadline",
+	5*time.Minute,
+	"How long after its first attempt a start auction may still be retried (0 for no deadline)",
+)
+
+var failedStartAuctionTTL = flag.Duration(
+	"failedStartAuctionTTL",
+	auctioneer.DefaultFailedStartAuctionTTL,
+	"How long the record of why a start auction failed is kept in etcd",
+)
+
 var auctionNATSTimeout = flag.Duration(
 	"natsAuctionTimeout",
 	time.Second,
@@ -69,6 +191,114 @@ var auctionRunTimeout = flag.Duration(
 	"How long the auction will wait to hear that the chosen winner has succesfully started the app",
 )
 
+var drainTimeout = flag.Duration(
+	"drainTimeout",
+	10*time.Second,
+	"How long to wait, on shutdown, for running auctions to finish before abandoning them and releasing the lock",
+)
+
+var executorDrainInterval = flag.Duration(
+	"executorDrainInterval",
+	auctioneer.DefaultExecutorDrainInterval,
+	"How often to move an instance off the cordoned executors being drained",
+)
+
+var executorDrainReplacementTimeout = flag.Duration(
+	"executorDrainReplacementTimeout",
+	auctioneer.DefaultExecutorDrainReplacementTimeout,
+	"How long to wait for the replacement of an instance being drained to run before leaving the instance where it is",
+)
+
+var rebalanceInterval = flag.Duration(
+	"rebalanceInterval",
+	0,
+	"How often to sample the reps' load and move instances off the most loaded; instances aren't moved if 0",
+)
+
+var rebalanceThreshold = flag.Float64(
+	"rebalanceThreshold",
+	auctioneer.DefaultRebalanceThreshold,
+	"How much more loaded (0 to 1) than the least loaded rep the most loaded may be before its instances are moved",
+)
+
+var rebalanceMaxMoves = flag.Int(
+	"rebalanceMaxMoves",
+	auctioneer.DefaultRebalanceMaxMoves,
+	"Maximum number of instances to start moving each rebalance interval",
+)
+
+var rebalanceMaxUnavailable = flag.Int(
+	"rebalanceMaxUnavailable",
+	auctioneer.DefaultRebalanceMaxUnavailable,
+	"Maximum number of a process's instances that may be being moved, or not running, before no more are moved",
+)
+
+var rebalanceReplacementTimeout = flag.Duration(
+	"rebalanceReplacementTimeout",
+	auctioneer.DefaultRebalanceReplacementTimeout,
+	"How long to wait for the replacement of an instance being moved to run before leaving the instance where it is",
+)
+
+var rebalanceDryRun = flag.Bool(
+	"rebalanceDryRun",
+	false,
+	"Only log the instances that would be moved to rebalance the reps",
+)
+
+var repBreakerOpenFor = flag.Duration(
+	"repBreakerOpenFor",
+	breaker.DefaultOpenFor,
+	"How long to keep a rep that fails too many requests out of auctions before trying it again; every rep is always asked if 0",
+)
+
+var repBreakerWindow = flag.Int(
+	"repBreakerWindow",
+	breaker.DefaultWindow,
+	"Number of a rep's most recent requests whose failures and timeouts count against it",
+)
+
+var repBreakerMinRequests = flag.Int(
+	"repBreakerMinRequests",
+	breaker.DefaultMinRequests,
+	"Minimum number of requests in a rep's window before it can be kept out of auctions",
+)
+
+var repBreakerFailureRate = flag.Float64(
+	"repBreakerFailureRate",
+	breaker.DefaultFailureRate,
+	"Fraction (0 to 1) of a rep's requests that must fail or time out for it to be kept out of auctions",
+)
+
+var executorRelistInterval = flag.Duration(
+	"executorRelistInterval",
+	30*time.Second,
+	"How often to re-list all executors, in case their watch missed a change",
+)
+
+var metricsAddress = flag.String(
+	"metricsAddress",
+	"",
+	"Address (ip:port) on which to serve metrics at /metrics in the Prometheus text format; no metrics are served if empty",
+)
+
+var healthAddress = flag.String(
+	"healthAddress",
+	"",
+	"Address (ip:port) on which to serve /healthz, /ready and /status; nothing is served if empty",
+)
+
+var adminAddress = flag.String(
+	"adminAddress",
+	"",
+	"Address (ip:port) on which to serve the /admin endpoints that pause, resume and step down auctioning; nothing is served if empty",
+)
+
+var adminSecret = flag.String(
+	"adminSecret",
+	"",
+	"Shared secret that requests to the /admin endpoints must carry as a bearer token",
+)
+
 var lockInterval = flag.Duration(
 	"lockInterval",
 	30*time.Second,
@@ -79,14 +309,71 @@ func main() {
 	flag.Parse()
 
 	logger := cf_lager.New("auctioneer")
-	natsClient := initializeNatsClient(logger)
-	bbs := initializeBbs(logger)
-	auctioneer := initializeAuctioneer(bbs, natsClient, logger)
 
-	process := ifrit.Envoke(auctioneer)
+	//listen before anything starts, so that an early SIGHUP isn't fatal
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	config := loadConfig(logger)
+	natsClient := initializeNatsClient(config, logger)
+	store, bbs := initializeBbs(config, logger)
+	registry := metrics.NewRegistry()
+	repBreakers := initializeRepBreakers(config, logger)
+	auctioneer, auctionClient := initializeAuctioneer(config, bbs, natsClient, registry, repBreakers, logger)
+
+	//SIGHUP stays out of the group, whose http servers exit on any signal
+	go reloadConfigOnHangup(hangups, config, auctioneer, auctionClient, logger)
+
+	members := grouper.RunGroup{
+		"auctioneer": auctioneer,
+	}
+
+	//endpoints sharing an address are served by the same listener
+	handlers := map[string]*http.ServeMux{}
+	handle := func(address string, pattern string, handler http.Handler) {
+		if handlers[address] == nil {
+			handlers[address] = http.NewServeMux()
+			members["http-"+address] = http_server.New(address, handlers[address])
+		}
+		handlers[address].Handle(pattern, handler)
+	}
+
+	if config.MetricsAddress != "" {
+		handle(config.MetricsAddress, "/metrics", registry)
+	}
+
+	if config.HealthAddress != "" {
+		healthHandler := health.NewHandler(auctioneer, map[string]health.Check{
+			"etcd": etcdCheck(store),
+			"nats": natsCheck(natsClient),
+		}, health.DefaultCheckTimeout)
+
+		handle(config.HealthAddress, "/healthz", healthHandler)
+		handle(config.HealthAddress, "/ready", healthHandler)
+		handle(config.HealthAddress, "/status", healthHandler)
+
+		if repBreakers != nil {
+			handle(config.HealthAddress, "/breakers", repBreakers)
+		}
+	}
+
+	if config.AdminAddress != "" {
+		handle(config.AdminAddress, "/admin/", admin.NewHandler(auctioneer, config.AdminSecret))
+	}
+
+	group := grouper.EnvokeGroup(members)
 	logger.Info("auctioneer.started")
 
-	monitor := ifrit.Envoke(sigmon.New(process))
+	go func() {
+		//when any member exits, take the rest down with it
+		member := <-group.Exits()
+		if member.Error != nil {
+			logger.Error("member-exited-with-failure", member.Error, lager.Data{"member": member.Name})
+		}
+		group.Signal(syscall.SIGTERM)
+	}()
+
+	monitor := ifrit.Envoke(sigmon.New(group))
 
 	err := <-monitor.Wait()
 	if err != nil {
@@ -97,27 +384,208 @@ func main() {
 	logger.Info("auctioneer.exited")
 }
 
-func initializeAuctioneer(bbs Bbs.AuctioneerBBS, natsClient yagnats.NATSClient, logger lager.Logger) *auctioneer.Auctioneer {
-	client, err := auction_nats_client.New(natsClient, *auctionNATSTimeout, *auctionRunTimeout, logger)
+func flagConfig() auctioneer.ConfigFile {
+	return auctioneer.ConfigFile{
+		EtcdCluster:   *etcdCluster,
+		NATSAddresses: *natsAddresses,
+		NATSUsername:  *natsUsername,
+		NATSPassword:  *natsPassword,
+
+		MaxConcurrent:           *maxConcurrent,
+		MaxQueuedAuctions:       *maxQueuedAuctions,
+		StartAuctionShare:       *startAuctionShare,
+		StopAuctionShare:        *stopAuctionShare,
+		MaxConcurrentPerProcess: *maxConcurrentPerProcess,
+		StartAuctionPriority:    *startAuctionPriority,
+
+		MaxRounds:              *maxRounds,
+		AuctionAlgorithm:       *auctionAlgorithm,
+		MaxBiddingPoolFraction: *maxBiddingPoolFraction,
+		MinBiddingPool:         *minBiddingPool,
+		NumChoices:             *numChoices,
+		Placement:              *placement,
+		AntiAffinity:           *antiAffinity,
+		StackStartAuctionRules: auctioneer.JSON(*stackStartAuctionRules),
+
+		StartAuctionMaxAttempts:     *startAuctionMaxAttempts,
+		StartAuctionRetryMinBackoff: auctioneer.Duration(*startAuctionRetryMinBackoff),
+		StartAuctionRetryMaxBackoff: auctioneer.Duration(*startAuctionRetryMaxBackoff),
+		StartAuctionRetryDeadline:   auctioneer.Duration(*startAuctionRetryDeadline),
+		FailedStartAuctionTTL:       auctioneer.Duration(*failedStartAuctionTTL),
+
+		NATSAuctionTimeout: auctioneer.Duration(*auctionNATSTimeout),
+		RunAuctionTimeout:  auctioneer.Duration(*auctionRunTimeout),
+		DrainTimeout:       auctioneer.Duration(*drainTimeout),
+
+		ExecutorDrainInterval:           auctioneer.Duration(*executorDrainInterval),
+		ExecutorDrainReplacementTimeout: auctioneer.Duration(*executorDrainReplacementTimeout),
+
+		RebalanceInterval:           auctioneer.Duration(*rebalanceInterval),
+		RebalanceThreshold:          *rebalanceThreshold,
+		RebalanceMaxMoves:           *rebalanceMaxMoves,
+		RebalanceMaxUnavailable:     *rebalanceMaxUnavailable,
+		RebalanceReplacementTimeout: auctioneer.Duration(*rebalanceReplacementTimeout),
+		RebalanceDryRun:             *rebalanceDryRun,
+
+		RepBreakerOpenFor:     auctioneer.Duration(*repBreakerOpenFor),
+		RepBreakerWindow:      *repBreakerWindow,
+		RepBreakerMinRequests: *repBreakerMinRequests,
+		RepBreakerFailureRate: *repBreakerFailureRate,
+
+		ExecutorRelistInterval: auctioneer.Duration(*executorRelistInterval),
+		MetricsAddress:         *metricsAddress,
+		HealthAddress:          *healthAddress,
+		AdminAddress:           *adminAddress,
+		AdminSecret:            *adminSecret,
+		LockInterval:           auctioneer.Duration(*lockInterval),
+	}
+}
+
+// loadConfig reads the config file, if there is one, over the flags
+func loadConfig(logger lager.Logger) auctioneer.ConfigFile {
+	config := flagConfig()
+	if *configFile == "" {
+		err := config.Validate()
+		if err != nil {
+			logger.Fatal("invalid-config", err)
+		}
+
+		return config
+	}
+
+	config, err := auctioneer.LoadConfigFile(*configFile, config)
+	if err != nil {
+		logger.Fatal("invalid-config-file", err)
+	}
+
+	return config
+}
+
+// reloadConfigOnHangup re-reads the config file over the flags on each
+// SIGHUP, and applies whatever can change without a restart.  A config that
+// is invalid is rejected, and the current config stays in place.
+func reloadConfigOnHangup(
+	hangups <-chan os.Signal,
+	current auctioneer.ConfigFile,
+	a *auctioneer.Auctioneer,
+	client *auction_nats_client.AuctionNATSClient,
+	logger lager.Logger,
+) {
+	logger = logger.Session("reload-config")
+
+	for _ = range hangups {
+		if *configFile == "" {
+			logger.Info("no-config-file")
+			continue
+		}
+
+		next, err := auctioneer.LoadConfigFile(*configFile, flagConfig())
+		if err != nil {
+			logger.Error("invalid-config-rejected", err)
+			continue
+		}
+
+		reloaded, changes := current.Reload(next)
+
+		reconfigure := false
+		for _, change := range changes {
+			data := lager.Data{"setting": change.Setting, "from": change.From, "to": change.To}
+			if change.RequiresRestart {
+				logger.Info("change-requires-restart", data)
+			} else {
+				logger.Info("changed", data)
+				reconfigure = true
+			}
+		}
+
+		//only settings that wait for a restart changed, and reloaded keeps
+		//current's values for those
+		if !reconfigure {
+			logger.Info("nothing-to-reconfigure")
+			continue
+		}
+
+		//a config that is rejected here must not become current, or the next
+		//reload would be compared against settings that were never applied
+		config, err := reloaded.AuctioneerConfig()
+		if err != nil {
+			logger.Error("invalid-config-rejected", err)
+			continue
+		}
+
+		a.Reconfigure(config)
+		client.SetTimeouts(time.Duration(reloaded.NATSAuctionTimeout), time.Duration(reloaded.RunAuctionTimeout))
+
+		current = reloaded
+	}
+}
+
+func initializeRepBreakers(config auctioneer.ConfigFile, logger lager.Logger) *breaker.Breakers {
+	if config.RepBreakerOpenFor == 0 {
+		return nil
+	}
+
+	return breaker.New(config.RepBreakerConfig(), logger)
+}
+
+func initializeAuctioneer(config auctioneer.ConfigFile, bbs Bbs.AuctioneerBBS, natsClient yagnats.NATSClient, registry *metrics.Registry, repBreakers *breaker.Breakers, logger lager.Logger) (*auctioneer.Auctioneer, *auction_nats_client.AuctionNATSClient) {
+	client, err := auction_nats_client.New(natsClient, time.Duration(config.NATSAuctionTimeout), time.Duration(config.RunAuctionTimeout), logger)
 	if err != nil {
 		logger.Fatal("failed-to-create-auctioneer-nats-client", err)
 	}
 
-	runner := auctionrunner.New(client)
-	return auctioneer.New(bbs, runner, *maxConcurrent, *maxRounds, *lockInterval, logger)
+	if repBreakers != nil {
+		client.SetRequestObserver(func(repGuid string, err error) {
+			repBreakers.Record(repGuid, requestOutcome(err))
+		})
+	}
+
+	auctioneerConfig, err := config.AuctioneerConfig()
+	if err != nil {
+		logger.Fatal("invalid-config", err)
+	}
+
+	reservations := algorithms.NewReservationTracker(client)
+	runner := algorithms.NewRunner(reservations, algorithms.DefaultRegistry)
+
+	if repBreakers != nil {
+		runner.SetBidObserver(func(repGuids []string) {
+			for _, repGuid := range repGuids {
+				repBreakers.Asked(repGuid)
+			}
+		})
+	}
+
+	auctioneerConfig.Metrics = registry
+	auctioneerConfig.Reservations = reservations
+	auctioneerConfig.RepPool = client
+	auctioneerConfig.RepBreakers = repBreakers
+
+	return auctioneer.New(bbs, runner, auctioneerConfig, logger), client
+}
+
+func requestOutcome(err error) breaker.Outcome {
+	switch err {
+	case nil:
+		return breaker.Succeeded
+	case nats_muxer.TimeoutError:
+		return breaker.TimedOut
+	default:
+		return breaker.Failed
+	}
 }
 
-func initializeNatsClient(logger lager.Logger) yagnats.NATSClient {
+func initializeNatsClient(config auctioneer.ConfigFile, logger lager.Logger) yagnats.NATSClient {
 	natsClient := yagnats.NewClient()
 
 	natsMembers := []yagnats.ConnectionProvider{}
-	for _, addr := range strings.Split(*natsAddresses, ",") {
+	for _, addr := range strings.Split(config.NATSAddresses, ",") {
 		natsMembers = append(
 			natsMembers,
 			&yagnats.ConnectionInfo{
 				Addr:     addr,
-				Username: *natsUsername,
-				Password: *natsPassword,
+				Username: config.NATSUsername,
+				Password: config.NATSPassword,
 			},
 		)
 	}
@@ -133,9 +601,9 @@ func initializeNatsClient(logger lager.Logger) yagnats.NATSClient {
 	return natsClient
 }
 
-func initializeBbs(logger lager.Logger) Bbs.AuctioneerBBS {
+func initializeBbs(config auctioneer.ConfigFile, logger lager.Logger) (storeadapter.StoreAdapter, Bbs.AuctioneerBBS) {
 	etcdAdapter := etcdstoreadapter.NewETCDStoreAdapter(
-		strings.Split(*etcdCluster, ","),
+		strings.Split(config.EtcdCluster, ","),
 		workerpool.NewWorkerPool(10),
 	)
 
@@ -144,5 +612,29 @@ func initializeBbs(logger lager.Logger) Bbs.AuctioneerBBS {
 		logger.Fatal("failed-to-connect-to-etcd", err)
 	}
 
-	return Bbs.NewAuctioneerBBS(etcdAdapter, timeprovider.NewTimeProvider(), logger)
+	return etcdAdapter, Bbs.NewAuctioneerBBS(etcdAdapter, timeprovider.NewTimeProvider(), logger)
+}
+
+// etcd is reachable if it can tell us about the locks directory, whether or
+// not there is one
+func etcdCheck(store storeadapter.StoreAdapter) health.Check {
+	return func() error {
+		_, err := store.Get(shared.LockSchemaRoot)
+		switch err {
+		case nil, storeadapter.ErrorKeyNotFound, storeadapter.ErrorNodeIsDirectory:
+			return nil
+		}
+
+		return err
+	}
+}
+
+func natsCheck(natsClient yagnats.NATSClient) health.Check {
+	return func() error {
+		if !natsClient.Ping() {
+			return errors.New("nats did not answer a ping")
+		}
+
+		return nil
+	}
 }
@@ -0,0 +1,382 @@
+/*
+Package metrics keeps counters, gauges and histograms and serves them over
+HTTP in the Prometheus text exposition format (version 0.0.4).
+
+Series are created against a Registry, named once, and may be split by
+labels whose values are given, in order, with each update:
+
+	registry := metrics.NewRegistry()
+	failed := registry.NewCounter("auctions_failed_total", "Failed auctions", "reason")
+	failed.Inc("insufficient-resources")
+
+	http.Handle("/metrics", registry)
+*/
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultDurationBuckets suit durations in seconds from milliseconds to a
+// minute
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+type Registry struct {
+	lock    sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		names: map[string]bool{},
+	}
+}
+
+func (r *Registry) register(m metric) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.names[m.name()] {
+		panic("metrics: " + m.name() + " is already registered")
+	}
+
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo writes every series in the text exposition format, in the order
+// they were registered
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.lock.Lock()
+	metrics := make([]metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.lock.Unlock()
+
+	buffer := &bytes.Buffer{}
+	for _, m := range metrics {
+		m.write(buffer)
+	}
+
+	return buffer.WriteTo(w)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+// series are kept by their label values, joined with a byte that can't
+// appear in UTF-8
+const labelSeparator = "\xff"
+
+type labelled struct {
+	metricName string
+	help       string
+	kind       string
+	labelNames []string
+}
+
+func (l labelled) name() string {
+	return l.metricName
+}
+
+func (l labelled) key(labelValues []string) string {
+	if len(labelValues) != len(l.labelNames) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", l.metricName, len(l.labelNames), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, labelSeparator)
+}
+
+func (l labelled) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", l.metricName, escapeHelp(l.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", l.metricName, l.kind)
+}
+
+// labels renders the label pairs for a series, followed by any extra pairs
+func (l labelled) labels(key string, extra ...string) string {
+	pairs := []string{}
+
+	if len(l.labelNames) > 0 {
+		for i, value := range strings.Split(key, labelSeparator) {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, l.labelNames[i], escapeLabelValue(value)))
+		}
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabelValue(extra[i+1])))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter only goes up
+type Counter struct {
+	labelled
+	lock   sync.Mutex
+	values map[string]float64
+}
+
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		labelled: labelled{name, help, "counter", labelNames},
+		values:   map[string]float64{},
+	}
+
+	r.register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counters can't go down")
+	}
+
+	key := c.key(labelValues)
+
+	c.lock.Lock()
+	c.values[key] += delta
+	c.lock.Unlock()
+}
+
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := c.key(labelValues)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.values[key]
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labels(key), formatFloat(c.values[key]))
+	}
+}
+
+// Gauge is either set as things change or, with NewGaugeFunc, read when the
+// metrics are served
+type Gauge struct {
+	labelled
+	lock   sync.Mutex
+	values map[string]float64
+	read   func() float64
+}
+
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{
+		labelled: labelled{name, help, "gauge", labelNames},
+		values:   map[string]float64{},
+	}
+
+	r.register(g)
+	return g
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, read func() float64) *Gauge {
+	g := &Gauge{
+		labelled: labelled{name, help, "gauge", nil},
+		values:   map[string]float64{},
+		read:     read,
+	}
+
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.lock.Lock()
+	g.values[key] = value
+	g.lock.Unlock()
+}
+
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	key := g.key(labelValues)
+
+	g.lock.Lock()
+	g.values[key] += delta
+	g.lock.Unlock()
+}
+
+func (g *Gauge) Value(labelValues ...string) float64 {
+	if g.read != nil {
+		return g.read()
+	}
+
+	key := g.key(labelValues)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.values[key]
+}
+
+func (g *Gauge) write(w io.Writer) {
+	if g.read != nil {
+		value := g.read()
+
+		g.writeHeader(w)
+		fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(value))
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.writeHeader(w)
+	for _, key := range sortedKeys(g.values) {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labels(key), formatFloat(g.values[key]))
+	}
+}
+
+// Histogram counts observations into cumulative buckets by upper bound
+type Histogram struct {
+	labelled
+	buckets []float64
+	lock    sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	h := &Histogram{
+		labelled: labelled{name, help, "histogram", labelNames},
+		buckets:  sorted,
+		series:   map[string]*histogramSeries{},
+	}
+
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+
+	series.count++
+	series.sum += value
+}
+
+// Count is the number of observations made
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := h.key(labelValues)
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	series, ok := h.series[key]
+	if !ok {
+		return 0
+	}
+
+	return series.count
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	keys := []string{}
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h.writeHeader(w)
+	for _, key := range keys {
+		series := h.series[key]
+
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(key, "le", formatFloat(bound)), series.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labels(key, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labels(key), formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labels(key), series.count)
+	}
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
@@ -0,0 +1,126 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/cloudfoundry-incubator/auctioneer/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	var registry *Registry
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+	})
+
+	exposition := func() string {
+		buffer := &bytes.Buffer{}
+		registry.WriteTo(buffer)
+		return buffer.String()
+	}
+
+	Describe("counters", func() {
+		It("should count by label", func() {
+			counter := registry.NewCounter("failed_total", "Failed auctions", "reason", "stack")
+			counter.Inc("insufficient-resources", "lucid64")
+			counter.Inc("insufficient-resources", "lucid64")
+			counter.Add(3, "no-executors", ".Net")
+
+			Ω(counter.Value("insufficient-resources", "lucid64")).Should(Equal(2.0))
+			Ω(exposition()).Should(Equal(`# HELP failed_total Failed auctions
+# TYPE failed_total counter
+failed_total{reason="insufficient-resources",stack="lucid64"} 2
+failed_total{reason="no-executors",stack=".Net"} 3
+`))
+		})
+
+		It("should refuse to go down", func() {
+			counter := registry.NewCounter("started_total", "Started auctions")
+			Ω(func() { counter.Add(-1) }).Should(Panic())
+		})
+
+		It("should insist on a value for every label", func() {
+			counter := registry.NewCounter("started_total", "Started auctions", "stack")
+			Ω(func() { counter.Inc() }).Should(Panic())
+		})
+	})
+
+	Describe("gauges", func() {
+		It("should report the last value set", func() {
+			gauge := registry.NewGauge("in_flight", "Auctions in flight", "kind")
+			gauge.Set(3, "start")
+			gauge.Add(-1, "start")
+
+			Ω(gauge.Value("start")).Should(Equal(2.0))
+			Ω(exposition()).Should(ContainSubstring("in_flight{kind=\"start\"} 2\n"))
+		})
+
+		It("should read gauge funcs when written", func() {
+			value := 1.0
+			registry.NewGaugeFunc("lock_held", "Whether the lock is held", func() float64 { return value })
+
+			value = 0.5
+			Ω(exposition()).Should(Equal(`# HELP lock_held Whether the lock is held
+# TYPE lock_held gauge
+lock_held 0.5
+`))
+		})
+	})
+
+	Describe("histograms", func() {
+		It("should count observations into cumulative buckets", func() {
+			histogram := registry.NewHistogram("rounds", "Rounds per auction", []float64{5, 1, 2})
+			histogram.Observe(1)
+			histogram.Observe(3)
+			histogram.Observe(10)
+
+			Ω(histogram.Count()).Should(Equal(uint64(3)))
+			Ω(exposition()).Should(Equal(`# HELP rounds Rounds per auction
+# TYPE rounds histogram
+rounds_bucket{le="1"} 1
+rounds_bucket{le="2"} 1
+rounds_bucket{le="5"} 2
+rounds_bucket{le="+Inf"} 3
+rounds_sum 14
+rounds_count 3
+`))
+		})
+
+		It("should put the bucket bound after the other labels", func() {
+			histogram := registry.NewHistogram("wait_seconds", "Queue wait", []float64{1}, "kind")
+			histogram.Observe(0.5, "stop")
+
+			Ω(exposition()).Should(ContainSubstring(`wait_seconds_bucket{kind="stop",le="1"} 1`))
+			Ω(exposition()).Should(ContainSubstring(`wait_seconds_sum{kind="stop"} 0.5`))
+		})
+	})
+
+	It("should escape help text and label values", func() {
+		counter := registry.NewCounter("escaped_total", "back\\slash\nnewline", "value")
+		counter.Inc("a \"quoted\"\nvalue")
+
+		Ω(exposition()).Should(ContainSubstring(`# HELP escaped_total back\\slash\nnewline`))
+		Ω(exposition()).Should(ContainSubstring(`escaped_total{value="a \"quoted\"\nvalue"} 1`))
+	})
+
+	It("should refuse to register a name twice", func() {
+		registry.NewCounter("started_total", "Started auctions")
+		Ω(func() { registry.NewGauge("started_total", "Started auctions") }).Should(Panic())
+	})
+
+	It("should serve the metrics over HTTP", func() {
+		registry.NewCounter("started_total", "Started auctions").Inc()
+
+		recorder := httptest.NewRecorder()
+		registry.ServeHTTP(recorder, &http.Request{Method: "GET"})
+
+		Ω(recorder.Code).Should(Equal(http.StatusOK))
+		Ω(recorder.HeaderMap.Get("Content-Type")).Should(Equal("text/plain; version=0.0.4"))
+		Ω(recorder.Body.String()).Should(ContainSubstring("started_total 1\n"))
+	})
+})
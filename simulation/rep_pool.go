@@ -0,0 +1,349 @@
+package simulation
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+)
+
+var ErrInsufficientResources = errors.New("insufficient resources")
+var ErrNoSuchRep = errors.New("no such rep")
+
+/*
+
+RepPool is an in-process stand-in for a pool of reps reached over NATS.  It
+bids and reserves the way a rep would, and records every message sent to it so
+that algorithms can be checked for what they asked of the pool as well as
+where they placed instances.
+
+Reps that aren't in the pool refuse every start auction.
+
+*/
+
+type RepPool struct {
+	lock *sync.Mutex
+	reps map[string]*rep
+
+	communications int
+	contacted      map[string]bool
+}
+
+type rep struct {
+	total        auctiontypes.Resources
+	instances    []auctiontypes.SimulatedInstance
+	reservations []auctiontypes.StartAuctionInfo
+}
+
+func NewRepPool(reps map[string]auctiontypes.Resources) *RepPool {
+	pool := &RepPool{
+		lock:      &sync.Mutex{},
+		reps:      map[string]*rep{},
+		contacted: map[string]bool{},
+	}
+
+	for repGuid, total := range reps {
+		pool.reps[repGuid] = &rep{total: total}
+	}
+
+	return pool
+}
+
+func (p *RepPool) BidForStartAuction(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	bids := auctiontypes.StartAuctionBids{}
+	for _, repGuid := range repGuids {
+		p.contact(repGuid)
+
+		r, ok := p.reps[repGuid]
+		if !ok {
+			bids = append(bids, auctiontypes.StartAuctionBid{Rep: repGuid, Error: ErrNoSuchRep.Error()})
+			continue
+		}
+
+		bids = append(bids, r.bid(repGuid, startAuctionInfo))
+	}
+
+	return bids
+}
+
+func (p *RepPool) RebidThenTentativelyReserve(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBids {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	bids := auctiontypes.StartAuctionBids{}
+	for _, repGuid := range repGuids {
+		p.contact(repGuid)
+
+		r, ok := p.reps[repGuid]
+		if !ok {
+			bids = append(bids, auctiontypes.StartAuctionBid{Rep: repGuid, Error: ErrNoSuchRep.Error()})
+			continue
+		}
+
+		bid := r.bid(repGuid, startAuctionInfo)
+		if bid.Error == "" {
+			r.reservations = append(r.reservations, startAuctionInfo)
+		}
+
+		bids = append(bids, bid)
+	}
+
+	return bids
+}
+
+func (p *RepPool) ReleaseReservation(repGuids []string, startAuctionInfo auctiontypes.StartAuctionInfo) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, repGuid := range repGuids {
+		p.contact(repGuid)
+
+		if r, ok := p.reps[repGuid]; ok {
+			r.release(startAuctionInfo)
+		}
+	}
+}
+
+func (p *RepPool) Run(repGuid string, startAuction models.LRPStartAuction) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.contact(repGuid)
+
+	r, ok := p.reps[repGuid]
+	if !ok {
+		return
+	}
+
+	info := auctiontypes.NewStartAuctionInfoFromLRPStartAuction(startAuction)
+	if !r.release(info) && !r.fits(info) {
+		return
+	}
+
+	r.instances = append(r.instances, auctiontypes.SimulatedInstance{
+		ProcessGuid:  startAuction.ProcessGuid,
+		InstanceGuid: startAuction.InstanceGuid,
+		Index:        startAuction.Index,
+		MemoryMB:     startAuction.MemoryMB,
+		DiskMB:       startAuction.DiskMB,
+	})
+}
+
+func (p *RepPool) BidForStopAuction(repGuids []string, stopAuctionInfo auctiontypes.StopAuctionInfo) auctiontypes.StopAuctionBids {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	bids := auctiontypes.StopAuctionBids{}
+	for _, repGuid := range repGuids {
+		p.contact(repGuid)
+
+		r, ok := p.reps[repGuid]
+		if !ok {
+			continue
+		}
+
+		instanceGuids := []string{}
+		for _, instance := range r.instances {
+			if instance.ProcessGuid == stopAuctionInfo.ProcessGuid && instance.Index == stopAuctionInfo.Index {
+				instanceGuids = append(instanceGuids, instance.InstanceGuid)
+			}
+		}
+
+		if len(instanceGuids) == 0 {
+			continue
+		}
+
+		bids = append(bids, auctiontypes.StopAuctionBid{
+			Rep:           repGuid,
+			InstanceGuids: instanceGuids,
+			Bid:           r.score(),
+		})
+	}
+
+	return bids
+}
+
+func (p *RepPool) Stop(repGuid string, stopInstance models.StopLRPInstance) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.contact(repGuid)
+
+	r, ok := p.reps[repGuid]
+	if !ok {
+		return
+	}
+
+	for i, instance := range r.instances {
+		if instance.InstanceGuid == stopInstance.InstanceGuid {
+			r.instances = append(r.instances[:i], r.instances[i+1:]...)
+			return
+		}
+	}
+}
+
+//SIMULATION ONLY METHODS:
+
+func (p *RepPool) TotalResources(repGuid string) auctiontypes.Resources {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.reps[repGuid].total
+}
+
+func (p *RepPool) RemainingResources(repGuid string) auctiontypes.Resources {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.reps[repGuid].remaining()
+}
+
+func (p *RepPool) SimulatedInstances(repGuid string) []auctiontypes.SimulatedInstance {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	instances := make([]auctiontypes.SimulatedInstance, len(p.reps[repGuid].instances))
+	copy(instances, p.reps[repGuid].instances)
+
+	return instances
+}
+
+func (p *RepPool) SetSimulatedInstances(repGuid string, instances []auctiontypes.SimulatedInstance) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.reps[repGuid].instances = instances
+}
+
+func (p *RepPool) Reset(repGuid string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.reps[repGuid].instances = nil
+	p.reps[repGuid].reservations = nil
+}
+
+// Reservations returns the reservations that have been neither run nor released
+func (p *RepPool) Reservations(repGuid string) []auctiontypes.StartAuctionInfo {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.reps[repGuid].reservations
+}
+
+// Communications is the number of rep messages sent since the last ResetCommunications
+func (p *RepPool) Communications() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.communications
+}
+
+// Contacted is the set of reps messaged since the last ResetCommunications,
+// including reps that are not in the pool
+func (p *RepPool) Contacted() map[string]bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	contacted := map[string]bool{}
+	for repGuid := range p.contacted {
+		contacted[repGuid] = true
+	}
+
+	return contacted
+}
+
+func (p *RepPool) ResetCommunications() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.communications = 0
+	p.contacted = map[string]bool{}
+}
+
+func (p *RepPool) contact(repGuid string) {
+	p.communications++
+	p.contacted[repGuid] = true
+}
+
+func (r *rep) remaining() auctiontypes.Resources {
+	remaining := r.total
+
+	for _, instance := range r.instances {
+		remaining.MemoryMB -= instance.MemoryMB
+		remaining.DiskMB -= instance.DiskMB
+		remaining.Containers--
+	}
+
+	for _, reservation := range r.reservations {
+		remaining.MemoryMB -= reservation.MemoryMB
+		remaining.DiskMB -= reservation.DiskMB
+		remaining.Containers--
+	}
+
+	return remaining
+}
+
+func (r *rep) fits(info auctiontypes.StartAuctionInfo) bool {
+	remaining := r.remaining()
+	return remaining.MemoryMB >= info.MemoryMB && remaining.DiskMB >= info.DiskMB && remaining.Containers >= 1
+}
+
+// the fraction of the rep in use, averaged over memory, disk and containers;
+// lower bids win
+func (r *rep) score() float64 {
+	remaining := r.remaining()
+
+	used := 0.0
+	used += 1 - float64(remaining.MemoryMB)/float64(r.total.MemoryMB)
+	used += 1 - float64(remaining.DiskMB)/float64(r.total.DiskMB)
+	used += 1 - float64(remaining.Containers)/float64(r.total.Containers)
+
+	return used / 3
+}
+
+func (r *rep) bid(repGuid string, info auctiontypes.StartAuctionInfo) auctiontypes.StartAuctionBid {
+	if !r.fits(info) {
+		return auctiontypes.StartAuctionBid{Rep: repGuid, Error: ErrInsufficientResources.Error()}
+	}
+
+	return auctiontypes.StartAuctionBid{
+		Rep:          repGuid,
+		Bid:          r.score(),
+		Resources:    r.remaining(),
+		NumInstances: r.numInstances(info.ProcessGuid),
+	}
+}
+
+func (r *rep) numInstances(processGuid string) int {
+	n := 0
+
+	for _, instance := range r.instances {
+		if instance.ProcessGuid == processGuid {
+			n++
+		}
+	}
+
+	for _, reservation := range r.reservations {
+		if reservation.ProcessGuid == processGuid {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (r *rep) release(info auctiontypes.StartAuctionInfo) bool {
+	for i, reservation := range r.reservations {
+		if reservation.InstanceGuid == info.InstanceGuid {
+			r.reservations = append(r.reservations[:i], r.reservations[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
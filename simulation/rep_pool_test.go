@@ -0,0 +1,78 @@
+package simulation_test
+
+import (
+	"github.com/cloudfoundry-incubator/auction/auctiontypes"
+	. "github.com/cloudfoundry-incubator/auctioneer/simulation"
+	"github.com/cloudfoundry-incubator/runtime-schema/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RepPool", func() {
+	var pool *RepPool
+	var startAuction models.LRPStartAuction
+	var info auctiontypes.StartAuctionInfo
+
+	BeforeEach(func() {
+		pool = NewRepPool(map[string]auctiontypes.Resources{
+			"a": {MemoryMB: 100, DiskMB: 100, Containers: 2},
+			"b": {MemoryMB: 100, DiskMB: 100, Containers: 2},
+		})
+
+		startAuction = models.LRPStartAuction{ProcessGuid: "pg", InstanceGuid: "ig", MemoryMB: 50, DiskMB: 50}
+		info = auctiontypes.NewStartAuctionInfoFromLRPStartAuction(startAuction)
+	})
+
+	It("bids lower on emptier reps", func() {
+		pool.SetSimulatedInstances("a", []auctiontypes.SimulatedInstance{{ProcessGuid: "other", InstanceGuid: "x", MemoryMB: 10, DiskMB: 10}})
+
+		bids := pool.BidForStartAuction([]string{"a", "b"}, info)
+		Ω(bids).Should(HaveLen(2))
+		Ω(bids[0].Bid).Should(BeNumerically(">", bids[1].Bid))
+	})
+
+	It("refuses start auctions that don't fit, and start auctions for reps it doesn't have", func() {
+		startAuction.MemoryMB = 101
+		bids := pool.BidForStartAuction([]string{"a", "c"}, auctiontypes.NewStartAuctionInfoFromLRPStartAuction(startAuction))
+		Ω(bids[0].Error).Should(Equal(ErrInsufficientResources.Error()))
+		Ω(bids[1].Error).Should(Equal(ErrNoSuchRep.Error()))
+	})
+
+	It("holds resources for reservations until they are released or run", func() {
+		pool.RebidThenTentativelyReserve([]string{"a", "b"}, info)
+		Ω(pool.RemainingResources("a")).Should(Equal(auctiontypes.Resources{MemoryMB: 50, DiskMB: 50, Containers: 1}))
+
+		pool.ReleaseReservation([]string{"b"}, info)
+		Ω(pool.Reservations("b")).Should(BeEmpty())
+		Ω(pool.RemainingResources("b").MemoryMB).Should(Equal(100))
+
+		pool.Run("a", startAuction)
+		Ω(pool.Reservations("a")).Should(BeEmpty())
+		Ω(pool.SimulatedInstances("a")).Should(HaveLen(1))
+		Ω(pool.RemainingResources("a").MemoryMB).Should(Equal(50))
+	})
+
+	It("stops instances", func() {
+		pool.Run("a", startAuction)
+
+		bids := pool.BidForStopAuction([]string{"a", "b"}, auctiontypes.StopAuctionInfo{ProcessGuid: "pg", Index: 0})
+		Ω(bids).Should(HaveLen(1))
+		Ω(bids[0].InstanceGuids).Should(Equal([]string{"ig"}))
+
+		pool.Stop("a", models.StopLRPInstance{ProcessGuid: "pg", InstanceGuid: "ig", Index: 0})
+		Ω(pool.SimulatedInstances("a")).Should(BeEmpty())
+	})
+
+	It("counts the messages it receives and who received them", func() {
+		pool.BidForStartAuction([]string{"a", "b"}, info)
+		pool.Run("a", startAuction)
+
+		Ω(pool.Communications()).Should(Equal(3))
+		Ω(pool.Contacted()).Should(Equal(map[string]bool{"a": true, "b": true}))
+
+		pool.ResetCommunications()
+		Ω(pool.Communications()).Should(BeZero())
+		Ω(pool.Contacted()).Should(BeEmpty())
+	})
+})